@@ -0,0 +1,77 @@
+package jambon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// positionFlags are shared by commands which can express positions relative
+//  to a bullseye or a reference aircraft
+var positionFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "bullseye",
+		Usage: "express positions relative to a `lat,lon` bullseye, or the bullseye object of the given coalition (or 'any')",
+	},
+	&cli.StringFlag{
+		Name:  "braa-from",
+		Usage: "express positions as BRAA from the aircraft with the given pilot name or hexadecimal object id",
+	},
+}
+
+// positionReferenceFromContext returns the position reference configured by
+//  the positionFlags, or nil when none was provided
+func positionReferenceFromContext(ctx *cli.Context) (*ops.PositionReference, error) {
+	return ops.ParsePositionReference(ctx.String("bullseye"), ctx.String("braa-from"))
+}
+
+// positionColumns are the CSV columns written by positionRecord. Bearings are
+//  in degrees true, ranges and altitudes in meters.
+var positionColumns = []string{
+	"bullseye_bearing", "bullseye_range", "bullseye_altitude",
+	"braa_bearing", "braa_range", "braa_altitude", "braa_aspect",
+}
+
+// positionRecord returns the CSV columns of the bullseye and BRAA calls, which
+//  are left empty for a call that is nil
+func positionRecord(bullseye *ops.BullseyeCall, braa *ops.BRAACall) []string {
+	record := make([]string, 0, len(positionColumns))
+	if bullseye != nil {
+		record = append(
+			record,
+			fmt.Sprintf("%.0f", bullseye.Bearing),
+			fmt.Sprintf("%.0f", bullseye.Range),
+			fmt.Sprintf("%.0f", bullseye.Altitude),
+		)
+	} else {
+		record = append(record, "", "", "")
+	}
+
+	if braa != nil {
+		record = append(
+			record,
+			fmt.Sprintf("%.0f", braa.Bearing),
+			fmt.Sprintf("%.0f", braa.Range),
+			fmt.Sprintf("%.0f", braa.Altitude),
+			braa.Aspect,
+		)
+	} else {
+		record = append(record, "", "", "", "")
+	}
+	return record
+}
+
+// formatPositionCalls returns the bullseye and BRAA calls as text, or an empty
+//  string when neither is known
+func formatPositionCalls(bullseye *ops.BullseyeCall, braa *ops.BRAACall) string {
+	calls := make([]string, 0, 2)
+	if bullseye != nil {
+		calls = append(calls, bullseye.String())
+	}
+	if braa != nil {
+		calls = append(calls, braa.String())
+	}
+	return strings.Join(calls, ", ")
+}
@@ -17,6 +17,16 @@ func main() {
 			&jambon.CommandTrim,
 			&jambon.CommandNormalize,
 			&jambon.CommandRecord,
+			&jambon.CommandPilots,
+			&jambon.CommandSorties,
+			&jambon.CommandShots,
+			&jambon.CommandImpacts,
+			&jambon.CommandEncounters,
+			&jambon.CommandLandings,
+			&jambon.CommandFormations,
+			&jambon.CommandEnrich,
+			&jambon.CommandPicture,
+			&jambon.CommandTargeting,
 		},
 	}
 
@@ -0,0 +1,162 @@
+package jambon
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandEncounters handles reporting close encounters between aircraft
+var CommandEncounters = cli.Command{
+	Name:        "encounters",
+	Description: "report close encounters and probable mid-air collisions between aircraft",
+	Action:      commandEncounters,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringFlag{
+			Name:  "distance",
+			Usage: "report encounters where aircraft come closer than this distance",
+			Value: "150m",
+		},
+		&cli.Float64Flag{
+			Name:  "collision-window",
+			Usage: "seconds after an encounter in which both aircraft being removed is considered a collision",
+			Value: 3,
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report encounters involving the pilot with the given name",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "output data as CSV",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	}, positionFlags...),
+}
+
+func commandEncounters(ctx *cli.Context) error {
+	reference, err := positionReferenceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	distance, err := ops.ParseDistance(ctx.String("distance"))
+	if err != nil {
+		return err
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	encounters, err := ops.Encounters(ctx.Context, inputs.readers(), ops.EncountersOptions{
+		Options:         inputs.options(ctx.Int("concurrency")),
+		Distance:        distance,
+		CollisionWindow: ctx.Float64("collision-window"),
+		Pilots:          ctx.StringSlice("pilot"),
+		Reference:       reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(encounters)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	if ctx.Bool("csv") {
+		writer := csv.NewWriter(os.Stdout)
+		columns := []string{
+			"closest_approach", "first_id", "first", "first_pilot", "second_id", "second", "second_pilot",
+			"duration", "distance", "altitude_difference", "relative_speed", "probable_collision",
+		}
+		if reference != nil {
+			columns = append(columns, positionColumns...)
+		}
+		writer.Write(columns)
+
+		for _, encounter := range encounters {
+			record := []string{
+				ops.OffsetTime(encounter.ReferenceTime, encounter.ClosestApproach).Format(time.RFC3339),
+				fmt.Sprintf("%v", encounter.FirstId),
+				encounter.First,
+				encounter.FirstPilot,
+				fmt.Sprintf("%v", encounter.SecondId),
+				encounter.Second,
+				encounter.SecondPilot,
+				fmt.Sprintf("%.1f", encounter.End-encounter.Start),
+				fmt.Sprintf("%.1f", encounter.Distance),
+				fmt.Sprintf("%.1f", encounter.AltitudeDifference),
+				fmt.Sprintf("%.1f", encounter.RelativeSpeed),
+				fmt.Sprintf("%v", encounter.ProbableCollision),
+			}
+			if reference != nil {
+				record = append(record, positionRecord(encounter.Bullseye, encounter.BRAA)...)
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(writer, "TIME\tFIRST\tSECOND\tDURATION\tDISTANCE (FT)\tALT DIFF (FT)\tREL SPEED (KTS)\tCOLLISION")
+	if reference != nil {
+		fmt.Fprint(writer, "\tPOSITION")
+	}
+	fmt.Fprintln(writer)
+	for _, encounter := range encounters {
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%v\t%.0f\t%.0f\t%.0f\t%v",
+			ops.OffsetTime(encounter.ReferenceTime, encounter.ClosestApproach).Format(time.RFC3339),
+			encounterParticipant(encounter.First, encounter.FirstPilot),
+			encounterParticipant(encounter.Second, encounter.SecondPilot),
+			ops.FormatSeconds(encounter.End-encounter.Start),
+			ops.MetersToFeet(encounter.Distance),
+			ops.MetersToFeet(encounter.AltitudeDifference),
+			ops.MetersPerSecondToKnots(encounter.RelativeSpeed),
+			encounter.ProbableCollision,
+		)
+		if reference != nil {
+			fmt.Fprintf(writer, "\t%v", formatPositionCalls(encounter.Bullseye, encounter.BRAA))
+		}
+		fmt.Fprintln(writer)
+	}
+	return writer.Flush()
+}
+
+func encounterParticipant(name, pilot string) string {
+	if pilot == "" {
+		return name
+	}
+	return fmt.Sprintf("%v (%v)", pilot, name)
+}
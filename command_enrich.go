@@ -0,0 +1,62 @@
+package jambon
+
+import (
+	"runtime"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+const enrichDescription = `Rewrite an ACMI file adding kinematic properties derived from the successive
+ transforms of each object. The approximate load factor is written as the
+ standard VerticalGForce property. Speed and track are measured over the ground,
+ no wind is known, so instead of TAS and HDG they are written with the custom
+ keys GroundSpeed and VerticalSpeed (meters per second), GroundTrack (degrees)
+ and TurnRate (degrees per second). Properties already recorded for an object
+ are never overwritten.`
+
+// CommandEnrich handles adding derived kinematics to ACMI files
+var CommandEnrich = cli.Command{
+	Name:        "enrich",
+	Description: enrichDescription,
+	Action:      commandEnrich,
+	Flags: []cli.Flag{
+		&cli.PathFlag{
+			Name:     "input",
+			Usage:    "path to the input ACMI file",
+			Required: true,
+		},
+		&cli.PathFlag{
+			Name:     "output",
+			Usage:    "path to the output ACMI file",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	},
+}
+
+func commandEnrich(ctx *cli.Context) error {
+	inputs, err := openInputFiles(ctx.Path("input"))
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	outputFile, err := openWritableTacView(ctx.Path("output"))
+	if err != nil {
+		return err
+	}
+
+	err = ops.Enrich(ctx.Context, inputs.readers()[0], outputFile, ops.EnrichOptions{
+		Options: inputs.options(ctx.Int("concurrency")),
+	})
+	closeErr := outputFile.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,131 @@
+package jambon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandFormations handles reporting formation flying and air-to-air refuelling
+var CommandFormations = cli.Command{
+	Name:        "formations",
+	Description: "report periods of formation flight and air-to-air refuelling",
+	Action:      commandFormations,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringFlag{
+			Name:  "max-spacing",
+			Usage: "maximum distance between aircraft flying in formation",
+			Value: "500m",
+		},
+		&cli.StringFlag{
+			Name:  "tolerance",
+			Usage: "maximum distance a wingman may stray from their average position in the formation",
+			Value: "50m",
+		},
+		&cli.Float64Flag{
+			Name:  "min-duration",
+			Usage: "minimum number of seconds a formation must be held to be reported",
+			Value: ops.DefaultFormationMinDuration,
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report formations involving the pilot with the given name",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	},
+}
+
+func commandFormations(ctx *cli.Context) error {
+	maxSpacing, err := ops.ParseDistance(ctx.String("max-spacing"))
+	if err != nil {
+		return err
+	}
+
+	tolerance, err := ops.ParseDistance(ctx.String("tolerance"))
+	if err != nil {
+		return err
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	result, err := ops.Formations(ctx.Context, inputs.readers(), ops.FormationsOptions{
+		Options:     inputs.options(ctx.Int("concurrency")),
+		MaxSpacing:  maxSpacing,
+		Tolerance:   tolerance,
+		MinDuration: ctx.Float64("min-duration"),
+		Pilots:      ctx.StringSlice("pilot"),
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "START\tLEAD\tWINGMAN\tDURATION\tPOSITION (FWD/RIGHT/UP FT)\tDEVIATION (FT)\tSTABILITY (FT)")
+	for _, formation := range result.Formations {
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%v\t%v\t%v\t%.0f\n",
+			ops.OffsetTime(formation.ReferenceTime, formation.Start).Format(time.RFC3339),
+			encounterParticipant(formation.Lead, formation.LeadPilot),
+			encounterParticipant(formation.Wingman, formation.WingmanPilot),
+			ops.FormatSeconds(formation.Duration),
+			formatRelativePosition(formation.Position),
+			formatRelativePosition(formation.Deviation),
+			ops.MetersToFeet(formation.Stability),
+		)
+	}
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "START\tTANKER\tRECEIVER\tCONTACTS\tCONTACT TIME\tPOSITION (FWD/RIGHT/UP FT)\tDEVIATION (FT)\tSTABILITY (FT)")
+	for _, refueling := range result.Refuelings {
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%v\t%v\t%v\t%v\t%.0f\n",
+			ops.OffsetTime(refueling.ReferenceTime, refueling.Start).Format(time.RFC3339),
+			encounterParticipant(refueling.Tanker, refueling.TankerPilot),
+			encounterParticipant(refueling.Receiver, refueling.ReceiverPilot),
+			refueling.Contacts,
+			ops.FormatSeconds(refueling.ContactTime),
+			formatRelativePosition(refueling.Position),
+			formatRelativePosition(refueling.Deviation),
+			ops.MetersToFeet(refueling.Stability),
+		)
+	}
+	return writer.Flush()
+}
+
+func formatRelativePosition(position ops.RelativePosition) string {
+	return fmt.Sprintf("%.0f/%.0f/%.0f", ops.MetersToFeet(position.Forward), ops.MetersToFeet(position.Right), ops.MetersToFeet(position.Up))
+}
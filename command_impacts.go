@@ -0,0 +1,191 @@
+package jambon
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandImpacts handles reporting air-to-ground weapon impact accuracy
+var CommandImpacts = cli.Command{
+	Name:        "impacts",
+	Description: "report bomb and rocket impact accuracy and per-pilot CEP",
+	Action:      commandImpacts,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringFlag{
+			Name:  "target",
+			Usage: "measure impacts against a fixed `lat,lon` target instead of the nearest ground object",
+		},
+		&cli.StringFlag{
+			Name:  "max-target-distance",
+			Usage: "maximum distance from an impact to the nearest ground object for it to be considered the target",
+			Value: "1km",
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report impacts from the pilot with the given name",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "output impacts as CSV",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	}, positionFlags...),
+}
+
+func commandImpacts(ctx *cli.Context) error {
+	reference, err := positionReferenceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	maxTargetDistance, err := ops.ParseDistance(ctx.String("max-target-distance"))
+	if err != nil {
+		return err
+	}
+
+	var targetLatitude, targetLongitude *float64
+	if ctx.IsSet("target") {
+		latitude, longitude, err := ops.ParseLatLon(ctx.String("target"))
+		if err != nil {
+			return err
+		}
+		targetLatitude, targetLongitude = &latitude, &longitude
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	result, err := ops.Impacts(ctx.Context, inputs.readers(), ops.ImpactsOptions{
+		Options:           inputs.options(ctx.Int("concurrency")),
+		TargetLatitude:    targetLatitude,
+		TargetLongitude:   targetLongitude,
+		MaxTargetDistance: maxTargetDistance,
+		Pilots:            ctx.StringSlice("pilot"),
+		Reference:         reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	if ctx.Bool("csv") {
+		writer := csv.NewWriter(os.Stdout)
+		columns := []string{
+			"release", "weapon", "launcher_id", "launcher", "pilot", "release_altitude", "release_speed",
+			"dive_angle", "release_track", "impact_latitude", "impact_longitude", "target_id", "target",
+			"miss_distance", "range_error", "deflection_error",
+		}
+		if reference != nil {
+			columns = append(columns, positionColumns...)
+		}
+		writer.Write(columns)
+
+		for _, impact := range result.Impacts {
+			record := []string{
+				ops.OffsetTime(impact.ReferenceTime, impact.Release).Format(time.RFC3339),
+				impact.Weapon,
+				fmt.Sprintf("%v", impact.LauncherId),
+				impact.Launcher,
+				impact.Pilot,
+				fmt.Sprintf("%.0f", impact.ReleaseAltitude),
+				fmt.Sprintf("%.0f", impact.ReleaseSpeed),
+				fmt.Sprintf("%.1f", impact.DiveAngle),
+				fmt.Sprintf("%.0f", impact.ReleaseTrack),
+				fmt.Sprintf("%v", impact.ImpactLatitude),
+				fmt.Sprintf("%v", impact.ImpactLongitude),
+				fmt.Sprintf("%v", impact.TargetId),
+				impact.Target,
+				formatOptionalMeters(impact.MissDistance),
+				formatOptionalMeters(impact.RangeError),
+				formatOptionalMeters(impact.DeflectionError),
+			}
+			if reference != nil {
+				record = append(record, positionRecord(impact.Bullseye, impact.BRAA)...)
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(writer, "RELEASE\tWEAPON\tPILOT\tALT (FT)\tSPEED (KTS)\tDIVE\tTARGET\tMISS (M)\tRANGE (M)\tDEFLECTION (M)")
+	if reference != nil {
+		fmt.Fprint(writer, "\tIMPACT POSITION")
+	}
+	fmt.Fprintln(writer)
+	for _, impact := range result.Impacts {
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%.0f\t%.0f\t%.1f\t%v\t%v\t%v\t%v",
+			ops.OffsetTime(impact.ReferenceTime, impact.Release).Format(time.RFC3339),
+			impact.Weapon,
+			impact.Pilot,
+			ops.MetersToFeet(impact.ReleaseAltitude),
+			ops.MetersPerSecondToKnots(impact.ReleaseSpeed),
+			impact.DiveAngle,
+			impact.Target,
+			formatOptionalMeters(impact.MissDistance),
+			formatOptionalMeters(impact.RangeError),
+			formatOptionalMeters(impact.DeflectionError),
+		)
+		if reference != nil {
+			fmt.Fprintf(writer, "\t%v", formatPositionCalls(impact.Bullseye, impact.BRAA))
+		}
+		fmt.Fprintln(writer)
+	}
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "PILOT\tIMPACTS\tCEP (M)\tMEAN RANGE (M)\tMEAN DEFLECTION (M)")
+	for _, pilotAccuracy := range result.Pilots {
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%.1f\t%.1f\t%.1f\n",
+			pilotAccuracy.Pilot,
+			pilotAccuracy.Impacts,
+			pilotAccuracy.CEP,
+			pilotAccuracy.MeanRangeError,
+			pilotAccuracy.MeanDeflectionError,
+		)
+	}
+	return writer.Flush()
+}
+
+func formatOptionalMeters(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", *value)
+}
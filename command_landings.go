@@ -0,0 +1,229 @@
+package jambon
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandLandings handles grading runway and carrier landings
+var CommandLandings = cli.Command{
+	Name:        "landings",
+	Description: "grade each landing using touchdown, sink rate, glideslope and lineup",
+	Action:      commandLandings,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "runway",
+			Usage: "runway threshold as `lat,lon,heading,elevation[,name]` (elevation in meters)",
+		},
+		&cli.Float64Flag{
+			Name:  "glideslope",
+			Usage: "nominal runway glideslope in degrees",
+			Value: ops.DefaultGlideslope,
+		},
+		&cli.Float64Flag{
+			Name:  "carrier-glideslope",
+			Usage: "nominal carrier glideslope in degrees",
+			Value: ops.DefaultCarrierGlideslope,
+		},
+		&cli.StringFlag{
+			Name:      "trace",
+			Usage:     "write the final approach of every landing to the given CSV file",
+			TakesFile: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report landings by the pilot with the given name",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "output data as CSV",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	}, positionFlags...),
+}
+
+func commandLandings(ctx *cli.Context) error {
+	reference, err := positionReferenceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	runways := make([]*ops.Runway, 0)
+	for _, value := range ctx.StringSlice("runway") {
+		runway, err := ops.ParseRunway(value)
+		if err != nil {
+			return err
+		}
+		runways = append(runways, runway)
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	landings, err := ops.Landings(ctx.Context, inputs.readers(), ops.LandingsOptions{
+		Options:           inputs.options(ctx.Int("concurrency")),
+		Runways:           runways,
+		Glideslope:        ctx.Float64("glideslope"),
+		CarrierGlideslope: ctx.Float64("carrier-glideslope"),
+		Pilots:            ctx.StringSlice("pilot"),
+		Reference:         reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	if path := ctx.String("trace"); path != "" {
+		err := writeApproachTrace(path, landings)
+		if err != nil {
+			return fmt.Errorf("Failed to write approach trace: %v", err)
+		}
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(landings)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	if ctx.Bool("csv") {
+		writer := csv.NewWriter(os.Stdout)
+		columns := []string{
+			"touchdown", "object_id", "pilot", "airframe", "reference", "reference_type", "touchdown_distance",
+			"touchdown_lateral", "sink_rate", "touchdown_speed", "aoa", "glideslope_deviation",
+			"max_glideslope_deviation", "lineup_deviation", "max_lineup_deviation", "grade",
+		}
+		if reference != nil {
+			columns = append(columns, positionColumns...)
+		}
+		writer.Write(columns)
+
+		for _, landing := range landings {
+			record := []string{
+				ops.OffsetTime(landing.ReferenceTime, landing.Touchdown).Format(time.RFC3339),
+				fmt.Sprintf("%v", landing.ObjectId),
+				landing.Pilot,
+				landing.Airframe,
+				landing.Reference,
+				landing.ReferenceType,
+				fmt.Sprintf("%.1f", landing.TouchdownDistance),
+				fmt.Sprintf("%.1f", landing.TouchdownLateral),
+				fmt.Sprintf("%.2f", landing.SinkRate),
+				fmt.Sprintf("%.1f", landing.TouchdownSpeed),
+				formatOptionalFloat(landing.AOA, "%.1f"),
+				formatOptionalFloat(landing.GlideslopeDeviation, "%.2f"),
+				formatOptionalFloat(landing.MaxGlideslopeDeviation, "%.2f"),
+				formatOptionalFloat(landing.LineupDeviation, "%.1f"),
+				formatOptionalFloat(landing.MaxLineupDeviation, "%.1f"),
+				landing.Grade,
+			}
+			if reference != nil {
+				record = append(record, positionRecord(landing.Bullseye, landing.BRAA)...)
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(writer, "TOUCHDOWN\tPILOT\tAIRFRAME\tREFERENCE\tDISTANCE (FT)\tLATERAL (FT)\tSINK (FPM)\tSPEED (KTS)\tAOA\tGS DEV\tLINEUP (FT)\tGRADE")
+	if reference != nil {
+		fmt.Fprint(writer, "\tTOUCHDOWN POSITION")
+	}
+	fmt.Fprintln(writer)
+	for _, landing := range landings {
+		var lineup *float64
+		if landing.MaxLineupDeviation != nil {
+			feet := ops.MetersToFeet(*landing.MaxLineupDeviation)
+			lineup = &feet
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%v\t%.0f\t%.0f\t%.0f\t%.0f\t%v\t%v\t%v\t%v",
+			ops.OffsetTime(landing.ReferenceTime, landing.Touchdown).Format(time.RFC3339),
+			landing.Pilot,
+			landing.Airframe,
+			landing.Reference,
+			ops.MetersToFeet(landing.TouchdownDistance),
+			ops.MetersToFeet(landing.TouchdownLateral),
+			ops.MetersToFeet(landing.SinkRate)*60,
+			ops.MetersPerSecondToKnots(landing.TouchdownSpeed),
+			formatOptionalFloat(landing.AOA, "%.1f"),
+			formatOptionalFloat(landing.MaxGlideslopeDeviation, "%.2f"),
+			formatOptionalFloat(lineup, "%.0f"),
+			landing.Grade,
+		)
+		if reference != nil {
+			fmt.Fprintf(writer, "\t%v", formatPositionCalls(landing.Bullseye, landing.BRAA))
+		}
+		fmt.Fprintln(writer)
+	}
+	return writer.Flush()
+}
+
+func formatOptionalFloat(value *float64, format string) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf(format, *value)
+}
+
+func writeApproachTrace(path string, landings []*ops.Landing) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{
+		"touchdown", "object_id", "pilot", "time", "distance", "height", "glideslope_deviation", "lineup_deviation", "aoa",
+	})
+	for _, landing := range landings {
+		touchdown := ops.OffsetTime(landing.ReferenceTime, landing.Touchdown).Format(time.RFC3339)
+		for _, sample := range landing.Approach {
+			writer.Write([]string{
+				touchdown,
+				fmt.Sprintf("%v", landing.ObjectId),
+				landing.Pilot,
+				ops.OffsetTime(landing.ReferenceTime, sample.Offset).Format(time.RFC3339Nano),
+				fmt.Sprintf("%.1f", sample.Distance),
+				fmt.Sprintf("%.1f", sample.Height),
+				fmt.Sprintf("%.2f", sample.GlideslopeDeviation),
+				fmt.Sprintf("%.1f", sample.LineupDeviation),
+				formatOptionalFloat(sample.AOA, "%.1f"),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,97 @@
+package jambon
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandPicture handles generating GCI picture call transcripts
+var CommandPicture = cli.Command{
+	Name:        "picture",
+	Description: "generate a transcript of GCI picture calls from one coalition's perspective",
+	Action:      commandPicture,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringFlag{
+			Name:     "coalition",
+			Usage:    "coalition the picture is called for, every aircraft of another coalition is hostile",
+			Required: true,
+		},
+		&cli.Float64Flag{
+			Name:  "interval",
+			Usage: "seconds between picture calls",
+			Value: 60,
+		},
+		&cli.BoolFlag{
+			Name:  "on-change",
+			Usage: "also call the picture whenever the number of groups or contacts changes",
+		},
+		&cli.StringFlag{
+			Name:  "group-distance",
+			Usage: "maximum distance between aircraft of the same group",
+			Value: "3nm",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	}, positionFlags...),
+}
+
+func commandPicture(ctx *cli.Context) error {
+	groupDistance, err := ops.ParseDistance(ctx.String("group-distance"))
+	if err != nil {
+		return err
+	}
+
+	reference, err := positionReferenceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	pictures, err := ops.Pictures(ctx.Context, inputs.readers(), ops.PicturesOptions{
+		Options:       inputs.options(ctx.Int("concurrency")),
+		Coalition:     ctx.String("coalition"),
+		Interval:      ctx.Float64("interval"),
+		OnChange:      ctx.Bool("on-change"),
+		GroupDistance: groupDistance,
+		Reference:     reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(pictures)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	for _, picture := range pictures {
+		fmt.Print(picture.Transcript())
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package jambon
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandPilots handles reporting per-pilot sessions across one or more tacview files
+var CommandPilots = cli.Command{
+	Name:        "pilots",
+	Description: "report each pilots sorties, airframes and flight time",
+	Action:      commandPilots,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report the pilot with the given name",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	},
+}
+
+func commandPilots(ctx *cli.Context) error {
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	pilots, err := ops.Pilots(ctx.Context, inputs.readers(), ops.PilotsOptions{
+		Options: inputs.options(ctx.Int("concurrency")),
+		Pilots:  ctx.StringSlice("pilot"),
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(pilots)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	for _, pilot := range pilots {
+		fmt.Printf(
+			"Pilot %v\n  Sorties:     %v\n  Flight Time: %v\n",
+			pilot.Name,
+			len(pilot.Sorties),
+			ops.FormatSeconds(pilot.FlightTime),
+		)
+
+		for idx, sortie := range pilot.Sorties {
+			fmt.Printf(
+				"  %v. %v (object %v) %v - %v (%v)",
+				idx+1,
+				sortie.Airframe,
+				sortie.ObjectId,
+				sortie.Start.Format(time.RFC3339),
+				sortie.End.Format(time.RFC3339),
+				ops.FormatSeconds(sortie.Duration),
+			)
+			if idx > 0 {
+				fmt.Printf(", %v after previous", ops.FormatSeconds(sortie.Gap))
+			}
+			fmt.Printf("\n")
+		}
+
+		airframes := make([]string, 0, len(pilot.Airframes))
+		for airframe := range pilot.Airframes {
+			airframes = append(airframes, airframe)
+		}
+		sort.Strings(airframes)
+
+		fmt.Printf("  Airframes:\n")
+		for _, airframe := range airframes {
+			fmt.Printf("    %v: %v\n", airframe, ops.FormatSeconds(pilot.Airframes[airframe]))
+		}
+	}
+
+	return nil
+}
@@ -1,10 +1,10 @@
 package jambon
 
 import (
-	"fmt"
-	"strings"
+	"os"
+	"os/signal"
 
-	"github.com/b1naryth1ef/jambon/tacview"
+	"github.com/b1naryth1ef/jambon/ops"
 	"github.com/urfave/cli/v2"
 )
 
@@ -38,41 +38,29 @@ var CommandRecord = cli.Command{
 }
 
 func commandRecord(ctx *cli.Context) error {
-	serverStr := ctx.String("server")
-	if strings.Index(serverStr, ":") == -1 {
-		serverStr = fmt.Sprintf("%s:42674", serverStr)
-	}
-
-	reader, err := tacview.NewRealTimeReader(serverStr, ctx.String("username"), ctx.String("password"))
-	if err != nil {
-		return err
-	}
+	// Recording stops once interrupted, keeping everything received so far
+	recordCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt)
+	defer stop()
 
 	outputFile, err := openWritableTacView(ctx.Path("output"))
 	if err != nil {
 		return err
 	}
 
-	writer, err := tacview.NewWriter(outputFile, &reader.Header)
+	progress := newProgressBar(0)
+	err = ops.Record(recordCtx, outputFile, ops.RecordOptions{
+		Options: ops.Options{
+			Progress:         progress.update,
+			ProgressInterval: progressInterval,
+		},
+		Server:   ctx.String("server"),
+		Username: ctx.String("username"),
+		Password: ctx.String("password"),
+	})
+	progress.finish()
+	closeErr := outputFile.Close()
 	if err != nil {
 		return err
 	}
-	defer writer.Close()
-
-	data := make(chan *tacview.TimeFrame, 1)
-	go reader.ProcessTimeFrames(1, data)
-
-	for {
-		frame, ok := <-data
-		if !ok {
-			break
-		}
-
-		err = writer.WriteTimeFrame(frame)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return closeErr
 }
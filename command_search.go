@@ -3,12 +3,11 @@ package jambon
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"runtime"
-	"sort"
 	"strings"
 	"time"
 
+	"github.com/b1naryth1ef/jambon/ops"
 	"github.com/b1naryth1ef/jambon/tacview"
 	"github.com/urfave/cli/v2"
 )
@@ -18,7 +17,7 @@ var CommandSearch = cli.Command{
 	Name:        "search",
 	Description: "search for an object",
 	Action:      commandSearch,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringSliceFlag{
 			Name:      "file",
 			Usage:     "path to tacview files you'd like to search",
@@ -29,6 +28,35 @@ var CommandSearch = cli.Command{
 			Name:  "property",
 			Usage: "provide a key=value property pair to search for",
 		},
+		&cli.StringFlag{
+			Name:  "near",
+			Usage: "only match objects within the search radius of a `lat,lon` coordinate",
+		},
+		&cli.StringFlag{
+			Name:  "radius",
+			Usage: "radius used with --near (supports nm, km, ft and m units)",
+			Value: "10nm",
+		},
+		&cli.PathFlag{
+			Name:  "within",
+			Usage: "only match objects within the polygons of a GeoJSON file",
+		},
+		&cli.StringFlag{
+			Name:  "altitude-between",
+			Usage: "only match objects with an altitude between `min,max` (supports ft and m units)",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "only match objects from the given time (offset, RFC3339 or 15:04:05)",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "only match objects until the given time (offset, RFC3339 or 15:04:05)",
+		},
+		&cli.StringFlag{
+			Name:  "alive-at",
+			Usage: "only match objects alive at the given time (offset, RFC3339 or 15:04:05)",
+		},
 		&cli.BoolFlag{
 			Name:  "print-properties",
 			Usage: "print found object properties",
@@ -42,44 +70,90 @@ var CommandSearch = cli.Command{
 			Usage: "number of parallel processing routines to run",
 			Value: runtime.GOMAXPROCS(-1),
 		},
-	},
+	}, positionFlags...),
 }
 
-func commandSearch(ctx *cli.Context) error {
-	properties := make(map[string]string)
+// searchOptionsFromContext returns the search options configured by the flags
+func searchOptionsFromContext(ctx *cli.Context) (ops.SearchOptions, error) {
+	options := ops.SearchOptions{
+		Properties: make(map[string]string),
+		From:       ops.Time(ctx.String("from")),
+		Until:      ops.Time(ctx.String("until")),
+		AliveAt:    ops.Time(ctx.String("alive-at")),
+	}
+
 	for _, property := range ctx.StringSlice("property") {
 		parts := strings.SplitN(property, "=", 2)
 		if len(parts) != 2 {
-			return fmt.Errorf("Failed to process property '%v'", property)
+			return options, fmt.Errorf("Failed to process property '%v'", property)
 		}
-		properties[parts[0]] = parts[1]
+		options.Properties[parts[0]] = parts[1]
 	}
 
-	if len(properties) == 0 {
-		return fmt.Errorf("No properties to search for")
+	if ctx.IsSet("near") {
+		latitude, longitude, err := ops.ParseLatLon(ctx.String("near"))
+		if err != nil {
+			return options, err
+		}
+
+		radius, err := ops.ParseDistance(ctx.String("radius"))
+		if err != nil {
+			return options, err
+		}
+
+		options.Regions = append(options.Regions, ops.NewCircleRegion(latitude, longitude, radius))
 	}
 
-	for _, filePath := range ctx.StringSlice("file") {
-		fmt.Fprintf(os.Stderr, "Processing file %v...\n", filePath)
+	if ctx.IsSet("within") {
+		polygon, err := ops.LoadGeoJSONRegion(ctx.Path("within"))
+		if err != nil {
+			return options, err
+		}
+		options.Regions = append(options.Regions, polygon)
+	}
+
+	if ctx.IsSet("altitude-between") {
+		parts := strings.Split(ctx.String("altitude-between"), ",")
+		if len(parts) != 2 {
+			return options, fmt.Errorf("Expected min,max for altitude, found '%v'", ctx.String("altitude-between"))
+		}
 
-		file, err := openReadableTacView(filePath)
+		minAltitude, err := ops.ParseDistance(parts[0])
 		if err != nil {
-			return err
+			return options, err
 		}
 
-		reader, err := tacview.NewReader(file)
+		maxAltitude, err := ops.ParseDistance(parts[1])
+		if err != nil {
+			return options, err
+		}
+		options.MinAltitude, options.MaxAltitude = &minAltitude, &maxAltitude
+	}
+
+	var err error
+	options.Reference, err = positionReferenceFromContext(ctx)
+	return options, err
+}
+
+func commandSearch(ctx *cli.Context) error {
+	options, err := searchOptionsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range ctx.StringSlice("file") {
+		inputs, err := openInputFiles(filePath)
 		if err != nil {
 			return err
 		}
 
-		results, err := search(ctx.Int("concurrency"), reader, properties)
+		options.Options = inputs.options(ctx.Int("concurrency"))
+		results, err := ops.Search(ctx.Context, inputs.readers()[0], options)
+		inputs.Close()
 		if err != nil {
 			return err
 		}
 
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].Object.Id < results[j].Object.Id
-		})
 		if ctx.Bool("json") {
 			encoded, err := json.Marshal(results)
 			if err != nil {
@@ -88,8 +162,9 @@ func commandSearch(ctx *cli.Context) error {
 			fmt.Printf("%s\n", string(encoded))
 		} else {
 			for _, result := range results {
-				firstSeenDate := reader.Header.ReferenceTime.Add(time.Second * time.Duration(result.FirstSeen))
-				lastSeenDate := reader.Header.ReferenceTime.Add(time.Second * time.Duration(result.LastSeen))
+				header := &tacview.Header{ReferenceTime: result.ReferenceTime}
+				firstSeenDate := ops.OffsetTime(result.ReferenceTime, result.FirstSeen)
+				lastSeenDate := ops.OffsetTime(result.ReferenceTime, result.LastSeen)
 
 				fmt.Printf(
 					"Object %v\n  First Seen: %v (%v)\n  Last Seen:  %v (%v)\n",
@@ -99,6 +174,26 @@ func commandSearch(ctx *cli.Context) error {
 					lastSeenDate.Format(time.RFC3339),
 					result.LastSeen,
 				)
+				if result.Removed != nil {
+					fmt.Printf("  Removed:    %v\n", ops.FormatOffset(header, *result.Removed))
+				}
+				if result.Destroyed != nil {
+					fmt.Printf("  Outcome:    %v at %v\n", result.Outcome, ops.FormatOffset(header, *result.Destroyed))
+				} else {
+					fmt.Printf("  Outcome:    %v\n", result.Outcome)
+				}
+				if result.SpawnPosition != nil {
+					fmt.Printf("  Spawned At: %v\n", result.SpawnPosition)
+				}
+				if result.RemovalPosition != nil {
+					fmt.Printf("  Removed At: %v\n", result.RemovalPosition)
+				}
+				if result.MatchedAt != nil {
+					fmt.Printf("  Matched At: %v\n", ops.FormatOffset(header, *result.MatchedAt))
+				}
+				if result.Position != nil {
+					fmt.Printf("  Position:   %v\n", result.Position)
+				}
 				if ctx.Bool("print-properties") {
 					for _, property := range result.Object.Properties {
 						fmt.Printf("  %v = %v\n", property.Key, property.Value)
@@ -112,69 +207,3 @@ func commandSearch(ctx *cli.Context) error {
 
 	return nil
 }
-
-type searchResult struct {
-	Object    *tacview.Object `json:"object"`
-	FirstSeen float64         `json:"first_seen"`
-	LastSeen  float64         `json:"last_seen"`
-}
-
-func search(concurrency int, reader *tacview.Reader, properties map[string]string) ([]*searchResult, error) {
-	done := make(chan struct{})
-	timeFrames := make(chan *tacview.TimeFrame)
-
-	results := make(map[uint64]*searchResult)
-
-	go func() {
-		defer close(done)
-
-		for {
-			tf, ok := <-timeFrames
-
-			if !ok {
-				return
-			}
-
-			for _, object := range tf.Objects {
-				if _, ok := results[object.Id]; !ok {
-					matched := true
-					for k, v := range properties {
-						if res := object.Get(k); res == nil || res.Value != v {
-							matched = false
-							break
-						}
-					}
-
-					if matched {
-						results[object.Id] = &searchResult{Object: object, FirstSeen: tf.Offset, LastSeen: tf.Offset}
-					}
-				} else {
-					result := results[object.Id]
-					if tf.Offset < result.FirstSeen {
-						result.FirstSeen = tf.Offset
-					}
-
-					if tf.Offset > result.LastSeen {
-						result.LastSeen = tf.Offset
-					}
-				}
-			}
-		}
-	}()
-
-	err := reader.ProcessTimeFrames(concurrency, timeFrames)
-	if err != nil {
-		return nil, err
-	}
-
-	<-done
-
-	final := make([]*searchResult, len(results))
-	idx := 0
-	for _, result := range results {
-		final[idx] = result
-		idx++
-	}
-
-	return final, nil
-}
@@ -0,0 +1,156 @@
+package jambon
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandShots handles reporting launch parameters and outcomes for missile shots
+var CommandShots = cli.Command{
+	Name:        "shots",
+	Description: "report launch parameters, time of flight and outcome for each missile shot",
+	Action:      commandShots,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report shots taken by the pilot with the given name",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "output data as CSV",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	}, positionFlags...),
+}
+
+func commandShots(ctx *cli.Context) error {
+	reference, err := positionReferenceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	shots, err := ops.Shots(ctx.Context, inputs.readers(), ops.ShotsOptions{
+		Options:   inputs.options(ctx.Int("concurrency")),
+		Pilots:    ctx.StringSlice("pilot"),
+		Reference: reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(shots)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	if ctx.Bool("csv") {
+		writer := csv.NewWriter(os.Stdout)
+		columns := []string{
+			"time", "weapon", "launcher_id", "launcher", "pilot", "target_id", "target", "target_pilot",
+			"launch_range", "aspect", "launcher_altitude", "target_altitude", "closing_speed",
+			"time_of_flight", "closest_approach", "target_destroyed",
+		}
+		if reference != nil {
+			columns = append(columns, positionColumns...)
+		}
+		writer.Write(columns)
+
+		for _, shot := range shots {
+			record := []string{
+				ops.OffsetTime(shot.ReferenceTime, shot.Launch).Format(time.RFC3339),
+				shot.Weapon,
+				fmt.Sprintf("%v", shot.LauncherId),
+				shot.Launcher,
+				shot.Pilot,
+				fmt.Sprintf("%v", shot.TargetId),
+				shot.Target,
+				shot.TargetPilot,
+				fmt.Sprintf("%.0f", shot.LaunchRange),
+				fmt.Sprintf("%.0f", shot.Aspect),
+				fmt.Sprintf("%.0f", shot.LauncherAltitude),
+				fmt.Sprintf("%.0f", shot.TargetAltitude),
+				fmt.Sprintf("%.0f", shot.ClosingSpeed),
+				fmt.Sprintf("%.1f", shot.TimeOfFlight),
+				fmt.Sprintf("%.0f", shot.ClosestApproach),
+				fmt.Sprintf("%v", shot.TargetDestroyed),
+			}
+			if reference != nil {
+				record = append(record, positionRecord(shot.Bullseye, shot.BRAA)...)
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(writer, "TIME\tWEAPON\tSHOOTER\tTARGET\tRANGE (NM)\tASPECT\tALT (FT)\tTGT ALT (FT)\tCLOSURE (KTS)\tTOF (S)\tMISS (M)\tKILL")
+	if reference != nil {
+		fmt.Fprint(writer, "\tLAUNCH POSITION")
+	}
+	fmt.Fprintln(writer)
+	for _, shot := range shots {
+		shooter := shot.Pilot
+		if shooter == "" {
+			shooter = shot.Launcher
+		}
+		target := shot.TargetPilot
+		if target == "" {
+			target = shot.Target
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%v\t%.1f\t%.0f\t%.0f\t%.0f\t%.0f\t%.1f\t%.0f\t%v",
+			ops.OffsetTime(shot.ReferenceTime, shot.Launch).Format(time.RFC3339),
+			shot.Weapon,
+			shooter,
+			target,
+			ops.MetersToNauticalMiles(shot.LaunchRange),
+			shot.Aspect,
+			ops.MetersToFeet(shot.LauncherAltitude),
+			ops.MetersToFeet(shot.TargetAltitude),
+			ops.MetersPerSecondToKnots(shot.ClosingSpeed),
+			shot.TimeOfFlight,
+			shot.ClosestApproach,
+			shot.TargetDestroyed,
+		)
+		if reference != nil {
+			fmt.Fprintf(writer, "\t%v", formatPositionCalls(shot.Bullseye, shot.BRAA))
+		}
+		fmt.Fprintln(writer)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,154 @@
+package jambon
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandSorties handles reporting every sortie flown within tacview files
+var CommandSorties = cli.Command{
+	Name:        "sorties",
+	Description: "report takeoff, landing, airfields, duration and outcome for each sortie",
+	Action:      commandSorties,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report sorties flown by the pilot with the given name",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "output data as CSV",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	}, positionFlags...),
+}
+
+func commandSorties(ctx *cli.Context) error {
+	reference, err := positionReferenceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	sorties, err := ops.Sorties(ctx.Context, inputs.readers(), ops.SortiesOptions{
+		Options:   inputs.options(ctx.Int("concurrency")),
+		Pilots:    ctx.StringSlice("pilot"),
+		Reference: reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(sorties)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	if ctx.Bool("csv") {
+		writer := csv.NewWriter(os.Stdout)
+		columns := []string{
+			"pilot", "airframe", "object_id", "departure", "arrival", "block_out", "takeoff",
+			"landing", "block_in", "block_duration", "airborne_duration", "weapons", "outcome",
+		}
+		if reference != nil {
+			columns = append(columns, positionColumns...)
+		}
+		writer.Write(columns)
+
+		for _, sortie := range sorties {
+			record := []string{
+				sortie.Pilot,
+				sortie.Airframe,
+				fmt.Sprintf("%v", sortie.ObjectId),
+				sortie.Departure,
+				sortie.Arrival,
+				formatSortieTime(sortie, &sortie.BlockOut),
+				formatSortieTime(sortie, sortie.TakeOff),
+				formatSortieTime(sortie, sortie.Landing),
+				formatSortieTime(sortie, &sortie.BlockIn),
+				fmt.Sprintf("%.0f", sortie.BlockDuration),
+				fmt.Sprintf("%.0f", sortie.AirborneDuration),
+				formatSortieWeapons(sortie.Weapons),
+				sortie.Outcome,
+			}
+			if reference != nil {
+				record = append(record, positionRecord(sortie.Bullseye, sortie.BRAA)...)
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	for idx, sortie := range sorties {
+		fmt.Printf("Sortie %v: %v (%v, object %v)\n", idx+1, sortie.Pilot, sortie.Airframe, sortie.ObjectId)
+		fmt.Printf("  Departure: %v\n", sortie.Departure)
+		fmt.Printf("  Arrival:   %v\n", sortie.Arrival)
+		fmt.Printf("  Block Out: %v\n", formatSortieTime(sortie, &sortie.BlockOut))
+		fmt.Printf("  Takeoff:   %v\n", formatSortieTime(sortie, sortie.TakeOff))
+		fmt.Printf("  Landing:   %v\n", formatSortieTime(sortie, sortie.Landing))
+		fmt.Printf("  Block In:  %v\n", formatSortieTime(sortie, &sortie.BlockIn))
+		fmt.Printf("  Block:     %v\n", ops.FormatSeconds(sortie.BlockDuration))
+		fmt.Printf("  Airborne:  %v\n", ops.FormatSeconds(sortie.AirborneDuration))
+		fmt.Printf("  Weapons:   %v\n", formatSortieWeapons(sortie.Weapons))
+		fmt.Printf("  Outcome:   %v\n", sortie.Outcome)
+		if calls := formatPositionCalls(sortie.Bullseye, sortie.BRAA); calls != "" {
+			fmt.Printf("  Ended At:  %v\n", calls)
+		}
+	}
+
+	return nil
+}
+
+func formatSortieWeapons(weapons map[string]int) string {
+	names := make([]string, 0, len(weapons))
+	for name := range weapons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for idx, name := range names {
+		parts[idx] = fmt.Sprintf("%v x%v", name, weapons[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatSortieTime(sortie *ops.Sortie, offset *float64) string {
+	if offset == nil {
+		return ""
+	}
+	return ops.OffsetTime(sortie.ReferenceTime, *offset).Format(time.RFC3339)
+}
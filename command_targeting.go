@@ -0,0 +1,183 @@
+package jambon
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// CommandTargeting handles reporting radar locks and targeting
+var CommandTargeting = cli.Command{
+	Name:        "targeting",
+	Description: "report a timeline of radar locks and which locks preceded a shot or a kill",
+	Action:      commandTargeting,
+	Flags: append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:      "file",
+			Usage:     "path to tacview files you'd like to process",
+			TakesFile: true,
+			Required:  true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "pilot",
+			Usage: "only report locks held by or on the pilot with the given name",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "only report locks held after this time (offset seconds, RFC3339 or a 15:04:05 UTC clock time)",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "only report locks held before this time (offset seconds, RFC3339 or a 15:04:05 UTC clock time)",
+		},
+		&cli.StringFlag{
+			Name:  "graph",
+			Usage: "output the locks as a directed graph in the given format (dot or json)",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output data as JSON",
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "output data as CSV",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of parallel processing routines to run",
+			Value: runtime.GOMAXPROCS(-1),
+		},
+	}, positionFlags...),
+}
+
+func commandTargeting(ctx *cli.Context) error {
+	reference, err := positionReferenceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	format := ctx.String("graph")
+	if format != "" && format != "dot" && format != "json" {
+		return fmt.Errorf("Unsupported graph format '%v'", format)
+	}
+
+	inputs, err := openInputFiles(ctx.StringSlice("file")...)
+	if err != nil {
+		return err
+	}
+	defer inputs.Close()
+
+	locks, err := ops.Targeting(ctx.Context, inputs.readers(), ops.TargetingOptions{
+		Options:   inputs.options(ctx.Int("concurrency")),
+		From:      ops.Time(ctx.String("from")),
+		Until:     ops.Time(ctx.String("until")),
+		Pilots:    ctx.StringSlice("pilot"),
+		Reference: reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	if format != "" {
+		graph, err := ops.NewTargetingGraph(locks, ops.Time(ctx.String("from")), ops.Time(ctx.String("until")))
+		if err != nil {
+			return err
+		}
+
+		if format == "dot" {
+			return graph.WriteDOT(os.Stdout)
+		}
+
+		encoded, err := json.Marshal(graph)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	if ctx.Bool("json") {
+		encoded, err := json.Marshal(locks)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", string(encoded))
+		return nil
+	}
+
+	if ctx.Bool("csv") {
+		writer := csv.NewWriter(os.Stdout)
+		columns := []string{
+			"time", "slot", "source_id", "source", "source_pilot", "target_id", "target", "target_pilot",
+			"radar_mode", "duration", "shots", "target_destroyed",
+		}
+		if reference != nil {
+			columns = append(columns, positionColumns...)
+		}
+		writer.Write(columns)
+
+		for _, lock := range locks {
+			record := []string{
+				ops.OffsetTime(lock.ReferenceTime, lock.Start).Format(time.RFC3339),
+				lock.Slot,
+				fmt.Sprintf("%v", lock.SourceId),
+				lock.Source,
+				lock.SourcePilot,
+				fmt.Sprintf("%v", lock.TargetId),
+				lock.Target,
+				lock.TargetPilot,
+				lock.RadarMode,
+				fmt.Sprintf("%.1f", lock.Duration),
+				fmt.Sprintf("%v", len(lock.Shots)),
+				fmt.Sprintf("%v", lock.TargetDestroyed),
+			}
+			if reference != nil {
+				record = append(record, positionRecord(lock.Bullseye, lock.BRAA)...)
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(writer, "TIME\tSLOT\tSOURCE\tTARGET\tDURATION\tSHOTS\tKILL")
+	if reference != nil {
+		fmt.Fprint(writer, "\tTARGET POSITION")
+	}
+	fmt.Fprintln(writer)
+	for _, lock := range locks {
+		source := lock.SourcePilot
+		if source == "" {
+			source = lock.Source
+		}
+		target := lock.TargetPilot
+		if target == "" {
+			target = lock.Target
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%v\t%v\t%v\t%v\t%v\t%v\t%v",
+			ops.OffsetTime(lock.ReferenceTime, lock.Start).Format(time.RFC3339),
+			lock.Slot,
+			source,
+			target,
+			ops.FormatSeconds(lock.Duration),
+			len(lock.Shots),
+			lock.TargetDestroyed,
+		)
+		if reference != nil {
+			fmt.Fprintf(writer, "\t%v", formatPositionCalls(lock.Bullseye, lock.BRAA))
+		}
+		fmt.Fprintln(writer)
+	}
+	return writer.Flush()
+}
@@ -4,7 +4,7 @@ import (
 	"os"
 	"runtime/pprof"
 
-	"github.com/b1naryth1ef/jambon/tacview"
+	"github.com/b1naryth1ef/jambon/ops"
 	"github.com/urfave/cli/v2"
 )
 
@@ -13,7 +13,7 @@ var CommandTrim = cli.Command{
 	Name:        "trim",
 	Description: "trim a tacview to reduce its duration",
 	Action:      commandTrim,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.PathFlag{
 			Name:     "input",
 			Usage:    "path to the input ACMI file",
@@ -24,19 +24,19 @@ var CommandTrim = cli.Command{
 			Usage:    "path to the output ACMI file",
 			Required: true,
 		},
-		&cli.Float64Flag{
+		&cli.StringFlag{
 			Name:  "start-at-offset-time",
-			Usage: "set the start point via an offset time",
+			Usage: "set the start point via a time (offset, RFC3339 or 15:04:05)",
 		},
-		&cli.Float64Flag{
+		&cli.StringFlag{
 			Name:  "end-at-offset-time",
-			Usage: "set the end point via an offset time",
+			Usage: "set the end point via a time (offset, RFC3339 or 15:04:05)",
 		},
 		&cli.PathFlag{
 			Name:  "cpuprofile",
 			Usage: "record a cpu profile for debugging purposes",
 		},
-	},
+	}, regionFlags...),
 }
 
 func commandTrim(ctx *cli.Context) error {
@@ -50,31 +50,31 @@ func commandTrim(ctx *cli.Context) error {
 		defer pprof.StopCPUProfile()
 	}
 
-	inputFile, err := openReadableTacView(ctx.Path("input"))
+	clipRegion, err := regionFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	outputFile, err := openWritableTacView(ctx.Path("output"))
+	inputs, err := openInputFiles(ctx.Path("input"))
 	if err != nil {
 		return err
 	}
-	defer outputFile.Close()
-
-	var start float64
-	var end float64
-
-	if ctx.IsSet("start-at-offset-time") {
-		start = ctx.Float64("start-at-offset-time")
-	}
+	defer inputs.Close()
 
-	if ctx.IsSet("end-at-offset-time") {
-		end = ctx.Float64("end-at-offset-time")
+	outputFile, err := openWritableTacView(ctx.Path("output"))
+	if err != nil {
+		return err
 	}
 
-	parser, err := tacview.NewParser(inputFile)
+	err = ops.Trim(ctx.Context, inputs.readers()[0], outputFile, ops.TrimOptions{
+		Options: inputs.options(0),
+		Start:   ops.Time(ctx.String("start-at-offset-time")),
+		End:     ops.Time(ctx.String("end-at-offset-time")),
+		Region:  clipRegion,
+	})
+	closeErr := outputFile.Close()
 	if err != nil {
 		return err
 	}
-	return tacview.TrimRaw(parser, tacview.NewRawWriter(outputFile), start, end)
+	return closeErr
 }
@@ -0,0 +1,198 @@
+package ops
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// BullseyeCall is the bearing (degrees true) and range (meters) of a position
+//  from the bullseye
+type BullseyeCall struct {
+	Bearing  float64 `json:"bearing"`
+	Range    float64 `json:"range"`
+	Altitude float64 `json:"altitude"`
+}
+
+func (b *BullseyeCall) String() string {
+	return fmt.Sprintf(
+		"bullseye %03.0f/%.0f, %.0fft",
+		b.Bearing,
+		MetersToNauticalMiles(b.Range),
+		MetersToFeet(b.Altitude),
+	)
+}
+
+// BRAACall is the bearing (degrees true), range (meters), altitude (meters)
+//  and aspect of a position from a reference aircraft
+type BRAACall struct {
+	Bearing  float64 `json:"bearing"`
+	Range    float64 `json:"range"`
+	Altitude float64 `json:"altitude"`
+	Aspect   string  `json:"aspect,omitempty"`
+}
+
+func (b *BRAACall) String() string {
+	call := fmt.Sprintf(
+		"braa %03.0f/%.0f, %.0fft",
+		b.Bearing,
+		MetersToNauticalMiles(b.Range),
+		MetersToFeet(b.Altitude),
+	)
+	if b.Aspect != "" {
+		call += ", " + b.Aspect
+	}
+	return call
+}
+
+// braaAspect returns the brevity aspect (hot, flank, beam or drag) of a target
+//  based on the angle between its heading and the observer
+func braaAspect(target tacview.Transform, targetHeading float64, observer tacview.Transform) string {
+	off := angleDifference(targetHeading, transformBearing(target, observer))
+	switch {
+	case off <= 30:
+		return "hot"
+	case off <= 70:
+		return "flank"
+	case off <= 110:
+		return "beam"
+	default:
+		return "drag"
+	}
+}
+
+// PositionReference resolves the bullseye and BRAA reference aircraft within
+//  a reconstructed world
+type PositionReference struct {
+	bullseye          *tacview.Transform
+	bullseyeCoalition string
+	braaPilot         string
+	braaId            uint64
+}
+
+// ParsePositionReference creates a reference from a `lat,lon` bullseye or the
+//  coalition of a bullseye object (or 'any'), and the pilot name or object id of
+//  the BRAA reference aircraft. Either may be empty, returning nil when both are.
+//  An id is hexadecimal as within the recording, optionally prefixed by 0x.
+func ParsePositionReference(bullseye string, braaFrom string) (*PositionReference, error) {
+	if bullseye == "" && braaFrom == "" {
+		return nil, nil
+	}
+
+	reference := &PositionReference{}
+	if bullseye != "" {
+		if strings.Contains(bullseye, ",") {
+			latitude, longitude, err := ParseLatLon(bullseye)
+			if err != nil {
+				return nil, err
+			}
+			reference.bullseye = &tacview.Transform{Latitude: latitude, Longitude: longitude}
+		} else {
+			reference.bullseyeCoalition = bullseye
+		}
+	}
+
+	if braaFrom != "" {
+		// Object ids are hexadecimal like within the recording, a pilot whose
+		//  name happens to be valid hexadecimal is still found by name
+		reference.braaPilot = braaFrom
+		hex := strings.TrimPrefix(strings.ToLower(braaFrom), "0x")
+		if id, err := strconv.ParseUint(hex, 16, 64); err == nil {
+			reference.braaId = id
+		}
+	}
+
+	return reference, nil
+}
+
+// findBullseye returns the position of the bullseye within the world (if one exists)
+func (r *PositionReference) findBullseye(world *tacview.World) *tacview.Transform {
+	if r.bullseye != nil {
+		return r.bullseye
+	}
+	if r.bullseyeCoalition == "" {
+		return nil
+	}
+
+	// The lowest id is picked when several bullseyes match
+	var bullseye *tacview.ObjectState
+	for _, state := range world.Objects {
+		if !state.HasTransform || !state.Object.HasTags("Navaid", "Bullseye") {
+			continue
+		}
+
+		if r.bullseyeCoalition != "any" {
+			coalition := state.Object.Get("Coalition")
+			if coalition == nil || !strings.EqualFold(coalition.Value, r.bullseyeCoalition) {
+				continue
+			}
+		}
+
+		if bullseye == nil || state.Id() < bullseye.Id() {
+			bullseye = state
+		}
+	}
+	if bullseye == nil {
+		return nil
+	}
+	return &bullseye.Transform
+}
+
+// findReference returns the BRAA reference aircraft within the world (if one exists)
+func (r *PositionReference) findReference(world *tacview.World) *tacview.ObjectState {
+	if r.braaId != 0 {
+		if state := world.Get(r.braaId); state != nil {
+			return state
+		}
+	}
+	if r.braaPilot == "" {
+		return nil
+	}
+
+	// The lowest id is picked when several aircraft share the pilot name
+	var reference *tacview.ObjectState
+	for _, state := range world.Objects {
+		pilot := state.Object.Get("Pilot")
+		if pilot == nil || pilot.Value != r.braaPilot {
+			continue
+		}
+		if reference == nil || state.Id() < reference.Id() {
+			reference = state
+		}
+	}
+	return reference
+}
+
+// describe returns the bullseye and BRAA calls for a position, either may be
+//  nil when its reference is not configured or cannot be found. The target
+//  state and motion are optional and only used for the BRAA aspect.
+func (r *PositionReference) describe(world *tacview.World, transform tacview.Transform, target *tacview.ObjectState, targetMotion *motion) (*BullseyeCall, *BRAACall) {
+	if r == nil {
+		return nil, nil
+	}
+
+	var bullseye *BullseyeCall
+	if origin := r.findBullseye(world); origin != nil {
+		bullseye = &BullseyeCall{
+			Bearing:  transformBearing(*origin, transform),
+			Range:    groundDistance(*origin, transform),
+			Altitude: transform.Altitude,
+		}
+	}
+
+	var braa *BRAACall
+	if observer := r.findReference(world); observer != nil && observer.HasTransform && observer != target {
+		braa = &BRAACall{
+			Bearing:  transformBearing(observer.Transform, transform),
+			Range:    slantRange(observer.Transform, transform),
+			Altitude: transform.Altitude,
+		}
+		if target != nil {
+			braa.Aspect = braaAspect(transform, heading(target, targetMotion), observer.Transform)
+		}
+	}
+
+	return bullseye, braa
+}
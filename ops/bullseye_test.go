@@ -0,0 +1,103 @@
+package ops
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPositionReference(t *testing.T) {
+	data := opsTestData(
+		"#0",
+		"8,T=0|0.2|0,Type=Navaid+Static+Bullseye,Coalition=Enemies",
+		"20,T=1|0|0,Type=Navaid+Static+Bullseye,Coalition=Allies",
+		"10,T=0|0|0,Type=Navaid+Static+Bullseye,Coalition=Allies",
+		"2b,T=0|0.2|1000,Type=Air+FixedWing,Pilot=Viper",
+		"1a,T=0|0|1000,Type=Air+FixedWing,Pilot=Viper",
+		"2c,T=0|0.2|1000,Type=Air+FixedWing,Pilot=Bad",
+		// Flying south towards 1a
+		"40,T=0|0.1|3000|0|0|180,Type=Air+FixedWing,Name=Target",
+	)
+
+	cases := []struct {
+		name     string
+		bullseye string
+		braaFrom string
+		expected string
+	}{
+		{
+			name:     "coalition bullseye",
+			bullseye: "allies",
+			expected: "0.1, 0 at 3000m (bullseye 000/6, 9843ft)",
+		},
+		{
+			name:     "any bullseye",
+			bullseye: "any",
+			expected: "0.1, 0 at 3000m (bullseye 180/6, 9843ft)",
+		},
+		{
+			name:     "coordinate bullseye",
+			bullseye: "0.1,-0.1",
+			expected: "0.1, 0 at 3000m (bullseye 090/6, 9843ft)",
+		},
+		{
+			name:     "braa from an id",
+			braaFrom: "1a",
+			expected: "0.1, 0 at 3000m (braa 000/6, 9843ft, hot)",
+		},
+		{
+			name:     "braa from a prefixed id",
+			braaFrom: "0x1A",
+			expected: "0.1, 0 at 3000m (braa 000/6, 9843ft, hot)",
+		},
+		{
+			name:     "braa from a pilot",
+			braaFrom: "Viper",
+			expected: "0.1, 0 at 3000m (braa 000/6, 9843ft, hot)",
+		},
+		{
+			// Valid hexadecimal but no object has the id
+			name:     "braa from a hexadecimal pilot name",
+			braaFrom: "Bad",
+			expected: "0.1, 0 at 3000m (braa 180/6, 9843ft, drag)",
+		},
+		{
+			name:     "bullseye and braa",
+			bullseye: "allies",
+			braaFrom: "2b",
+			expected: "0.1, 0 at 3000m (bullseye 000/6, 9843ft) (braa 180/6, 9843ft, drag)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			reference, err := ParsePositionReference(c.bullseye, c.braaFrom)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Repeated since objects are looked up by iterating over a map
+			for i := 0; i < 10; i++ {
+				results, err := Search(context.Background(), strings.NewReader(data), SearchOptions{
+					Properties: map[string]string{"Name": "Target"},
+					Reference:  reference,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(results) != 1 {
+					t.Fatalf("Expected a single result, found %v", len(results))
+				}
+
+				if found := results[0].SpawnPosition.String(); found != c.expected {
+					t.Fatalf("Expected '%v', found '%v'", c.expected, found)
+				}
+			}
+		})
+	}
+
+	reference, err := ParsePositionReference("", "")
+	if err != nil || reference != nil {
+		t.Fatalf("Expected no reference, found %v (%v)", reference, err)
+	}
+}
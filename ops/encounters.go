@@ -0,0 +1,306 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// Aircraft moving slower than this (in meters per second) are considered
+//  stationary, encounters between two stationary aircraft are not reported.
+const encounterStationarySpeed = 1.0
+
+// Aircraft moving faster than this (in meters per second) between two time
+//  frames are considered to have jumped, such as when respawning, and are only
+//  compared at their new position.
+const encounterMaxSpeed = 1500.0
+
+// Encounter describes a period in which two aircraft were closer than the
+//  configured distance. Distances are in meters, speeds in meters per second
+//  and times are offsets in seconds.
+type Encounter struct {
+	ReferenceTime time.Time `json:"reference_time"`
+	FirstId       uint64    `json:"first_id"`
+	First         string    `json:"first"`
+	FirstPilot    string    `json:"first_pilot"`
+	SecondId      uint64    `json:"second_id"`
+	Second        string    `json:"second"`
+	SecondPilot   string    `json:"second_pilot"`
+	Start         float64   `json:"start"`
+	End           float64   `json:"end"`
+	// ClosestApproach is the offset at which the aircraft were closest
+	ClosestApproach    float64 `json:"closest_approach"`
+	Distance           float64 `json:"distance"`
+	AltitudeDifference float64 `json:"altitude_difference"`
+	// RelativeSpeed is the speed of the aircraft relative to each other at the
+	//  closest approach
+	RelativeSpeed     float64 `json:"relative_speed"`
+	ProbableCollision bool    `json:"probable_collision"`
+	// Bullseye and BRAA describe the position of the first aircraft at the
+	//  closest approach when a position reference is configured
+	Bullseye *BullseyeCall `json:"bullseye,omitempty"`
+	BRAA     *BRAACall     `json:"braa,omitempty"`
+}
+
+// EncounterDetector finds the closest point of approach between every pair of
+//  aircraft which come within a configurable distance of each other.
+type EncounterDetector struct {
+	concurrency     int
+	distance        float64
+	collisionWindow float64
+	reference       *PositionReference
+	encounters      []*Encounter
+}
+
+// NewEncounterDetector creates a new EncounterDetector. The closest approach
+//  of each encounter is described relative to the reference when it isn't nil.
+func NewEncounterDetector(concurrency int, distance float64, collisionWindow float64, reference *PositionReference) *EncounterDetector {
+	return &EncounterDetector{
+		concurrency:     concurrency,
+		distance:        distance,
+		collisionWindow: collisionWindow,
+		reference:       reference,
+		encounters:      make([]*Encounter, 0),
+	}
+}
+
+// Encounters returns every encounter seen, ordered by closest approach
+func (e *EncounterDetector) Encounters() []*Encounter {
+	sort.SliceStable(e.encounters, func(i, j int) bool {
+		closest := OffsetTime(e.encounters[i].ReferenceTime, e.encounters[i].ClosestApproach)
+		return closest.Before(OffsetTime(e.encounters[j].ReferenceTime, e.encounters[j].ClosestApproach))
+	})
+	return e.encounters
+}
+
+type encounterProcessor struct {
+	detector *EncounterDetector
+	header   *tacview.Header
+	motions  *motionTracker
+	// Transforms of every aircraft as of the previous time frame
+	previous       map[uint64]tacview.Transform
+	previousOffset float64
+	// Encounters which were still in progress as of the previous time frame
+	active     map[[2]uint64]*Encounter
+	removed    map[uint64]float64
+	encounters []*Encounter
+}
+
+// ProcessFile records every encounter within the file
+func (e *EncounterDetector) ProcessFile(reader *tacview.Reader) error {
+	processor := &encounterProcessor{
+		detector:   e,
+		header:     &reader.Header,
+		motions:    newMotionTracker(1),
+		previous:   make(map[uint64]tacview.Transform),
+		active:     make(map[[2]uint64]*Encounter),
+		removed:    make(map[uint64]float64),
+		encounters: make([]*Encounter, 0),
+	}
+
+	_, err := reconstruct(e.concurrency, reader, func(world *tacview.World, tf *tacview.TimeFrame) error {
+		processor.motions.update(world, tf)
+		processor.process(world, tf.Offset)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, encounter := range processor.encounters {
+		first, firstOk := processor.removed[encounter.FirstId]
+		second, secondOk := processor.removed[encounter.SecondId]
+		if !firstOk || !secondOk {
+			continue
+		}
+
+		window := encounter.End + e.collisionWindow
+		encounter.ProbableCollision = first >= encounter.Start && first <= window &&
+			second >= encounter.Start && second <= window
+	}
+
+	e.encounters = append(e.encounters, processor.encounters...)
+	return nil
+}
+
+func (p *encounterProcessor) process(world *tacview.World, offset float64) {
+	for _, state := range world.Removed {
+		if isAircraft(state.Object) {
+			p.removed[state.Id()] = offset
+		}
+	}
+
+	current := make(map[uint64]tacview.Transform)
+	travelled := 0.0
+	maxTravelled := encounterMaxSpeed * (offset - p.previousOffset)
+	for _, state := range world.Objects {
+		if !state.HasTransform || !isAircraft(state.Object) {
+			continue
+		}
+
+		current[state.Id()] = state.Transform
+		if previous, ok := p.previous[state.Id()]; ok {
+			distance := slantRange(previous, state.Transform)
+			if distance > maxTravelled {
+				delete(p.previous, state.Id())
+			} else {
+				travelled = math.Max(travelled, distance)
+			}
+		}
+	}
+
+	// Two aircraft close on each other by at most twice the furthest any
+	//  aircraft travelled since the previous time frame, so pairs which passed
+	//  within the distance in between are no further apart than that now
+	grid := newSpatialGrid(p.detector.distance+2*travelled, p.header)
+	for id, transform := range current {
+		grid.insert(id, transform)
+	}
+
+	active := make(map[[2]uint64]*Encounter)
+	grid.pairs(func(a, b *gridEntry) {
+		if a.id > b.id {
+			a, b = b, a
+		}
+
+		aMotion, bMotion := p.motions.get(a.id), p.motions.get(b.id)
+		if isStationary(aMotion) && isStationary(bMotion) {
+			return
+		}
+
+		// Find the closest approach along the straight line between the
+		//  previous and current relative positions
+		relative := separation(a.transform, b.transform)
+		closest, at := relative, offset
+		aPrevious, aOk := p.previous[a.id]
+		bPrevious, bOk := p.previous[b.id]
+		if aOk && bOk {
+			previous := separation(aPrevious, bPrevious)
+			delta := relative.sub(previous)
+			if lengthSquared := delta.dot(delta); lengthSquared > 0 {
+				fraction := math.Max(0, math.Min(1, -previous.dot(delta)/lengthSquared))
+				closest = localPosition{
+					previous.east + delta.east*fraction,
+					previous.north + delta.north*fraction,
+					previous.up + delta.up*fraction,
+				}
+				at = p.previousOffset + (offset-p.previousOffset)*fraction
+			}
+		}
+
+		distance := closest.length()
+		if distance > p.detector.distance {
+			return
+		}
+
+		key := [2]uint64{a.id, b.id}
+		encounter := p.active[key]
+		if encounter == nil {
+			encounter = p.newEncounter(world, a.id, b.id, at)
+			encounter.Distance = math.Inf(1)
+		}
+		active[key] = encounter
+		encounter.End = offset
+
+		if distance < encounter.Distance {
+			encounter.ClosestApproach = at
+			encounter.Distance = distance
+			encounter.AltitudeDifference = math.Abs(closest.up)
+			encounter.RelativeSpeed = relativeSpeed(aMotion, bMotion)
+			encounter.Bullseye, encounter.BRAA = p.detector.reference.describe(world, a.transform, world.Get(a.id), aMotion)
+		}
+	})
+
+	p.active = active
+	p.previous = current
+	p.previousOffset = offset
+}
+
+func (p *encounterProcessor) newEncounter(world *tacview.World, firstId, secondId uint64, start float64) *Encounter {
+	encounter := &Encounter{
+		ReferenceTime: p.header.ReferenceTime,
+		FirstId:       firstId,
+		SecondId:      secondId,
+		Start:         start,
+	}
+
+	first, second := world.Get(firstId), world.Get(secondId)
+	if property := first.Object.Get("Name"); property != nil {
+		encounter.First = property.Value
+	}
+	if property := first.Object.Get("Pilot"); property != nil {
+		encounter.FirstPilot = property.Value
+	}
+	if property := second.Object.Get("Name"); property != nil {
+		encounter.Second = property.Value
+	}
+	if property := second.Object.Get("Pilot"); property != nil {
+		encounter.SecondPilot = property.Value
+	}
+
+	p.encounters = append(p.encounters, encounter)
+	return encounter
+}
+
+func isStationary(objectMotion *motion) bool {
+	return objectMotion == nil || objectMotion.speed() < encounterStationarySpeed
+}
+
+// relativeSpeed returns the speed of two objects relative to each other, either
+//  motion may be nil for stationary objects.
+func relativeSpeed(a, b *motion) float64 {
+	var east, north, up float64
+	if a != nil {
+		east, north, up = a.velocity()
+	}
+	if b != nil {
+		bEast, bNorth, bUp := b.velocity()
+		east, north, up = east-bEast, north-bNorth, up-bUp
+	}
+	return math.Sqrt(east*east + north*north + up*up)
+}
+
+// EncountersOptions configures Encounters
+type EncountersOptions struct {
+	Options
+	// Distance (in meters) aircraft must come within to be reported, it must be
+	//  positive
+	Distance float64
+	// CollisionWindow is the number of seconds after an encounter in which both
+	//  aircraft being removed marks it as a probable collision
+	CollisionWindow float64
+	// Pilots only reports encounters involving the pilots with the given names
+	//  when set
+	Pilots []string
+	// Reference describes the closest approach of each encounter relative to a
+	//  bullseye or aircraft when set
+	Reference *PositionReference
+}
+
+// Encounters returns every close encounter between aircraft across the inputs,
+//  ordered by closest approach
+func Encounters(ctx context.Context, inputs []io.Reader, options EncountersOptions) ([]*Encounter, error) {
+	options.Options = options.Options.withDefaults()
+	if options.Distance <= 0 {
+		return nil, fmt.Errorf("Encounter distance must be positive")
+	}
+
+	detector := NewEncounterDetector(options.Concurrency, options.Distance, options.CollisionWindow, options.Reference)
+	err := processInputs(ctx, options.Options, inputs, detector)
+	if err != nil {
+		return nil, err
+	}
+
+	encounters := make([]*Encounter, 0)
+	for _, encounter := range detector.Encounters() {
+		if matchesPilot(options.Pilots, encounter.FirstPilot, encounter.SecondPilot) {
+			encounters = append(encounters, encounter)
+		}
+	}
+	return encounters, nil
+}
@@ -0,0 +1,98 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncounters(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    []string
+		pilots   []string
+		expected []string
+	}{
+		{
+			name: "formation",
+			input: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing,Pilot=A", "2,T=0.003|0|1100,Type=Air+FixedWing,Pilot=B",
+				"#10", "1,T=0|0.01|1000", "2,T=0.003|0.01|1100",
+				"#20", "1,T=0|0.02|1000", "2,T=0.001|0.02|1050",
+				"#30", "1,T=0|0.03|1000", "2,T=0.004|0.03|1100",
+			},
+			expected: []string{"1-2 10.0-30.0 at 20.0 distance=122 altitude=50 collision=false"},
+		},
+		{
+			// Crossing head on at 1km/s each between time frames 10km apart
+			name: "high closure",
+			input: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing", "2,T=0.0001|0.18|1000,Type=Air+FixedWing",
+				"#10", "1,T=0|0.045|1000", "2,T=0.0001|0.135|1000",
+				"#20", "1,T=0|0.135|1000", "2,T=0.0001|0.045|1000",
+				"#30", "1,T=0|0.225|1000", "2,T=0.0001|-0.045|1000",
+			},
+			expected: []string{"1-2 15.0-20.0 at 15.0 distance=11 altitude=0 collision=false"},
+		},
+		{
+			// Far from the reference point, 600m apart along the parallel
+			name: "far from the reference point",
+			input: []string{
+				"#0", "1,T=60|60|1000,Type=Air+FixedWing", "2,T=60.0108|60|1000,Type=Air+FixedWing",
+				"#10", "1,T=60|60.01|1000", "2,T=60.0108|60.01|1000",
+				"#20", "1,T=60|60.02|1000", "2,T=60.0083|60.02|1000",
+			},
+			expected: []string{"1-2 20.0-20.0 at 20.0 distance=463 altitude=0 collision=false"},
+		},
+		{
+			// Respawning far away is not a crossing of everything in between
+			name: "jump",
+			input: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing", "2,T=0.0001|5|1000,Type=Air+FixedWing",
+				"#10", "1,T=0|10|1000", "2,T=0.0001|5.001|1000",
+			},
+			expected: []string{},
+		},
+		{
+			name: "collision",
+			input: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing,Pilot=A", "2,T=0.001|0|1000,Type=Air+FixedWing,Pilot=B",
+				"3,T=0.0002|0|0,Type=Air+FixedWing,Pilot=C", "4,T=0.0003|0|0,Type=Air+FixedWing,Pilot=D",
+				"#10", "1,T=0|0.001|1000", "2,T=0.0005|0.001|1000",
+				"#20", "1,T=0|0.002|1000", "2,T=0|0.002|1000",
+				"#21", "-1", "-2",
+			},
+			pilots:   []string{"B"},
+			expected: []string{"1-2 10.0-20.0 at 20.0 distance=0 altitude=0 collision=true"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			encounters, err := Encounters(context.Background(), opsTestInputs(opsTestData(c.input...)), EncountersOptions{
+				Distance:        500,
+				CollisionWindow: 5,
+				Pilots:          c.pilots,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := make([]string, len(encounters))
+			for idx, encounter := range encounters {
+				found[idx] = fmt.Sprintf("%v-%v %.1f-%.1f at %.1f distance=%.0f altitude=%.0f collision=%v",
+					encounter.FirstId, encounter.SecondId, encounter.Start, encounter.End, encounter.ClosestApproach,
+					encounter.Distance, encounter.AltitudeDifference, encounter.ProbableCollision)
+			}
+			if strings.Join(found, "\n") != strings.Join(c.expected, "\n") {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", strings.Join(c.expected, "\n"), strings.Join(found, "\n"))
+			}
+		})
+	}
+
+	_, err := Encounters(context.Background(), opsTestInputs(opsTestData()), EncountersOptions{})
+	if err == nil {
+		t.Fatal("Expected an error for a zero distance")
+	}
+}
@@ -0,0 +1,155 @@
+package ops
+
+import (
+	"context"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+const (
+	// Samples closer together than this many seconds are skipped to reduce
+	//  noise from rounded coordinates
+	enrichSampleInterval = 0.5
+	standardGravity      = 9.80665
+)
+
+type enrichedObject struct {
+	offset        float64
+	transform     tacview.Transform
+	hasVelocity   bool
+	hasTrack      bool
+	groundSpeed   float64
+	verticalSpeed float64
+	track         float64
+}
+
+// kinematicsEnricher adds derived kinematic properties to every moving object.
+//  Only VerticalGForce is defined by the ACMI specification, GroundSpeed,
+//  VerticalSpeed, GroundTrack and TurnRate are custom keys.
+type kinematicsEnricher struct {
+	world   *tacview.World
+	objects map[uint64]*enrichedObject
+}
+
+func newKinematicsEnricher(header *tacview.Header) *kinematicsEnricher {
+	return &kinematicsEnricher{
+		world:   tacview.NewWorld(header),
+		objects: make(map[uint64]*enrichedObject),
+	}
+}
+
+// signedAngleDifference returns the difference in degrees from a to b between -180 and 180
+func signedAngleDifference(a, b float64) float64 {
+	return math.Mod(b-a+540, 360) - 180
+}
+
+func (k *kinematicsEnricher) Filter(tf *tacview.TimeFrame) error {
+	err := k.world.Apply(tf)
+	if err != nil {
+		return err
+	}
+
+	for _, state := range k.world.Removed {
+		delete(k.objects, state.Id())
+	}
+
+	for _, object := range tf.Objects {
+		if object.Id == 0 || object.Deleted || object.Get("T") == nil {
+			continue
+		}
+
+		state := k.world.Get(object.Id)
+		if state == nil || !state.HasTransform || state.Object.HasTags("Static") {
+			continue
+		}
+
+		previous, ok := k.objects[object.Id]
+		if !ok {
+			k.objects[object.Id] = &enrichedObject{offset: tf.Offset, transform: state.Transform}
+			continue
+		}
+
+		elapsed := tf.Offset - previous.offset
+		if elapsed < enrichSampleInterval {
+			continue
+		}
+
+		distance := groundDistance(previous.transform, state.Transform)
+		current := &enrichedObject{
+			offset:        tf.Offset,
+			transform:     state.Transform,
+			hasVelocity:   true,
+			groundSpeed:   distance / elapsed,
+			verticalSpeed: (state.Transform.Altitude - previous.transform.Altitude) / elapsed,
+			hasTrack:      previous.hasTrack,
+			track:         previous.track,
+		}
+		if distance > 0 {
+			current.hasTrack = true
+			current.track = transformBearing(previous.transform, state.Transform)
+		}
+		k.objects[object.Id] = current
+
+		k.set(state, object, "GroundSpeed", current.groundSpeed, 1)
+		k.set(state, object, "VerticalSpeed", current.verticalSpeed, 1)
+		if distance > 0 {
+			k.set(state, object, "GroundTrack", current.track, 1)
+		}
+
+		if !previous.hasVelocity {
+			continue
+		}
+
+		// The turn rate is only known once the object moved over both
+		//  intervals, a stationary object has no track to turn from
+		turnRate := 0.0
+		if previous.hasTrack && distance > 0 {
+			turnRate = signedAngleDifference(previous.track, current.track) / elapsed
+			k.set(state, object, "TurnRate", turnRate, 2)
+		}
+
+		verticalAcceleration := (current.verticalSpeed - previous.verticalSpeed) / elapsed
+		centripetalAcceleration := current.groundSpeed * toRadians(turnRate)
+		loadFactor := math.Hypot(centripetalAcceleration, standardGravity+verticalAcceleration) / standardGravity
+
+		k.set(state, object, "VerticalGForce", loadFactor, 2)
+	}
+
+	return nil
+}
+
+// set adds a derived property to the object unless the recording already
+//  contains the property
+func (k *kinematicsEnricher) set(state *tacview.ObjectState, object *tacview.Object, key string, value float64, precision int) {
+	if state.Object.Get(key) != nil {
+		return
+	}
+	object.Set(key, strconv.FormatFloat(value, 'f', precision, 64))
+}
+
+// EnrichOptions configures Enrich
+type EnrichOptions struct {
+	Options
+}
+
+// Enrich rewrites the ACMI input to the output adding kinematic properties
+//  derived from the successive transforms of each object. Apart from
+//  VerticalGForce these use custom keys rather than the ones defined by the
+//  ACMI specification. Properties already recorded for an object are never
+//  overwritten.
+func Enrich(ctx context.Context, input io.Reader, output io.Writer, options EnrichOptions) error {
+	options.Options = options.Options.withDefaults()
+
+	reader, err := options.newReader(ctx, 0, input)
+	if err != nil {
+		return err
+	}
+
+	filters := []tacview.FrameFilter{newKinematicsEnricher(&reader.Header)}
+	return normalize(options.Concurrency, reader, output, filters, func(o *tacview.Object) bool {
+		return true
+	})
+}
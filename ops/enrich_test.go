@@ -0,0 +1,76 @@
+package ops
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEnrich(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name: "climbing north",
+			input: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing",
+				"#1", "1,T=0|0.001|1010",
+				"#2", "1,T=0|0.002|1030",
+			},
+			expected: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing",
+				"#1", "1,T=0|0.001|1010,GroundSpeed=110.6,VerticalSpeed=10.0,GroundTrack=0.0",
+				"#2", "1,T=0|0.002|1030,GroundSpeed=110.6,VerticalSpeed=20.0,GroundTrack=0.0,TurnRate=0.00,VerticalGForce=2.02",
+			},
+		},
+		{
+			// No turn rate until the object moved over two intervals
+			name: "stationary before moving",
+			input: []string{
+				"#0", "1,T=0|0|1000,Type=Air+Rotorcraft",
+				"#1", "1,T=0|0|1000",
+				"#2", "1,T=0.001|0|1000",
+				"#3", "1,T=0.002|0|1000",
+			},
+			expected: []string{
+				"#0", "1,T=0|0|1000,Type=Air+Rotorcraft",
+				"#1", "1,T=0|0|1000,GroundSpeed=0.0,VerticalSpeed=0.0",
+				"#2", "1,T=0.001|0|1000,GroundSpeed=111.3,VerticalSpeed=0.0,GroundTrack=90.0,VerticalGForce=1.00",
+				"#3", "1,T=0.002|0|1000,GroundSpeed=111.3,VerticalSpeed=0.0,GroundTrack=90.0,TurnRate=0.00,VerticalGForce=1.00",
+			},
+		},
+		{
+			name: "recorded properties",
+			input: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing,GroundSpeed=100,VerticalSpeed=0",
+				"2,T=0|0|0,Type=Ground+Static+Building",
+				"#0.2", "1,T=0|0.0002|1000",
+				"#1", "1,T=0|0.001|1000", "2,T=0.001|0|0",
+			},
+			expected: []string{
+				"#0", "1,T=0|0|1000,Type=Air+FixedWing,GroundSpeed=100,VerticalSpeed=0",
+				"2,T=0|0|0,Type=Ground+Static+Building",
+				"#0.2", "1,T=0|0.0002|1000",
+				"#1", "1,T=0|0.001|1000,GroundTrack=0.0", "2,T=0.001|0|0",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Enrich(context.Background(), strings.NewReader(opsTestData(c.input...)), &output, EnrichOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := strings.Join(c.expected, "\n")
+			if frames := timeFrames(output.String()); frames != expected {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", expected, frames)
+			}
+		})
+	}
+}
@@ -0,0 +1,501 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// Thresholds used to detect formations and refuelling contacts. Speeds are in
+//  meters per second, distances in meters and times in seconds.
+const (
+	formationSampleInterval = 1.0
+	formationMinimumSpeed   = 50.0
+	formationMaxHeading     = 15.0
+	// The contact position is a box behind and below the tanker which covers
+	//  both boom and drogue refuelling
+	refuelingBehindMin  = 10.0
+	refuelingBehindMax  = 60.0
+	refuelingLateralMax = 25.0
+	refuelingBelowMax   = 25.0
+	refuelingSessionGap = 60.0
+)
+
+// Formation tolerance (in meters) and minimum duration (in seconds) used when
+//  none is configured
+const (
+	DefaultFormationTolerance   = 50.0
+	DefaultFormationMinDuration = 30.0
+)
+
+// refuelingRange is the furthest a receiver in the contact position may be
+//  from the tanker
+var refuelingRange = math.Sqrt(refuelingBehindMax*refuelingBehindMax + refuelingLateralMax*refuelingLateralMax +
+	refuelingBelowMax*refuelingBelowMax)
+
+// RelativePosition is a position in meters relative to another aircraft along
+//  its heading. Forward is positive ahead, right is positive to the right and
+//  up is positive above.
+type RelativePosition struct {
+	Forward float64 `json:"forward"`
+	Right   float64 `json:"right"`
+	Up      float64 `json:"up"`
+}
+
+// Formation describes a period in which a wingman held a steady position
+//  relative to their lead. Position is the average position of the wingman
+//  relative to the lead, deviation is the standard deviation of each axis and
+//  stability is the root mean square distance from the average position.
+type Formation struct {
+	ReferenceTime time.Time        `json:"reference_time"`
+	LeadId        uint64           `json:"lead_id"`
+	Lead          string           `json:"lead"`
+	LeadPilot     string           `json:"lead_pilot"`
+	WingmanId     uint64           `json:"wingman_id"`
+	Wingman       string           `json:"wingman"`
+	WingmanPilot  string           `json:"wingman_pilot"`
+	Start         float64          `json:"start"`
+	End           float64          `json:"end"`
+	Duration      float64          `json:"duration"`
+	Position      RelativePosition `json:"position"`
+	Deviation     RelativePosition `json:"deviation"`
+	Stability     float64          `json:"stability"`
+}
+
+// Refueling describes a refuelling session between a `Tanker` tagged aircraft
+//  and a receiver. A session contains one or more contacts, the position and
+//  deviation of the receiver are measured while in the contact position.
+type Refueling struct {
+	ReferenceTime time.Time        `json:"reference_time"`
+	TankerId      uint64           `json:"tanker_id"`
+	Tanker        string           `json:"tanker"`
+	TankerPilot   string           `json:"tanker_pilot"`
+	ReceiverId    uint64           `json:"receiver_id"`
+	Receiver      string           `json:"receiver"`
+	ReceiverPilot string           `json:"receiver_pilot"`
+	Start         float64          `json:"start"`
+	End           float64          `json:"end"`
+	Contacts      int              `json:"contacts"`
+	ContactTime   float64          `json:"contact_time"`
+	Position      RelativePosition `json:"position"`
+	Deviation     RelativePosition `json:"deviation"`
+	Stability     float64          `json:"stability"`
+}
+
+// relativeStats accumulates the mean and variance of relative positions
+type relativeStats struct {
+	count int
+	mean  [3]float64
+	m2    [3]float64
+}
+
+func (s *relativeStats) add(position RelativePosition) {
+	s.count++
+	for idx, value := range [3]float64{position.Forward, position.Right, position.Up} {
+		delta := value - s.mean[idx]
+		s.mean[idx] += delta / float64(s.count)
+		s.m2[idx] += delta * (value - s.mean[idx])
+	}
+}
+
+func (s *relativeStats) position() RelativePosition {
+	return RelativePosition{s.mean[0], s.mean[1], s.mean[2]}
+}
+
+func (s *relativeStats) deviation() RelativePosition {
+	if s.count == 0 {
+		return RelativePosition{}
+	}
+	return RelativePosition{
+		math.Sqrt(s.m2[0] / float64(s.count)),
+		math.Sqrt(s.m2[1] / float64(s.count)),
+		math.Sqrt(s.m2[2] / float64(s.count)),
+	}
+}
+
+func (s *relativeStats) stability() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return math.Sqrt((s.m2[0] + s.m2[1] + s.m2[2]) / float64(s.count))
+}
+
+func (s *relativeStats) distance(position RelativePosition) float64 {
+	return math.Sqrt(math.Pow(position.Forward-s.mean[0], 2) + math.Pow(position.Right-s.mean[1], 2) +
+		math.Pow(position.Up-s.mean[2], 2))
+}
+
+// FormationAnalyzer detects formations and refuelling sessions between pairs of
+//  aircraft based on the position of each aircraft relative to the other.
+type FormationAnalyzer struct {
+	concurrency int
+	maxSpacing  float64
+	tolerance   float64
+	minDuration float64
+	formations  []*Formation
+	refuelings  []*Refueling
+}
+
+// NewFormationAnalyzer creates a new FormationAnalyzer
+func NewFormationAnalyzer(concurrency int, maxSpacing, tolerance, minDuration float64) *FormationAnalyzer {
+	return &FormationAnalyzer{
+		concurrency: concurrency,
+		maxSpacing:  maxSpacing,
+		tolerance:   tolerance,
+		minDuration: minDuration,
+		formations:  make([]*Formation, 0),
+		refuelings:  make([]*Refueling, 0),
+	}
+}
+
+// Formations returns every formation seen, ordered by start time
+func (f *FormationAnalyzer) Formations() []*Formation {
+	sort.SliceStable(f.formations, func(i, j int) bool {
+		start := OffsetTime(f.formations[i].ReferenceTime, f.formations[i].Start)
+		return start.Before(OffsetTime(f.formations[j].ReferenceTime, f.formations[j].Start))
+	})
+	return f.formations
+}
+
+// Refuelings returns every refuelling session seen, ordered by start time
+func (f *FormationAnalyzer) Refuelings() []*Refueling {
+	sort.SliceStable(f.refuelings, func(i, j int) bool {
+		start := OffsetTime(f.refuelings[i].ReferenceTime, f.refuelings[i].Start)
+		return start.Before(OffsetTime(f.refuelings[j].ReferenceTime, f.refuelings[j].Start))
+	})
+	return f.refuelings
+}
+
+type formationSegment struct {
+	formation *Formation
+	stats     relativeStats
+}
+
+type refuelingSession struct {
+	refueling    *Refueling
+	stats        relativeStats
+	inContact    bool
+	contactStart float64
+	lastContact  float64
+}
+
+// formationProcessor tracks formations and refuelling sessions by the ids of
+//  both aircraft, lowest first. Formations end as soon as the pair stops
+//  flying in formation while refuelling sessions outlast breaks in contact.
+type formationProcessor struct {
+	analyzer   *FormationAnalyzer
+	header     *tacview.Header
+	world      *tacview.World
+	motions    *motionTracker
+	lastSample float64
+	segments   map[[2]uint64]*formationSegment
+	sessions   map[[2]uint64]*refuelingSession
+}
+
+// ProcessFile records every formation and refuelling session within the file
+func (f *FormationAnalyzer) ProcessFile(reader *tacview.Reader) error {
+	processor := &formationProcessor{
+		analyzer:   f,
+		header:     &reader.Header,
+		motions:    newMotionTracker(1),
+		lastSample: math.Inf(-1),
+		segments:   make(map[[2]uint64]*formationSegment),
+		sessions:   make(map[[2]uint64]*refuelingSession),
+	}
+
+	_, err := reconstruct(f.concurrency, reader, func(world *tacview.World, tf *tacview.TimeFrame) error {
+		processor.world = world
+		processor.motions.update(world, tf)
+		for _, state := range world.Removed {
+			processor.remove(state.Id())
+		}
+		if tf.Offset-processor.lastSample >= formationSampleInterval {
+			processor.lastSample = tf.Offset
+			processor.sample(tf.Offset)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range processor.segments {
+		processor.finishFormation(segment)
+	}
+	for _, session := range processor.sessions {
+		processor.finishRefueling(session)
+	}
+	return nil
+}
+
+// relativePosition returns the position of an object relative to another
+//  along the others heading
+func relativePosition(from tacview.Transform, fromHeading float64, to tacview.Transform) RelativePosition {
+	local := toLocalPosition(to, from.Latitude, from.Longitude)
+	heading := toRadians(fromHeading)
+	return RelativePosition{
+		Forward: local.east*math.Sin(heading) + local.north*math.Cos(heading),
+		Right:   local.east*math.Cos(heading) - local.north*math.Sin(heading),
+		Up:      to.Altitude - from.Altitude,
+	}
+}
+
+func (p *formationProcessor) sample(offset float64) {
+	grid := newSpatialGrid(math.Max(p.analyzer.maxSpacing, refuelingRange), p.header)
+	for _, state := range p.world.Objects {
+		if state.HasTransform && isAircraft(state.Object) {
+			grid.insert(state.Id(), state.Transform)
+		}
+	}
+
+	segments := make(map[[2]uint64]*formationSegment)
+	grid.pairs(func(a, b *gridEntry) {
+		if a.id > b.id {
+			a, b = b, a
+		}
+
+		key := [2]uint64{a.id, b.id}
+		first, second := p.world.Get(a.id), p.world.Get(b.id)
+		firstMotion, secondMotion := p.motions.get(a.id), p.motions.get(b.id)
+		firstHeading, secondHeading := heading(first, firstMotion), heading(second, secondMotion)
+		p.sampleRefueling(key, first, firstHeading, second, secondHeading, offset)
+
+		if slantRange(a.transform, b.transform) > p.analyzer.maxSpacing ||
+			firstMotion == nil || firstMotion.groundSpeed < formationMinimumSpeed ||
+			secondMotion == nil || secondMotion.groundSpeed < formationMinimumSpeed ||
+			angleDifference(firstHeading, secondHeading) > formationMaxHeading {
+			return
+		}
+		segments[key] = p.sampleFormation(p.segments[key], first, firstHeading, second, secondHeading, offset)
+	})
+
+	for key, segment := range p.segments {
+		if _, ok := segments[key]; !ok {
+			p.finishFormation(segment)
+		}
+	}
+	p.segments = segments
+
+	// A contact ends when the receiver leaves the contact position, the session
+	//  once there has been no contact for refuelingSessionGap seconds
+	for key, session := range p.sessions {
+		if session.lastContact == offset {
+			continue
+		}
+
+		if session.inContact {
+			session.inContact = false
+			session.refueling.ContactTime += session.lastContact - session.contactStart
+		}
+		if offset-session.lastContact > refuelingSessionGap {
+			p.finishRefueling(session)
+			delete(p.sessions, key)
+		}
+	}
+}
+
+// remove ends every refuelling session involving the object
+func (p *formationProcessor) remove(id uint64) {
+	for key, session := range p.sessions {
+		if key[0] == id || key[1] == id {
+			p.finishRefueling(session)
+			delete(p.sessions, key)
+		}
+	}
+}
+
+// sampleFormation extends the formation segment with the current positions of
+//  the pair, returning the segment the pair is now in
+func (p *formationProcessor) sampleFormation(segment *formationSegment, first *tacview.ObjectState, firstHeading float64, second *tacview.ObjectState, secondHeading float64, offset float64) *formationSegment {
+	if segment != nil {
+		formation := segment.formation
+
+		var position RelativePosition
+		if formation.LeadId == first.Id() {
+			position = relativePosition(first.Transform, firstHeading, second.Transform)
+		} else {
+			position = relativePosition(second.Transform, secondHeading, first.Transform)
+		}
+
+		if segment.stats.distance(position) <= p.analyzer.tolerance {
+			segment.stats.add(position)
+			formation.End = offset
+			return segment
+		}
+
+		p.finishFormation(segment)
+	}
+
+	// The aircraft furthest ahead leads the formation
+	lead, leadHeading, wingman := first, firstHeading, second
+	position := relativePosition(first.Transform, firstHeading, second.Transform)
+	if position.Forward > 0 {
+		lead, leadHeading, wingman = second, secondHeading, first
+		position = relativePosition(lead.Transform, leadHeading, wingman.Transform)
+	}
+
+	formation := &Formation{
+		ReferenceTime: p.header.ReferenceTime,
+		LeadId:        lead.Id(),
+		WingmanId:     wingman.Id(),
+		Start:         offset,
+		End:           offset,
+	}
+	formation.Lead, formation.LeadPilot = formationParticipant(lead)
+	formation.Wingman, formation.WingmanPilot = formationParticipant(wingman)
+
+	segment = &formationSegment{formation: formation}
+	segment.stats.add(position)
+	return segment
+}
+
+// sampleRefueling records a contact when the receiver is in the contact
+//  position behind the tanker
+func (p *formationProcessor) sampleRefueling(key [2]uint64, first *tacview.ObjectState, firstHeading float64, second *tacview.ObjectState, secondHeading float64, offset float64) {
+	tanker, tankerHeading, receiver := first, firstHeading, second
+	if !tanker.Object.HasTags("Tanker") {
+		tanker, tankerHeading, receiver = second, secondHeading, first
+	}
+	if !tanker.Object.HasTags("Tanker") || receiver.Object.HasTags("Tanker") {
+		return
+	}
+
+	position := relativePosition(tanker.Transform, tankerHeading, receiver.Transform)
+	contact := -position.Forward >= refuelingBehindMin && -position.Forward <= refuelingBehindMax &&
+		math.Abs(position.Right) <= refuelingLateralMax && position.Up <= 0 && -position.Up <= refuelingBelowMax
+
+	if !contact {
+		return
+	}
+
+	session := p.sessions[key]
+	if session == nil {
+		refueling := &Refueling{
+			ReferenceTime: p.header.ReferenceTime,
+			TankerId:      tanker.Id(),
+			ReceiverId:    receiver.Id(),
+			Start:         offset,
+		}
+		refueling.Tanker, refueling.TankerPilot = formationParticipant(tanker)
+		refueling.Receiver, refueling.ReceiverPilot = formationParticipant(receiver)
+
+		session = &refuelingSession{refueling: refueling}
+		p.sessions[key] = session
+	}
+
+	if !session.inContact {
+		session.inContact = true
+		session.contactStart = offset
+		session.refueling.Contacts++
+	}
+	session.lastContact = offset
+	session.refueling.End = offset
+	session.stats.add(position)
+}
+
+func (p *formationProcessor) finishFormation(segment *formationSegment) {
+	formation := segment.formation
+	formation.Duration = formation.End - formation.Start
+	if formation.Duration < p.analyzer.minDuration {
+		return
+	}
+
+	formation.Position = segment.stats.position()
+	formation.Deviation = segment.stats.deviation()
+	formation.Stability = segment.stats.stability()
+	p.analyzer.formations = append(p.analyzer.formations, formation)
+}
+
+func (p *formationProcessor) finishRefueling(session *refuelingSession) {
+	if session.inContact {
+		session.refueling.ContactTime += session.lastContact - session.contactStart
+	}
+
+	refueling := session.refueling
+	refueling.Position = session.stats.position()
+	refueling.Deviation = session.stats.deviation()
+	refueling.Stability = session.stats.stability()
+	p.analyzer.refuelings = append(p.analyzer.refuelings, refueling)
+}
+
+func formationParticipant(state *tacview.ObjectState) (string, string) {
+	var name, pilot string
+	if property := state.Object.Get("Name"); property != nil {
+		name = property.Value
+	}
+	if property := state.Object.Get("Pilot"); property != nil {
+		pilot = property.Value
+	}
+	return name, pilot
+}
+
+// FormationsOptions configures Formations
+type FormationsOptions struct {
+	Options
+	// MaxSpacing is the maximum distance (in meters) between aircraft flying
+	//  in formation, it must be positive
+	MaxSpacing float64
+	// Tolerance is the maximum distance (in meters) a wingman may stray from
+	//  their average position in the formation, defaulting to
+	//  DefaultFormationTolerance
+	Tolerance float64
+	// MinDuration is the minimum number of seconds a formation must be held,
+	//  defaulting to DefaultFormationMinDuration
+	MinDuration float64
+	// Pilots only reports formations involving the pilots with the given names
+	//  when set
+	Pilots []string
+}
+
+func (o FormationsOptions) withDefaults() FormationsOptions {
+	o.Options = o.Options.withDefaults()
+	if o.Tolerance <= 0 {
+		o.Tolerance = DefaultFormationTolerance
+	}
+	if o.MinDuration <= 0 {
+		o.MinDuration = DefaultFormationMinDuration
+	}
+	return o
+}
+
+// FormationsResult contains every formation and refuelling session found
+type FormationsResult struct {
+	Formations []*Formation `json:"formations"`
+	Refuelings []*Refueling `json:"refuelings"`
+}
+
+// Formations returns every period of formation flight and air-to-air
+//  refuelling across the inputs, ordered by start time
+func Formations(ctx context.Context, inputs []io.Reader, options FormationsOptions) (*FormationsResult, error) {
+	options = options.withDefaults()
+	if options.MaxSpacing <= 0 {
+		return nil, fmt.Errorf("Maximum formation spacing must be positive")
+	}
+
+	analyzer := NewFormationAnalyzer(options.Concurrency, options.MaxSpacing, options.Tolerance, options.MinDuration)
+	err := processInputs(ctx, options.Options, inputs, analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FormationsResult{
+		Formations: make([]*Formation, 0),
+		Refuelings: make([]*Refueling, 0),
+	}
+	for _, formation := range analyzer.Formations() {
+		if matchesPilot(options.Pilots, formation.LeadPilot, formation.WingmanPilot) {
+			result.Formations = append(result.Formations, formation)
+		}
+	}
+	for _, refueling := range analyzer.Refuelings() {
+		if matchesPilot(options.Pilots, refueling.TankerPilot, refueling.ReceiverPilot) {
+			result.Refuelings = append(result.Refuelings, refueling)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,179 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/b1naryth1ef/jambon/tacview/geo"
+)
+
+// formationTestAircraft is positioned in meters east, north and up of a lead
+//  flying north at 100m/s
+type formationTestAircraft struct {
+	properties string
+	position   func(second int) geo.ENU
+}
+
+func formationOffset(east, north, up float64) func(int) geo.ENU {
+	return func(int) geo.ENU {
+		return geo.ENU{East: east, North: north, Up: up}
+	}
+}
+
+// formationFlight returns a time frame every second for the aircraft, the
+//  first of which is the lead starting at the given position
+func formationFlight(latitude, longitude float64, seconds int, aircraft ...formationTestAircraft) []string {
+	start := geo.Point{Latitude: latitude, Longitude: longitude, Altitude: 3000}
+
+	lines := make([]string, 0)
+	for second := 0; second <= seconds; second++ {
+		lines = append(lines, fmt.Sprintf("#%v", second))
+		lead := geo.FromENU(start, geo.ENU{North: 100 * float64(second)})
+		for idx, a := range aircraft {
+			point := geo.FromENU(lead, a.position(second))
+			line := fmt.Sprintf("%v,T=%v|%v|%v", idx+1, point.Longitude, point.Latitude, point.Altitude)
+			if second == 0 {
+				line += "," + a.properties
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestFormations(t *testing.T) {
+	const fighter = "Type=Air+FixedWing,Name=F-16C"
+	const tanker = "Type=Air+FixedWing+Tanker,Name=KC-135"
+
+	cases := []struct {
+		name       string
+		input      []string
+		formations []string
+		refuelings []string
+	}{
+		{
+			name: "echelon",
+			input: formationFlight(0, 0, 60,
+				formationTestAircraft{fighter, formationOffset(0, 0, 0)},
+				formationTestAircraft{fighter, formationOffset(30, -50, 10)},
+			),
+			formations: []string{"1>2 1-60 forward=-50 right=30 up=10"},
+		},
+		{
+			// The wingman leads once ahead
+			name: "lead change",
+			input: formationFlight(0, 0, 60,
+				formationTestAircraft{fighter, formationOffset(0, 0, 0)},
+				formationTestAircraft{fighter, func(second int) geo.ENU {
+					if second > 30 {
+						return geo.ENU{East: 30, North: 50, Up: 5}
+					}
+					return geo.ENU{East: 30, North: -50, Up: 5}
+				}},
+			),
+			formations: []string{"1>2 1-30 forward=-50 right=30 up=5", "2>1 31-60 forward=-50 right=-30 up=-5"},
+		},
+		{
+			// Far from the reference point, 600m apart along the parallel
+			name: "beyond the maximum spacing",
+			input: formationFlight(60, 60, 60,
+				formationTestAircraft{fighter, formationOffset(0, 0, 0)},
+				formationTestAircraft{fighter, formationOffset(600, 0, 0)},
+			),
+		},
+		{
+			name: "far from the reference point",
+			input: formationFlight(60, 60, 60,
+				formationTestAircraft{fighter, formationOffset(0, 0, 0)},
+				formationTestAircraft{fighter, formationOffset(400, -20, 0)},
+			),
+			formations: []string{"1>2 1-60 forward=-20 right=400 up=0"},
+		},
+		{
+			name: "refueling",
+			input: formationFlight(0, 0, 120,
+				formationTestAircraft{tanker, formationOffset(0, 0, 0)},
+				formationTestAircraft{fighter, func(second int) geo.ENU {
+					if second >= 40 && second < 60 || second >= 80 && second < 100 {
+						return geo.ENU{North: -30, Up: -10}
+					}
+					return geo.ENU{North: -65, Up: -10}
+				}},
+			),
+			formations: []string{"1>2 1-39 forward=-65 right=0 up=-10", "1>2 100-120 forward=-65 right=0 up=-10"},
+			refuelings: []string{"1>2 40-99 contacts=2 contact=38 forward=-30 right=0 up=-10"},
+		},
+		{
+			// Leaving the formation between contacts doesn't end the session
+			name: "refueling break away",
+			input: formationFlight(0, 0, 90,
+				formationTestAircraft{tanker, formationOffset(0, 0, 0)},
+				formationTestAircraft{fighter, func(second int) geo.ENU {
+					if second >= 10 && second < 30 || second >= 50 && second < 70 {
+						return geo.ENU{North: -30, Up: -10}
+					} else if second >= 30 && second < 50 {
+						return geo.ENU{East: 600}
+					}
+					return geo.ENU{North: -65, Up: -10}
+				}},
+			),
+			formations: []string{"1>2 70-90 forward=-65 right=0 up=-10"},
+			refuelings: []string{"1>2 10-69 contacts=2 contact=38 forward=-30 right=0 up=-10"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := Formations(context.Background(), opsTestInputs(opsTestData(c.input...)), FormationsOptions{
+				MaxSpacing:  500,
+				Tolerance:   20,
+				MinDuration: 20,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			formations := make([]string, len(result.Formations))
+			for idx, formation := range result.Formations {
+				formations[idx] = fmt.Sprintf("%v>%v %v-%v forward=%.0f right=%.0f up=%.0f",
+					formation.LeadId, formation.WingmanId, formation.Start, formation.End,
+					formation.Position.Forward, formation.Position.Right, formation.Position.Up)
+			}
+			if strings.Join(formations, "\n") != strings.Join(c.formations, "\n") {
+				t.Fatalf("Expected formations:\n%v\nfound:\n%v", strings.Join(c.formations, "\n"), strings.Join(formations, "\n"))
+			}
+
+			refuelings := make([]string, len(result.Refuelings))
+			for idx, refueling := range result.Refuelings {
+				refuelings[idx] = fmt.Sprintf("%v>%v %v-%v contacts=%v contact=%v forward=%.0f right=%.0f up=%.0f",
+					refueling.TankerId, refueling.ReceiverId, refueling.Start, refueling.End, refueling.Contacts,
+					refueling.ContactTime, refueling.Position.Forward, refueling.Position.Right, refueling.Position.Up)
+			}
+			if strings.Join(refuelings, "\n") != strings.Join(c.refuelings, "\n") {
+				t.Fatalf("Expected refuelings:\n%v\nfound:\n%v", strings.Join(c.refuelings, "\n"), strings.Join(refuelings, "\n"))
+			}
+		})
+	}
+
+	_, err := Formations(context.Background(), opsTestInputs(opsTestData()), FormationsOptions{})
+	if err == nil {
+		t.Fatal("Expected an error for a zero maximum spacing")
+	}
+
+	// The tolerance and minimum duration default to 50m and 30 seconds
+	input := formationFlight(0, 0, 60,
+		formationTestAircraft{fighter, formationOffset(0, 0, 0)},
+		formationTestAircraft{fighter, func(second int) geo.ENU {
+			return geo.ENU{East: 30 + float64(second%2)*10, North: -50, Up: 10}
+		}},
+	)
+	result, err := Formations(context.Background(), opsTestInputs(opsTestData(input...)), FormationsOptions{MaxSpacing: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Formations) != 1 || result.Formations[0].Duration != 59 {
+		t.Fatalf("Expected a single formation of 59 seconds, found %v", len(result.Formations))
+	}
+}
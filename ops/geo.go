@@ -0,0 +1,55 @@
+package ops
+
+import (
+	"math"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+	"github.com/b1naryth1ef/jambon/tacview/geo"
+)
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// groundDistance returns the distance in meters along the surface of the earth between two transforms
+func groundDistance(a, b tacview.Transform) float64 {
+	return geo.Distance(geo.FromTransform(a), geo.FromTransform(b))
+}
+
+// slantRange returns the straight line distance in meters between two transforms
+func slantRange(a, b tacview.Transform) float64 {
+	return geo.SlantRange(geo.FromTransform(a), geo.FromTransform(b))
+}
+
+// transformBearing returns the true bearing in degrees from one transform to another
+func transformBearing(a, b tacview.Transform) float64 {
+	return geo.Bearing(geo.FromTransform(a), geo.FromTransform(b))
+}
+
+// MetersToNauticalMiles converts a distance in meters to nautical miles
+func MetersToNauticalMiles(meters float64) float64 {
+	return meters / 1852
+}
+
+// MetersToFeet converts a distance in meters to feet
+func MetersToFeet(meters float64) float64 {
+	return meters / 0.3048
+}
+
+// MetersPerSecondToKnots converts a speed in meters per second to knots
+func MetersPerSecondToKnots(speed float64) float64 {
+	return speed * 3600 / 1852
+}
+
+// angleDifference returns the absolute difference in degrees between two headings
+func angleDifference(a, b float64) float64 {
+	diff := math.Mod(math.Abs(a-b), 360)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return diff
+}
@@ -0,0 +1,124 @@
+package ops
+
+import (
+	"math"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+	"github.com/b1naryth1ef/jambon/tacview/geo"
+)
+
+// localPosition is a position in meters east, north and up of a fixed origin
+type localPosition struct {
+	east  float64
+	north float64
+	up    float64
+}
+
+func (p localPosition) sub(other localPosition) localPosition {
+	return localPosition{p.east - other.east, p.north - other.north, p.up - other.up}
+}
+
+func (p localPosition) dot(other localPosition) float64 {
+	return p.east*other.east + p.north*other.north + p.up*other.up
+}
+
+func (p localPosition) length() float64 {
+	return math.Sqrt(p.dot(p))
+}
+
+// toLocalPosition projects a transform onto the east, north plane tangent to
+//  the given origin. The up component is the altitude of the transform rather
+//  than the height above the tangent plane so nearby objects can be compared
+//  without the curvature of the earth skewing their vertical separation. The
+//  projection is only accurate close to the origin, so distances must be
+//  measured with the origin at one of the objects being compared.
+func toLocalPosition(transform tacview.Transform, originLatitude, originLongitude float64) localPosition {
+	enu := geo.ToENU(geo.Point{Latitude: originLatitude, Longitude: originLongitude}, geo.FromTransform(transform))
+	return localPosition{
+		east:  enu.East,
+		north: enu.North,
+		up:    transform.Altitude,
+	}
+}
+
+// separation returns the position of b relative to a in the local frame of a,
+//  with the up component being the difference in altitude
+func separation(a, b tacview.Transform) localPosition {
+	position := toLocalPosition(b, a.Latitude, a.Longitude)
+	position.up = b.Altitude - a.Altitude
+	return position
+}
+
+// Cells are enlarged by this fraction to cover objects below sea level and
+//  rounding in the projection
+const gridMargin = 0.01
+
+type gridCell struct {
+	x int64
+	y int64
+}
+
+type gridEntry struct {
+	id        uint64
+	transform tacview.Transform
+}
+
+// spatialGrid buckets objects into square cells by their horizontal position
+//  so that nearby objects can be found without comparing every pair. Objects
+//  are projected at sea level onto the plane tangent to a fixed origin, which
+//  never places two objects further apart than their ground distance or slant
+//  range but distorts them further from the origin. The grid therefore only
+//  finds candidate pairs, their distance must be measured separately.
+type spatialGrid struct {
+	cellSize        float64
+	originLatitude  float64
+	originLongitude float64
+	cells           map[gridCell][]*gridEntry
+}
+
+func newSpatialGrid(cellSize float64, header *tacview.Header) *spatialGrid {
+	return &spatialGrid{
+		cellSize:        cellSize * (1 + gridMargin),
+		originLatitude:  header.ReferenceLatitude,
+		originLongitude: header.ReferenceLongitude,
+		cells:           make(map[gridCell][]*gridEntry),
+	}
+}
+
+func (g *spatialGrid) insert(id uint64, transform tacview.Transform) {
+	origin := geo.Point{Latitude: g.originLatitude, Longitude: g.originLongitude}
+	projected := geo.ToENU(origin, geo.Point{Latitude: transform.Latitude, Longitude: transform.Longitude})
+	cell := gridCell{
+		x: int64(math.Floor(projected.East / g.cellSize)),
+		y: int64(math.Floor(projected.North / g.cellSize)),
+	}
+	g.cells[cell] = append(g.cells[cell], &gridEntry{
+		id:        id,
+		transform: transform,
+	})
+}
+
+// neighbourCells are visited from each cell so that every pair of adjacent
+//  cells is only compared once.
+var neighbourCells = []gridCell{{1, -1}, {1, 0}, {1, 1}, {0, 1}}
+
+// pairs calls fn once for every pair of objects in the same or adjacent cells,
+//  which includes every pair closer together than the cell size.
+func (g *spatialGrid) pairs(fn func(a, b *gridEntry)) {
+	for cell, entries := range g.cells {
+		for i := range entries {
+			for j := i + 1; j < len(entries); j++ {
+				fn(entries[i], entries[j])
+			}
+		}
+
+		for _, offset := range neighbourCells {
+			neighbours := g.cells[gridCell{cell.x + offset.x, cell.y + offset.y}]
+			for _, a := range entries {
+				for _, b := range neighbours {
+					fn(a, b)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package ops
+
+import (
+	"context"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+	"github.com/b1naryth1ef/jambon/tacview/geo"
+)
+
+const impactLauncherRange = 200.0
+
+// DefaultMaxTargetDistance is the maximum distance (in meters) to the nearest
+//  ground object used when none is configured
+const DefaultMaxTargetDistance = 1000.0
+
+// Impact describes the release and impact of a single bomb or rocket. Distances
+//  are in meters, speeds in meters per second, angles in degrees and times are
+//  offsets in seconds. Range error is positive when long and deflection error
+//  is positive when right of the release track.
+type Impact struct {
+	ReferenceTime   time.Time `json:"reference_time"`
+	WeaponId        uint64    `json:"weapon_id"`
+	Weapon          string    `json:"weapon"`
+	LauncherId      uint64    `json:"launcher_id"`
+	Launcher        string    `json:"launcher"`
+	Pilot           string    `json:"pilot"`
+	Release         float64   `json:"release"`
+	ReleaseAltitude float64   `json:"release_altitude"`
+	ReleaseSpeed    float64   `json:"release_speed"`
+	DiveAngle       float64   `json:"dive_angle"`
+	ReleaseTrack    float64   `json:"release_track"`
+	Impact          float64   `json:"impact"`
+	ImpactLatitude  float64   `json:"impact_latitude"`
+	ImpactLongitude float64   `json:"impact_longitude"`
+	TargetId        uint64    `json:"target_id"`
+	Target          string    `json:"target"`
+	MissDistance    *float64  `json:"miss_distance"`
+	RangeError      *float64  `json:"range_error"`
+	DeflectionError *float64  `json:"deflection_error"`
+	// Bullseye and BRAA describe the impact point when a position reference is
+	//  configured
+	Bullseye *BullseyeCall `json:"bullseye,omitempty"`
+	BRAA     *BRAACall     `json:"braa,omitempty"`
+}
+
+// PilotAccuracy aggregates the impacts of a single pilot
+type PilotAccuracy struct {
+	Pilot   string `json:"pilot"`
+	Impacts int    `json:"impacts"`
+	// CEP is the median miss distance of all impacts with a target
+	CEP                 float64 `json:"cep"`
+	MeanRangeError      float64 `json:"mean_range_error"`
+	MeanDeflectionError float64 `json:"mean_deflection_error"`
+}
+
+// ImpactAnalyzer detects bomb and rocket releases and measures their impact
+//  point against either a fixed target or the nearest ground object.
+type ImpactAnalyzer struct {
+	concurrency       int
+	target            *geoPoint
+	maxTargetDistance float64
+	reference         *PositionReference
+	impacts           []*Impact
+}
+
+// NewImpactAnalyzer creates a new ImpactAnalyzer. When target is nil impacts
+//  are measured against the nearest ground object within maxTargetDistance.
+//  Impact points are described relative to the reference when it isn't nil.
+func NewImpactAnalyzer(concurrency int, targetLatitude, targetLongitude *float64, maxTargetDistance float64, reference *PositionReference) *ImpactAnalyzer {
+	analyzer := &ImpactAnalyzer{
+		concurrency:       concurrency,
+		maxTargetDistance: maxTargetDistance,
+		reference:         reference,
+		impacts:           make([]*Impact, 0),
+	}
+	if targetLatitude != nil && targetLongitude != nil {
+		analyzer.target = &geoPoint{latitude: *targetLatitude, longitude: *targetLongitude}
+	}
+	return analyzer
+}
+
+// Impacts returns every impact seen, ordered by release time
+func (a *ImpactAnalyzer) Impacts() []*Impact {
+	sort.SliceStable(a.impacts, func(i, j int) bool {
+		release := OffsetTime(a.impacts[i].ReferenceTime, a.impacts[i].Release)
+		return release.Before(OffsetTime(a.impacts[j].ReferenceTime, a.impacts[j].Release))
+	})
+	return a.impacts
+}
+
+// PilotAccuracy returns the accuracy of every pilot, ordered by name
+func (a *ImpactAnalyzer) PilotAccuracy() []*PilotAccuracy {
+	misses := make(map[string][]*Impact)
+	for _, impact := range a.impacts {
+		if impact.MissDistance != nil {
+			misses[impact.Pilot] = append(misses[impact.Pilot], impact)
+		}
+	}
+
+	result := make([]*PilotAccuracy, 0, len(misses))
+	for pilot, impacts := range misses {
+		distances := make([]float64, len(impacts))
+		accuracy := &PilotAccuracy{Pilot: pilot, Impacts: len(impacts)}
+		for idx, impact := range impacts {
+			distances[idx] = *impact.MissDistance
+			accuracy.MeanRangeError += *impact.RangeError / float64(len(impacts))
+			accuracy.MeanDeflectionError += *impact.DeflectionError / float64(len(impacts))
+		}
+
+		sort.Float64s(distances)
+		if len(distances)%2 == 1 {
+			accuracy.CEP = distances[len(distances)/2]
+		} else {
+			accuracy.CEP = (distances[len(distances)/2-1] + distances[len(distances)/2]) / 2
+		}
+		result = append(result, accuracy)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Pilot < result[j].Pilot
+	})
+	return result
+}
+
+// ProcessFile records every bomb and rocket impact within the file
+func (a *ImpactAnalyzer) ProcessFile(reader *tacview.Reader) error {
+	motions := newMotionTracker(1)
+	released := make(map[uint64]*Impact)
+
+	_, err := reconstruct(a.concurrency, reader, func(world *tacview.World, tf *tacview.TimeFrame) error {
+		motions.update(world, tf)
+
+		for _, state := range world.Created {
+			if !state.Object.HasTags("Weapon") || !(state.Object.HasTags("Bomb") || state.Object.HasTags("Rocket")) {
+				continue
+			}
+
+			released[state.Id()] = a.release(world, motions, state, &reader.Header, tf.Offset)
+		}
+
+		for _, state := range world.Removed {
+			impact, ok := released[state.Id()]
+			if !ok {
+				continue
+			}
+			delete(released, state.Id())
+
+			if state.HasTransform {
+				impact.Bullseye, impact.BRAA = a.reference.describe(world, state.Transform, state, motions.get(state.Id()))
+				a.impact(world, impact, state, tf.Offset)
+			}
+		}
+
+		return nil
+	})
+	return err
+}
+
+func (a *ImpactAnalyzer) release(world *tacview.World, motions *motionTracker, weapon *tacview.ObjectState, header *tacview.Header, offset float64) *Impact {
+	impact := &Impact{
+		ReferenceTime:   header.ReferenceTime,
+		WeaponId:        weapon.Id(),
+		Release:         offset,
+		ReleaseAltitude: weapon.Transform.Altitude,
+	}
+	if property := weapon.Object.Get("Name"); property != nil {
+		impact.Weapon = property.Value
+	}
+
+	launcher := findLauncher(world, weapon, impactLauncherRange)
+	if launcher == nil {
+		return impact
+	}
+
+	impact.LauncherId = launcher.Id()
+	if property := launcher.Object.Get("Name"); property != nil {
+		impact.Launcher = property.Value
+	}
+	if property := launcher.Object.Get("Pilot"); property != nil {
+		impact.Pilot = property.Value
+	}
+
+	if launcherMotion := motions.get(launcher.Id()); launcherMotion != nil {
+		impact.ReleaseSpeed = launcherMotion.speed()
+		impact.ReleaseTrack = launcherMotion.track
+		impact.DiveAngle = toDegrees(math.Atan2(-launcherMotion.verticalSpeed, launcherMotion.groundSpeed))
+	} else {
+		impact.ReleaseTrack = launcher.Transform.Yaw
+	}
+
+	return impact
+}
+
+func (a *ImpactAnalyzer) impact(world *tacview.World, impact *Impact, weapon *tacview.ObjectState, offset float64) {
+	impact.Impact = offset
+	impact.ImpactLatitude = weapon.Transform.Latitude
+	impact.ImpactLongitude = weapon.Transform.Longitude
+	a.impacts = append(a.impacts, impact)
+
+	var target *geoPoint
+	if a.target != nil {
+		target = a.target
+	} else {
+		var closest *tacview.ObjectState
+		closestDistance := a.maxTargetDistance
+
+		consider := func(state *tacview.ObjectState) {
+			if state == weapon || !state.HasTransform || !state.Object.HasTags("Ground") || state.Object.HasTags("Aerodrome") {
+				return
+			}
+
+			distance := groundDistance(weapon.Transform, state.Transform)
+			if distance <= closestDistance {
+				closest = state
+				closestDistance = distance
+			}
+		}
+
+		for _, state := range world.Objects {
+			consider(state)
+		}
+		// Targets destroyed by the impact may be removed in the same time frame
+		for _, state := range world.Removed {
+			consider(state)
+		}
+
+		if closest == nil {
+			return
+		}
+
+		impact.TargetId = closest.Id()
+		if property := closest.Object.Get("Name"); property != nil {
+			impact.Target = property.Value
+		}
+		target = &geoPoint{latitude: closest.Transform.Latitude, longitude: closest.Transform.Longitude}
+	}
+
+	miss, bearing, _ := geo.Inverse(geo.Point{Latitude: target.latitude, Longitude: target.longitude}, geo.FromTransform(weapon.Transform))
+	bearing = toRadians(bearing)
+	east := miss * math.Sin(bearing)
+	north := miss * math.Cos(bearing)
+
+	track := toRadians(impact.ReleaseTrack)
+	rangeError := east*math.Sin(track) + north*math.Cos(track)
+	deflectionError := east*math.Cos(track) - north*math.Sin(track)
+
+	impact.MissDistance = &miss
+	impact.RangeError = &rangeError
+	impact.DeflectionError = &deflectionError
+}
+
+// ImpactsOptions configures Impacts
+type ImpactsOptions struct {
+	Options
+	// TargetLatitude and TargetLongitude measure every impact against a fixed
+	//  target when both are set
+	TargetLatitude  *float64
+	TargetLongitude *float64
+	// MaxTargetDistance is the maximum distance (in meters) to the nearest ground
+	//  object impacts are measured against when no fixed target is set,
+	//  defaulting to DefaultMaxTargetDistance
+	MaxTargetDistance float64
+	// Pilots only reports the impacts and accuracy of the pilots with the given
+	//  names when set
+	Pilots []string
+	// Reference describes impact points relative to a bullseye or aircraft when
+	//  set
+	Reference *PositionReference
+}
+
+func (o ImpactsOptions) withDefaults() ImpactsOptions {
+	o.Options = o.Options.withDefaults()
+	if o.MaxTargetDistance <= 0 {
+		o.MaxTargetDistance = DefaultMaxTargetDistance
+	}
+	return o
+}
+
+// ImpactsResult contains every impact and the accuracy of every pilot
+type ImpactsResult struct {
+	Impacts []*Impact        `json:"impacts"`
+	Pilots  []*PilotAccuracy `json:"pilots"`
+}
+
+// Impacts returns every bomb and rocket impact across the inputs ordered by
+//  release time, along with the accuracy of every pilot ordered by name
+func Impacts(ctx context.Context, inputs []io.Reader, options ImpactsOptions) (*ImpactsResult, error) {
+	options = options.withDefaults()
+
+	analyzer := NewImpactAnalyzer(options.Concurrency, options.TargetLatitude, options.TargetLongitude, options.MaxTargetDistance,
+		options.Reference)
+	err := processInputs(ctx, options.Options, inputs, analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImpactsResult{
+		Impacts: make([]*Impact, 0),
+		Pilots:  make([]*PilotAccuracy, 0),
+	}
+	for _, impact := range analyzer.Impacts() {
+		if matchesPilot(options.Pilots, impact.Pilot) {
+			result.Impacts = append(result.Impacts, impact)
+		}
+	}
+	for _, accuracy := range analyzer.PilotAccuracy() {
+		if matchesPilot(options.Pilots, accuracy.Pilot) {
+			result.Pilots = append(result.Pilots, accuracy)
+		}
+	}
+	return result, nil
+}
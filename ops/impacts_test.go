@@ -0,0 +1,92 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestImpacts(t *testing.T) {
+	data := opsTestData(
+		"#0",
+		"1,T=0|0|1000,Type=Air+FixedWing,Name=A-10C,Pilot=Tracer",
+		"3,T=0|0.05|0,Type=Ground+Heavy+Armor+Vehicle+Tank,Name=T-72",
+		"6,T=1|1|1000,Type=Air+FixedWing,Name=F-16C,Pilot=Hawk",
+		// Both aircraft dive north
+		"#10", "1,T=0|0.01|900", "6,T=1|1.01|900",
+		"2,T=0|0.01|900,Type=Weapon+Bomb,Name=Mk-82,Parent=1",
+		"5,T=1|1.01|900,Type=Weapon+Bomb,Name=Mk-82,Parent=6",
+		// Long and right of the target
+		"#30", "2,T=0.0001|0.0502|0", "5,T=1|1.03|0",
+		"#31", "-2", "-5",
+		"#40", "1,T=0|0.02|700", "4,T=0|0.02|700,Type=Weapon+Rocket,Name=Hydra 70,Parent=1",
+		// Short of the target
+		"#50", "4,T=0|0.0499|0",
+		"#51", "-4",
+	)
+	latitude, longitude := 0.05, 0.0
+
+	cases := []struct {
+		name     string
+		options  ImpactsOptions
+		impacts  []string
+		accuracy []string
+	}{
+		{
+			// Within the default maximum target distance of 1km
+			name:    "nearest ground object",
+			options: ImpactsOptions{},
+			impacts: []string{
+				"Mk-82 Tracer 10-31 dive=5 target=3 miss=25 range=22 deflection=11",
+				"Mk-82 Hawk 10-31 dive=5 target=0 miss=- range=- deflection=-",
+				"Hydra 70 Tracer 40-51 dive=10 target=3 miss=11 range=-11 deflection=0",
+			},
+			accuracy: []string{"Tracer impacts=2 cep=18 range=6 deflection=6"},
+		},
+		{
+			name:    "fixed target",
+			options: ImpactsOptions{TargetLatitude: &latitude, TargetLongitude: &longitude, Pilots: []string{"Tracer"}},
+			impacts: []string{
+				"Mk-82 Tracer 10-31 dive=5 target=0 miss=25 range=22 deflection=11",
+				"Hydra 70 Tracer 40-51 dive=10 target=0 miss=11 range=-11 deflection=0",
+			},
+			accuracy: []string{"Tracer impacts=2 cep=18 range=6 deflection=6"},
+		},
+	}
+
+	optional := func(value *float64) string {
+		if value == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f", *value)
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := Impacts(context.Background(), opsTestInputs(data), c.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			impacts := make([]string, len(result.Impacts))
+			for idx, impact := range result.Impacts {
+				impacts[idx] = fmt.Sprintf("%v %v %v-%v dive=%.0f target=%v miss=%v range=%v deflection=%v",
+					impact.Weapon, impact.Pilot, impact.Release, impact.Impact, impact.DiveAngle, impact.TargetId,
+					optional(impact.MissDistance), optional(impact.RangeError), optional(impact.DeflectionError))
+			}
+			if strings.Join(impacts, "\n") != strings.Join(c.impacts, "\n") {
+				t.Fatalf("Expected impacts:\n%v\nfound:\n%v", strings.Join(c.impacts, "\n"), strings.Join(impacts, "\n"))
+			}
+
+			accuracy := make([]string, len(result.Pilots))
+			for idx, pilot := range result.Pilots {
+				accuracy[idx] = fmt.Sprintf("%v impacts=%v cep=%.0f range=%.0f deflection=%.0f",
+					pilot.Pilot, pilot.Impacts, pilot.CEP, pilot.MeanRangeError, pilot.MeanDeflectionError)
+			}
+			if strings.Join(accuracy, "\n") != strings.Join(c.accuracy, "\n") {
+				t.Fatalf("Expected accuracy:\n%v\nfound:\n%v", strings.Join(c.accuracy, "\n"), strings.Join(accuracy, "\n"))
+			}
+		})
+	}
+}
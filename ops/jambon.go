@@ -0,0 +1,32 @@
+package ops
+
+import (
+	"io"
+	"runtime"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+type JambonProcessor interface {
+	ProcessFile(*tacview.Reader) error
+}
+
+type JambonNoopProcessor struct {
+	dest io.WriteCloser
+}
+
+func NewJambonNoopProcessor(dest io.WriteCloser) *JambonNoopProcessor {
+	return &JambonNoopProcessor{dest: dest}
+}
+
+func (j *JambonNoopProcessor) ProcessFile(source *tacview.Reader) error {
+	writer, err := tacview.NewWriter(j.dest, &source.Header)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return processTimeFrames(runtime.GOMAXPROCS(-1), source, func(tf *tacview.TimeFrame) error {
+		return writer.WriteTimeFrame(tf)
+	})
+}
@@ -0,0 +1,663 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+	"github.com/b1naryth1ef/jambon/tacview/geo"
+)
+
+const (
+	LandingReferenceRunway    = "runway"
+	LandingReferenceAerodrome = "aerodrome"
+	LandingReferenceCarrier   = "carrier"
+)
+
+// Nominal glideslopes (in degrees) used when none is configured
+const (
+	DefaultGlideslope        = 3.0
+	DefaultCarrierGlideslope = 3.5
+)
+
+// Thresholds used to detect touchdowns. Speeds are in meters per second,
+//  heights and distances in meters and times in seconds.
+const (
+	landingHistorySeconds     = 300.0
+	landingRateInterval       = 1.0
+	landingDescentRate        = 0.5
+	landingLevelRate          = 0.2
+	landingMinimumSpeed       = 20.0
+	landingRearmHeight        = 30.0
+	landingRunwayHeight       = 10.0
+	landingRunwayRange        = 4000.0
+	landingRunwayCourse       = 30.0
+	landingAerodromeHeight    = 20.0
+	landingAerodromeRange     = 10000.0
+	landingCarrierHeight      = 50.0
+	landingCarrierRange       = 400.0
+	landingThresholdHeight    = 15.0
+	landingApproachDistance   = 3 * 1852.0
+	landingMinimumApproach    = 200.0
+	landingCarrierSinkRate    = 5.5
+	landingRunwaySinkRate     = 2.0
+	landingGlideslopeFair     = 0.35
+	landingGlideslopePoor     = 0.7
+	landingGlideslopeCut      = 1.5
+	landingLineupFair         = 10.0
+	landingLineupPoor         = 20.0
+	landingPoorSinkRateFactor = 1.4
+	landingCutSinkRateFactor  = 2.0
+)
+
+// Runway describes the threshold of a runway. Elevation is in meters and the
+//  heading is the true course of the runway.
+type Runway struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	Heading   float64
+	Elevation float64
+}
+
+// ParseRunway parses a runway in the `lat,lon,heading,elevation[,name]` format
+func ParseRunway(value string) (*Runway, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 && len(parts) != 5 {
+		return nil, fmt.Errorf("Expected lat,lon,heading,elevation[,name] for runway, found '%v'", value)
+	}
+
+	values, err := parseFloatList(strings.Join(parts[:4], ","), 4)
+	if err != nil {
+		return nil, err
+	}
+
+	runway := &Runway{
+		Latitude:  values[0],
+		Longitude: values[1],
+		Heading:   values[2],
+		Elevation: values[3],
+	}
+	if len(parts) == 5 {
+		runway.Name = strings.TrimSpace(parts[4])
+	}
+	return runway, nil
+}
+
+// ApproachSample describes the position of an aircraft on final approach.
+//  Distance is measured along the final approach course to the aim point,
+//  glideslope deviation is in degrees (positive is high) and lineup deviation
+//  is in meters (positive is right of the course).
+type ApproachSample struct {
+	Offset              float64  `json:"offset"`
+	Distance            float64  `json:"distance"`
+	Height              float64  `json:"height"`
+	GlideslopeDeviation float64  `json:"glideslope_deviation"`
+	LineupDeviation     float64  `json:"lineup_deviation"`
+	AOA                 *float64 `json:"aoa"`
+}
+
+// Landing describes a single touchdown. Distances are in meters, speeds in
+//  meters per second and angles in degrees. The touchdown position is relative
+//  to the runway threshold, aerodrome or carrier along the final approach
+//  course.
+type Landing struct {
+	ReferenceTime time.Time `json:"reference_time"`
+	ObjectId      uint64    `json:"object_id"`
+	Pilot         string    `json:"pilot"`
+	Airframe      string    `json:"airframe"`
+	Reference     string    `json:"reference"`
+	ReferenceType string    `json:"reference_type"`
+	Touchdown     float64   `json:"touchdown"`
+	// TouchdownDistance is positive past the reference point
+	TouchdownDistance float64 `json:"touchdown_distance"`
+	// TouchdownLateral is positive right of the approach course
+	TouchdownLateral float64  `json:"touchdown_lateral"`
+	SinkRate         float64  `json:"sink_rate"`
+	TouchdownSpeed   float64  `json:"touchdown_speed"`
+	AOA              *float64 `json:"aoa"`
+	// Mean and maximum absolute deviations within the final 3nm, nil when no
+	//  part of the approach was recorded
+	GlideslopeDeviation    *float64 `json:"glideslope_deviation"`
+	MaxGlideslopeDeviation *float64 `json:"max_glideslope_deviation"`
+	LineupDeviation        *float64 `json:"lineup_deviation"`
+	MaxLineupDeviation     *float64 `json:"max_lineup_deviation"`
+	Grade                  string   `json:"grade"`
+	// Bullseye and BRAA describe the touchdown point when a position reference
+	//  is configured
+	Bullseye *BullseyeCall     `json:"bullseye,omitempty"`
+	BRAA     *BRAACall         `json:"braa,omitempty"`
+	Approach []*ApproachSample `json:"-"`
+}
+
+// LandingAnalyzer detects touchdowns from the reconstructed altitude of every
+//  aircraft and grades the final approach. Approaches to a known runway are
+//  measured against its threshold, otherwise the glide path is anchored at the
+//  actual touchdown point relative to the nearest aerodrome or carrier.
+type LandingAnalyzer struct {
+	concurrency       int
+	runways           []*Runway
+	glideslope        float64
+	carrierGlideslope float64
+	reference         *PositionReference
+	landings          []*Landing
+}
+
+// NewLandingAnalyzer creates a new LandingAnalyzer. Touchdown points are
+//  described relative to the reference when it isn't nil.
+func NewLandingAnalyzer(concurrency int, runways []*Runway, glideslope, carrierGlideslope float64, reference *PositionReference) *LandingAnalyzer {
+	return &LandingAnalyzer{
+		concurrency:       concurrency,
+		runways:           runways,
+		glideslope:        glideslope,
+		carrierGlideslope: carrierGlideslope,
+		reference:         reference,
+		landings:          make([]*Landing, 0),
+	}
+}
+
+// Landings returns every landing seen, ordered by touchdown time
+func (l *LandingAnalyzer) Landings() []*Landing {
+	sort.SliceStable(l.landings, func(i, j int) bool {
+		touchdown := OffsetTime(l.landings[i].ReferenceTime, l.landings[i].Touchdown)
+		return touchdown.Before(OffsetTime(l.landings[j].ReferenceTime, l.landings[j].Touchdown))
+	})
+	return l.landings
+}
+
+type landingSample struct {
+	offset    float64
+	transform tacview.Transform
+	aoa       *float64
+}
+
+// landingHistory holds the recent samples of a single aircraft or carrier.
+//  Oriented is set once a transform including the yaw has been seen.
+type landingHistory struct {
+	state    *tacview.ObjectState
+	samples  []*landingSample
+	landed   bool
+	oriented bool
+}
+
+func (h *landingHistory) add(sample *landingSample) {
+	h.samples = append(h.samples, sample)
+
+	expired := 0
+	for expired < len(h.samples) && sample.offset-h.samples[expired].offset > landingHistorySeconds {
+		expired++
+	}
+	h.samples = h.samples[expired:]
+}
+
+// at returns the latest sample at or before the given offset
+func (h *landingHistory) at(offset float64) *landingSample {
+	for idx := len(h.samples) - 1; idx >= 0; idx-- {
+		if h.samples[idx].offset <= offset {
+			return h.samples[idx]
+		}
+	}
+	return nil
+}
+
+// landingReference is the frame an approach is measured in
+type landingReference struct {
+	name          string
+	referenceType string
+	latitude      float64
+	longitude     float64
+	elevation     float64
+	course        float64
+	glideslope    float64
+	history       *landingHistory
+}
+
+// position returns the along course and lateral position of a transform
+//  relative to the reference at the given offset
+func (r *landingReference) position(transform tacview.Transform, offset float64) (float64, float64) {
+	latitude, longitude := r.latitude, r.longitude
+	if r.history != nil {
+		if sample := r.history.at(offset); sample != nil {
+			latitude, longitude = sample.transform.Latitude, sample.transform.Longitude
+		}
+	}
+
+	local := toLocalPosition(transform, latitude, longitude)
+	course := toRadians(r.course)
+	along := local.east*math.Sin(course) + local.north*math.Cos(course)
+	lateral := local.east*math.Cos(course) - local.north*math.Sin(course)
+	return along, lateral
+}
+
+type landingProcessor struct {
+	analyzer   *LandingAnalyzer
+	header     *tacview.Header
+	aircraft   map[uint64]*landingHistory
+	carriers   map[uint64]*landingHistory
+	aerodromes map[uint64]*tacview.ObjectState
+	landings   []*Landing
+}
+
+// ProcessFile records every landing within the file
+func (l *LandingAnalyzer) ProcessFile(reader *tacview.Reader) error {
+	processor := &landingProcessor{
+		analyzer:   l,
+		header:     &reader.Header,
+		aircraft:   make(map[uint64]*landingHistory),
+		carriers:   make(map[uint64]*landingHistory),
+		aerodromes: make(map[uint64]*tacview.ObjectState),
+		landings:   make([]*Landing, 0),
+	}
+
+	_, err := reconstruct(l.concurrency, reader, processor.process)
+	if err != nil {
+		return err
+	}
+
+	l.landings = append(l.landings, processor.landings...)
+	return nil
+}
+
+func (p *landingProcessor) process(world *tacview.World, tf *tacview.TimeFrame) error {
+	for _, state := range world.Created {
+		if state.Object.HasTags("Aerodrome") {
+			p.aerodromes[state.Id()] = state
+		} else if state.Object.HasTags("AircraftCarrier") {
+			p.carriers[state.Id()] = &landingHistory{state: state}
+		} else if isAircraft(state.Object) {
+			p.aircraft[state.Id()] = &landingHistory{state: state}
+		}
+	}
+
+	for _, state := range world.Removed {
+		delete(p.aerodromes, state.Id())
+		delete(p.carriers, state.Id())
+		delete(p.aircraft, state.Id())
+	}
+
+	for _, object := range tf.Objects {
+		if object.Deleted || object.Get("T") == nil {
+			continue
+		}
+
+		state := world.Get(object.Id)
+		if state == nil || !state.HasTransform {
+			continue
+		}
+
+		sample := &landingSample{offset: tf.Offset, transform: state.Transform}
+		if property := state.Object.Get("AOA"); property != nil {
+			if aoa, err := strconv.ParseFloat(property.Value, 64); err == nil {
+				sample.aoa = &aoa
+			}
+		}
+
+		if history, ok := p.carriers[object.Id]; ok {
+			if len(strings.Split(object.Get("T").Value, "|")) >= 6 {
+				history.oriented = true
+			}
+			history.add(sample)
+		} else if history, ok := p.aircraft[object.Id]; ok {
+			history.add(sample)
+			p.step(world, state, history)
+		}
+	}
+
+	return nil
+}
+
+// rates returns the vertical speed, ground speed and track over the interval
+//  ending at the given sample
+func (h *landingHistory) rates(sample *landingSample) (float64, float64, float64, *landingSample) {
+	previous := h.at(sample.offset - landingRateInterval)
+	if previous == nil || previous.offset == sample.offset {
+		return 0, 0, 0, nil
+	}
+
+	elapsed := sample.offset - previous.offset
+	verticalSpeed := (sample.transform.Altitude - previous.transform.Altitude) / elapsed
+	groundSpeed := groundDistance(previous.transform, sample.transform) / elapsed
+	return verticalSpeed, groundSpeed, transformBearing(previous.transform, sample.transform), previous
+}
+
+func (p *landingProcessor) step(world *tacview.World, state *tacview.ObjectState, history *landingHistory) {
+	current := history.samples[len(history.samples)-1]
+	verticalSpeed, groundSpeed, _, previous := history.rates(current)
+	if previous == nil {
+		return
+	}
+
+	if history.landed {
+		if current.transform.Altitude-previous.transform.Altitude > landingRearmHeight ||
+			p.heightAboveReference(current.transform) > landingRearmHeight {
+			history.landed = false
+		}
+		return
+	}
+
+	// A touchdown is the point at which a descending aircraft stops descending
+	//  while still moving quickly
+	sinkRate, touchdownSpeed, track, before := history.rates(previous)
+	if before == nil || sinkRate > -landingDescentRate || math.Abs(verticalSpeed) > landingLevelRate ||
+		groundSpeed < landingMinimumSpeed {
+		return
+	}
+
+	reference := p.findReference(previous.transform, track)
+	if reference == nil {
+		return
+	}
+	history.landed = true
+
+	landing := &Landing{
+		ReferenceTime:  p.header.ReferenceTime,
+		ObjectId:       state.Id(),
+		Reference:      reference.name,
+		ReferenceType:  reference.referenceType,
+		Touchdown:      previous.offset,
+		SinkRate:       -sinkRate,
+		TouchdownSpeed: touchdownSpeed,
+		AOA:            previous.aoa,
+		Approach:       make([]*ApproachSample, 0),
+	}
+	if property := state.Object.Get("Pilot"); property != nil {
+		landing.Pilot = property.Value
+	}
+	if property := state.Object.Get("Name"); property != nil {
+		landing.Airframe = property.Value
+	}
+
+	if reference.referenceType == LandingReferenceCarrier {
+		// Speed relative to the deck
+		if carrierSample := reference.history.at(before.offset); carrierSample != nil {
+			_, carrierSpeed, carrierTrack, _ := reference.history.rates(carrierSample)
+			difference := toRadians(track - carrierTrack)
+			landing.TouchdownSpeed = math.Sqrt(touchdownSpeed*touchdownSpeed + carrierSpeed*carrierSpeed -
+				2*touchdownSpeed*carrierSpeed*math.Cos(difference))
+		}
+	}
+
+	landing.TouchdownDistance, landing.TouchdownLateral = reference.position(previous.transform, previous.offset)
+	landing.Bullseye, landing.BRAA = p.analyzer.reference.describe(world, previous.transform, state, nil)
+	p.measureApproach(landing, reference, history, previous)
+	landing.Grade = gradeLanding(landing, reference)
+	p.landings = append(p.landings, landing)
+}
+
+// heightAboveReference returns the height above the closest runway, carrier or
+//  aerodrome in range, or +Inf when there is none
+func (p *landingProcessor) heightAboveReference(transform tacview.Transform) float64 {
+	height := math.Inf(1)
+	for _, runway := range p.analyzer.runways {
+		threshold := geo.Point{Latitude: runway.Latitude, Longitude: runway.Longitude}
+		if geo.Distance(threshold, geo.FromTransform(transform)) <= landingRunwayRange {
+			height = math.Min(height, transform.Altitude-runway.Elevation)
+		}
+	}
+	for _, history := range p.carriers {
+		if len(history.samples) == 0 {
+			continue
+		}
+
+		carrier := history.samples[len(history.samples)-1].transform
+		if groundDistance(transform, carrier) <= landingCarrierRange {
+			height = math.Min(height, transform.Altitude-carrier.Altitude)
+		}
+	}
+	for _, aerodrome := range p.aerodromes {
+		if aerodrome.HasTransform && groundDistance(transform, aerodrome.Transform) <= landingAerodromeRange {
+			height = math.Min(height, transform.Altitude-aerodrome.Transform.Altitude)
+		}
+	}
+	return height
+}
+
+// findReference returns the runway, carrier or aerodrome the aircraft touched
+//  down on
+func (p *landingProcessor) findReference(transform tacview.Transform, track float64) *landingReference {
+	var closestRunway *Runway
+	closestDistance := landingRunwayRange
+	for _, runway := range p.analyzer.runways {
+		if math.Abs(transform.Altitude-runway.Elevation) > landingRunwayHeight ||
+			angleDifference(track, runway.Heading) > landingRunwayCourse {
+			continue
+		}
+
+		distance := geo.Distance(geo.Point{Latitude: runway.Latitude, Longitude: runway.Longitude}, geo.FromTransform(transform))
+		if distance <= closestDistance {
+			closestRunway = runway
+			closestDistance = distance
+		}
+	}
+	if closestRunway != nil {
+		return &landingReference{
+			name:          closestRunway.Name,
+			referenceType: LandingReferenceRunway,
+			latitude:      closestRunway.Latitude,
+			longitude:     closestRunway.Longitude,
+			elevation:     closestRunway.Elevation,
+			course:        closestRunway.Heading,
+			glideslope:    p.analyzer.glideslope,
+		}
+	}
+
+	for _, history := range p.carriers {
+		if len(history.samples) == 0 {
+			continue
+		}
+
+		carrier := history.samples[len(history.samples)-1].transform
+		if groundDistance(transform, carrier) > landingCarrierRange || transform.Altitude-carrier.Altitude > landingCarrierHeight {
+			continue
+		}
+
+		// The approach follows the carrier heading, falling back to its track
+		//  when the recording doesn't include its orientation
+		course := track
+		if history.oriented {
+			course = carrier.Yaw
+		} else if _, speed, carrierTrack, _ := history.rates(history.samples[len(history.samples)-1]); speed > 0 {
+			course = carrierTrack
+		}
+
+		reference := &landingReference{
+			referenceType: LandingReferenceCarrier,
+			elevation:     transform.Altitude,
+			course:        course,
+			glideslope:    p.analyzer.carrierGlideslope,
+			history:       history,
+		}
+		if property := history.state.Object.Get("Name"); property != nil {
+			reference.name = property.Value
+		}
+		return reference
+	}
+
+	var closest *tacview.ObjectState
+	closestDistance = landingAerodromeRange
+	for _, aerodrome := range p.aerodromes {
+		if !aerodrome.HasTransform || transform.Altitude-aerodrome.Transform.Altitude > landingAerodromeHeight {
+			continue
+		}
+
+		distance := groundDistance(transform, aerodrome.Transform)
+		if distance <= closestDistance {
+			closest = aerodrome
+			closestDistance = distance
+		}
+	}
+	if closest == nil {
+		return nil
+	}
+
+	reference := &landingReference{
+		referenceType: LandingReferenceAerodrome,
+		latitude:      closest.Transform.Latitude,
+		longitude:     closest.Transform.Longitude,
+		elevation:     transform.Altitude,
+		course:        track,
+		glideslope:    p.analyzer.glideslope,
+	}
+	if property := closest.Object.Get("Name"); property != nil {
+		reference.name = property.Value
+	}
+	return reference
+}
+
+// measureApproach records the deviation of every sample within the final 3nm
+//  of the approach from the nominal glide path and approach course
+func (p *landingProcessor) measureApproach(landing *Landing, reference *landingReference, history *landingHistory, touchdown *landingSample) {
+	aimDistance, aimLateral := landing.TouchdownDistance, landing.TouchdownLateral
+	if reference.referenceType == LandingReferenceRunway {
+		aimDistance = landingThresholdHeight / math.Tan(toRadians(reference.glideslope))
+		aimLateral = 0
+	}
+
+	var glideslopeTotal, glideslopeMax, lineupTotal, lineupMax float64
+	for _, sample := range history.samples {
+		if sample.offset >= touchdown.offset {
+			break
+		}
+
+		along, lateral := reference.position(sample.transform, sample.offset)
+		distance := aimDistance - along
+		if distance < landingMinimumApproach || distance > landingApproachDistance {
+			continue
+		}
+
+		height := sample.transform.Altitude - reference.elevation
+		approach := &ApproachSample{
+			Offset:              sample.offset,
+			Distance:            distance,
+			Height:              height,
+			GlideslopeDeviation: toDegrees(math.Atan2(height, distance)) - reference.glideslope,
+			LineupDeviation:     lateral - aimLateral,
+			AOA:                 sample.aoa,
+		}
+		landing.Approach = append(landing.Approach, approach)
+
+		glideslopeTotal += math.Abs(approach.GlideslopeDeviation)
+		glideslopeMax = math.Max(glideslopeMax, math.Abs(approach.GlideslopeDeviation))
+		lineupTotal += math.Abs(approach.LineupDeviation)
+		lineupMax = math.Max(lineupMax, math.Abs(approach.LineupDeviation))
+	}
+
+	if len(landing.Approach) == 0 {
+		return
+	}
+
+	glideslopeMean := glideslopeTotal / float64(len(landing.Approach))
+	lineupMean := lineupTotal / float64(len(landing.Approach))
+	landing.GlideslopeDeviation = &glideslopeMean
+	landing.MaxGlideslopeDeviation = &glideslopeMax
+	landing.LineupDeviation = &lineupMean
+	landing.MaxLineupDeviation = &lineupMax
+}
+
+// landingGrades are LSO style grades ordered from best to worst
+var landingGrades = []string{"_OK_", "OK", "(OK)", "--", "C"}
+
+// gradeLanding returns the worst grade earned by the sink rate, glideslope and
+//  lineup of the landing
+func gradeLanding(landing *Landing, reference *landingReference) string {
+	sinkRateLimit := landingRunwaySinkRate
+	if reference.referenceType == LandingReferenceCarrier {
+		sinkRateLimit = landingCarrierSinkRate
+	}
+
+	grade := 1
+	worse := func(value int) {
+		if value > grade {
+			grade = value
+		}
+	}
+
+	switch {
+	case landing.SinkRate > sinkRateLimit*landingCutSinkRateFactor:
+		worse(4)
+	case landing.SinkRate > sinkRateLimit*landingPoorSinkRateFactor:
+		worse(3)
+	case landing.SinkRate > sinkRateLimit:
+		worse(2)
+	}
+
+	if landing.MaxGlideslopeDeviation == nil {
+		return landingGrades[grade]
+	}
+
+	switch {
+	case *landing.MaxGlideslopeDeviation > landingGlideslopeCut:
+		worse(4)
+	case *landing.MaxGlideslopeDeviation > landingGlideslopePoor:
+		worse(3)
+	case *landing.MaxGlideslopeDeviation > landingGlideslopeFair:
+		worse(2)
+	}
+
+	switch {
+	case *landing.MaxLineupDeviation > landingLineupPoor:
+		worse(3)
+	case *landing.MaxLineupDeviation > landingLineupFair:
+		worse(2)
+	}
+
+	// A pass flown well within every tolerance earns the best grade
+	if grade == 1 && *landing.MaxGlideslopeDeviation < landingGlideslopeFair/2 &&
+		*landing.MaxLineupDeviation < landingLineupFair/2 {
+		grade = 0
+	}
+	return landingGrades[grade]
+}
+
+// LandingsOptions configures Landings
+type LandingsOptions struct {
+	Options
+	// Runways are the known runway thresholds approaches are measured against
+	Runways []*Runway
+	// Glideslope and CarrierGlideslope are the nominal glideslopes in degrees,
+	//  defaulting to DefaultGlideslope and DefaultCarrierGlideslope
+	Glideslope        float64
+	CarrierGlideslope float64
+	// Pilots only reports landings by the pilots with the given names when set
+	Pilots []string
+	// Reference describes touchdown points relative to a bullseye or aircraft
+	//  when set
+	Reference *PositionReference
+}
+
+func (o LandingsOptions) withDefaults() LandingsOptions {
+	o.Options = o.Options.withDefaults()
+	if o.Glideslope <= 0 {
+		o.Glideslope = DefaultGlideslope
+	}
+	if o.CarrierGlideslope <= 0 {
+		o.CarrierGlideslope = DefaultCarrierGlideslope
+	}
+	return o
+}
+
+// Landings returns every graded landing across the inputs, ordered by touchdown
+//  time
+func Landings(ctx context.Context, inputs []io.Reader, options LandingsOptions) ([]*Landing, error) {
+	options = options.withDefaults()
+
+	analyzer := NewLandingAnalyzer(options.Concurrency, options.Runways, options.Glideslope, options.CarrierGlideslope, options.Reference)
+	err := processInputs(ctx, options.Options, inputs, analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	landings := make([]*Landing, 0)
+	for _, landing := range analyzer.Landings() {
+		if matchesPilot(options.Pilots, landing.Pilot) {
+			landings = append(landings, landing)
+		}
+	}
+	return landings, nil
+}
@@ -0,0 +1,164 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// landingApproach returns the time frames of an aircraft flying north at 70m/s
+//  down a 3 degree glide path to the 0,0 runway threshold, offset laterally by
+//  the given number of meters. The final 12m are flown at the given sink rate.
+func landingApproach(lateral, sinkRate float64) []string {
+	const metersPerDegree = 110574.0
+	aim := landingThresholdHeight / math.Tan(toRadians(3))
+
+	lines := make([]string, 0)
+	height := 0.0
+	for second := 0; second <= 90; second++ {
+		along := -5000 + 70*float64(second)
+		if glidePath := (aim - along) * math.Tan(toRadians(3)); glidePath > 12 {
+			height = glidePath
+		} else if height > 12 {
+			height = 12
+		} else {
+			height = math.Max(0, height-sinkRate)
+		}
+
+		line := fmt.Sprintf("1,T=%v|%v|%v", lateral/metersPerDegree, along/metersPerDegree, height)
+		if second == 0 {
+			line += ",Type=Air+FixedWing,Name=F-16C,Pilot=Tracer"
+		}
+		lines = append(lines, fmt.Sprintf("#%v", second), line)
+	}
+	return lines
+}
+
+// landingBounce returns the time frames following landingApproach of an
+//  aircraft bouncing 8m into the air during the rollout
+func landingBounce() []string {
+	const metersPerDegree = 110574.0
+
+	lines := make([]string, 0)
+	for idx, height := range []float64{4, 8, 8, 6, 4, 2, 0, 0, 0} {
+		second := 91 + idx
+		along := -5000 + 70*float64(second)
+		lines = append(lines, fmt.Sprintf("#%v", second), fmt.Sprintf("1,T=0|%v|%v", along/metersPerDegree, height))
+	}
+	return lines
+}
+
+func TestLandings(t *testing.T) {
+	runway := &Runway{Name: "36", Heading: 0}
+
+	cases := []struct {
+		name     string
+		runways  []*Runway
+		input    []string
+		expected string
+	}{
+		{
+			name:     "on the glide path",
+			runways:  []*Runway{runway},
+			input:    landingApproach(0, 1.5),
+			expected: "36 runway at 81 distance=670 lateral=0 sink=1.5 glideslope=0.00 lineup=0 _OK_",
+		},
+		{
+			name:     "right of the course",
+			runways:  []*Runway{runway},
+			input:    landingApproach(15, 1.5),
+			expected: "36 runway at 81 distance=670 lateral=15 sink=1.5 glideslope=0.00 lineup=15 (OK)",
+		},
+		{
+			name:     "hard landing",
+			runways:  []*Runway{runway},
+			input:    landingApproach(0, 4),
+			expected: "36 runway at 76 distance=320 lateral=0 sink=4.0 glideslope=0.00 lineup=0 --",
+		},
+		{
+			// A bounce below the rearm height isn't a second landing
+			name:     "bounce",
+			runways:  []*Runway{runway},
+			input:    append(landingApproach(0, 1.5), landingBounce()...),
+			expected: "36 runway at 81 distance=670 lateral=0 sink=1.5 glideslope=0.00 lineup=0 _OK_",
+		},
+		{
+			// The glide path is anchored at the touchdown point instead
+			name:     "aerodrome",
+			input:    append([]string{"2,T=0|0.01|0,Type=Ground+Static+Aerodrome,Name=Batumi"}, landingApproach(0, 4)...),
+			expected: "Batumi aerodrome at 76 distance=-786 lateral=0 sink=4.0 glideslope=0.36 lineup=0 --",
+		},
+		{
+			// The approach course follows the carrier heading
+			name: "carrier",
+			input: append([]string{"2,T=0|0.0029|0|0|0|2,Type=Sea+Watercraft+AircraftCarrier,Name=CVN-71"},
+				landingApproach(0, 4)...),
+			expected: "CVN-71 carrier at 76 distance=-1 lateral=0 sink=4.0 glideslope=0.86 lineup=186 --",
+		},
+		{
+			name:     "without a reference",
+			input:    landingApproach(0, 1.5),
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// The glideslopes default to 3 and 3.5 degrees
+			landings, err := Landings(context.Background(), opsTestInputs(opsTestData(c.input...)), LandingsOptions{Runways: c.runways})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := ""
+			for _, landing := range landings {
+				found += fmt.Sprintf("%v %v at %v distance=%.0f lateral=%.0f sink=%.1f glideslope=%.2f lineup=%.0f %v",
+					landing.Reference, landing.ReferenceType, landing.Touchdown, landing.TouchdownDistance,
+					landing.TouchdownLateral, landing.SinkRate, *landing.MaxGlideslopeDeviation,
+					*landing.MaxLineupDeviation, landing.Grade)
+			}
+			if found != c.expected {
+				t.Fatalf("Expected '%v', found '%v'", c.expected, found)
+			}
+		})
+	}
+}
+
+func TestGradeLanding(t *testing.T) {
+	value := func(value float64) *float64 {
+		return &value
+	}
+
+	cases := []struct {
+		referenceType string
+		sinkRate      float64
+		glideslope    *float64
+		lineup        *float64
+		expected      string
+	}{
+		{LandingReferenceRunway, 1, value(0.1), value(2), "_OK_"},
+		{LandingReferenceRunway, 1, value(0.2), value(2), "OK"},
+		{LandingReferenceRunway, 1, nil, nil, "OK"},
+		{LandingReferenceRunway, 2.5, value(0.1), value(2), "(OK)"},
+		{LandingReferenceRunway, 1, value(0.1), value(15), "(OK)"},
+		{LandingReferenceRunway, 1, value(0.8), value(2), "--"},
+		{LandingReferenceRunway, 1, value(0.1), value(25), "--"},
+		{LandingReferenceRunway, 5, value(0.1), value(2), "C"},
+		{LandingReferenceRunway, 1, value(2), value(2), "C"},
+		{LandingReferenceCarrier, 5, value(0.1), value(2), "_OK_"},
+		{LandingReferenceCarrier, 7, value(0.1), value(2), "(OK)"},
+	}
+
+	for idx, c := range cases {
+		landing := &Landing{
+			SinkRate:               c.sinkRate,
+			MaxGlideslopeDeviation: c.glideslope,
+			MaxLineupDeviation:     c.lineup,
+		}
+		grade := gradeLanding(landing, &landingReference{referenceType: c.referenceType})
+		if grade != c.expected {
+			t.Fatalf("Case %v: expected grade %v, found %v", idx, c.expected, grade)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package ops
+
+import (
+	"math"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// motion describes the velocity of an object derived from successive transforms.
+//  Speeds are in meters per second and the track is in degrees true.
+type motion struct {
+	// Offset and transform of the sample the motion was last computed from
+	offset    float64
+	transform tacview.Transform
+
+	// valid is set once at least two samples have been seen
+	valid         bool
+	groundSpeed   float64
+	verticalSpeed float64
+	track         float64
+}
+
+// speed returns the three dimensional speed of the object
+func (m *motion) speed() float64 {
+	return math.Hypot(m.groundSpeed, m.verticalSpeed)
+}
+
+// motionTracker derives the motion of every object from its transforms. Samples
+//  closer together than minInterval seconds are skipped to reduce noise from
+//  rounded coordinates.
+type motionTracker struct {
+	minInterval float64
+	motions     map[uint64]*motion
+}
+
+func newMotionTracker(minInterval float64) *motionTracker {
+	return &motionTracker{
+		minInterval: minInterval,
+		motions:     make(map[uint64]*motion),
+	}
+}
+
+// get returns the motion (if it is known) for the given object id
+func (m *motionTracker) get(id uint64) *motion {
+	result := m.motions[id]
+	if result == nil || !result.valid {
+		return nil
+	}
+	return result
+}
+
+// update must be called after each time frame has been applied to the world
+func (m *motionTracker) update(world *tacview.World, tf *tacview.TimeFrame) {
+	for _, state := range world.Removed {
+		delete(m.motions, state.Id())
+	}
+
+	for _, object := range tf.Objects {
+		if object.Id == 0 || object.Deleted || object.Get("T") == nil {
+			continue
+		}
+
+		state := world.Get(object.Id)
+		if state == nil || !state.HasTransform {
+			continue
+		}
+
+		current, ok := m.motions[object.Id]
+		if !ok {
+			m.motions[object.Id] = &motion{offset: tf.Offset, transform: state.Transform}
+			continue
+		}
+
+		elapsed := tf.Offset - current.offset
+		if elapsed < m.minInterval {
+			continue
+		}
+
+		distance := groundDistance(current.transform, state.Transform)
+		current.groundSpeed = distance / elapsed
+		current.verticalSpeed = (state.Transform.Altitude - current.transform.Altitude) / elapsed
+		if distance > 0 {
+			current.track = transformBearing(current.transform, state.Transform)
+		}
+		current.valid = true
+		current.offset = tf.Offset
+		current.transform = state.Transform
+	}
+}
+
+// velocity returns the east, north and up components of the objects velocity
+func (m *motion) velocity() (float64, float64, float64) {
+	track := toRadians(m.track)
+	return m.groundSpeed * math.Sin(track), m.groundSpeed * math.Cos(track), m.verticalSpeed
+}
+
+// closingSpeed returns the rate (in meters per second) at which the range
+//  between two objects is decreasing. Either motion may be nil for stationary
+//  objects.
+func closingSpeed(from tacview.Transform, fromMotion *motion, to tacview.Transform, toMotion *motion) float64 {
+	distance := slantRange(from, to)
+	if distance == 0 {
+		return 0
+	}
+
+	bearing := toRadians(transformBearing(from, to))
+	horizontal := groundDistance(from, to) / distance
+	unitEast := math.Sin(bearing) * horizontal
+	unitNorth := math.Cos(bearing) * horizontal
+	unitUp := (to.Altitude - from.Altitude) / distance
+
+	var east, north, up float64
+	if fromMotion != nil {
+		east, north, up = fromMotion.velocity()
+	}
+	if toMotion != nil {
+		toEast, toNorth, toUp := toMotion.velocity()
+		east, north, up = east-toEast, north-toNorth, up-toUp
+	}
+
+	return east*unitEast + north*unitNorth + up*unitUp
+}
+
+// aspectAngle returns the angle in degrees between the tail of the target and
+//  the observer, 0 being directly behind the target and 180 being head on.
+func aspectAngle(target tacview.Transform, targetHeading float64, observer tacview.Transform) float64 {
+	return 180 - angleDifference(targetHeading, transformBearing(target, observer))
+}
+
+// heading returns the track of the object if its motion is known and its yaw otherwise
+func heading(state *tacview.ObjectState, objectMotion *motion) float64 {
+	if objectMotion != nil && objectMotion.groundSpeed > 0 {
+		return objectMotion.track
+	}
+	return state.Transform.Yaw
+}
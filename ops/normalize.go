@@ -0,0 +1,106 @@
+package ops
+
+import (
+	"context"
+	"io"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// normalize rewrites the input, dropping objects which do not pass the filter
+//  function. The frame filters are applied in order to each time frame (and the
+//  initial time frame) right before it is written.
+func normalize(concurrency int, input *tacview.Reader, output io.Writer, frameFilters []tacview.FrameFilter, filter func(o *tacview.Object) bool) error {
+	applyFrameFilters := func(tf *tacview.TimeFrame) error {
+		for _, frameFilter := range frameFilters {
+			err := frameFilter.Filter(tf)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writeTimeFrame := func(writer *tacview.Writer, tf *tacview.TimeFrame) error {
+		err := applyFrameFilters(tf)
+		if err != nil {
+			return err
+		}
+		return writer.WriteTimeFrame(tf)
+	}
+
+	err := applyFrameFilters(&input.Header.InitialTimeFrame)
+	if err != nil {
+		return err
+	}
+
+	writer, err := tacview.NewWriter(nopWriteCloser{output}, &input.Header)
+	if err != nil {
+		return err
+	}
+
+	filteredObjects := make(map[uint64]struct{})
+	err = processTimeFrames(concurrency, input, func(tf *tacview.TimeFrame) error {
+		tf.Filter(func(object *tacview.Object) bool {
+			_, isFiltered := filteredObjects[object.Id]
+
+			if object.Deleted && isFiltered {
+				delete(filteredObjects, object.Id)
+			} else if isFiltered {
+				return false
+			} else if !filter(object) {
+				filteredObjects[object.Id] = struct{}{}
+				return false
+			}
+			return true
+		})
+
+		return writeTimeFrame(writer, tf)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// NormalizeOptions configures Normalize
+type NormalizeOptions struct {
+	Options
+	// ExcludeProperties are key=value pairs, objects with any of them are
+	//  excluded from the output
+	ExcludeProperties map[string]string
+	// Region only keeps objects while they are within the region when set
+	Region Region
+	// Rules rewrite the properties of every object when set
+	Rules *PropertyRules
+}
+
+// Normalize completely rewrites the ACMI input to the output. Time frames are
+//  parsed in parallel and always written in their original order.
+func Normalize(ctx context.Context, input io.Reader, output io.Writer, options NormalizeOptions) error {
+	options.Options = options.Options.withDefaults()
+
+	reader, err := options.newReader(ctx, 0, input)
+	if err != nil {
+		return err
+	}
+
+	var filters []tacview.FrameFilter
+	if options.Region != nil {
+		filters = append(filters, newRegionFilter(options.Region, &reader.Header))
+	}
+	if options.Rules != nil {
+		filters = append(filters, newPropertyRewriter(options.Rules))
+	}
+
+	return normalize(options.Concurrency, reader, output, filters, func(o *tacview.Object) bool {
+		for k, v := range options.ExcludeProperties {
+			prop := o.Get(k)
+			if prop != nil && prop.Value == v {
+				return false
+			}
+		}
+
+		return true
+	})
+}
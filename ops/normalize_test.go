@@ -0,0 +1,51 @@
+package ops
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	input := opsTestData(
+		"#1", "1,T=0|0|100,Type=Air+FixedWing,Name=A", "2,T=1|1|0,Type=Weapon+Missile,Name=B",
+		"#2", "1,T=1|1|", "2,T=2|2|",
+		"#3", "1,T=1.5|1.5|", "2,T=3|3|",
+		"#4", "1,T=2|2|",
+	)
+
+	cases := []struct {
+		name     string
+		options  NormalizeOptions
+		expected []string
+	}{
+		{
+			name: "rewritten",
+			expected: []string{
+				"#1", "1,T=0|0|100,Type=Air+FixedWing,Name=A", "2,T=1|1|0,Type=Weapon+Missile,Name=B",
+				"#2", "1,T=1|1|", "2,T=2|2|",
+				"#3", "1,T=1.5|1.5|", "2,T=3|3|",
+				"#4", "1,T=2|2|",
+			},
+		},
+		{
+			name:    "excluded properties",
+			options: NormalizeOptions{ExcludeProperties: map[string]string{"Type": "Weapon+Missile"}},
+			expected: []string{
+				"#1", "1,T=0|0|100,Type=Air+FixedWing,Name=A",
+				"#2", "1,T=1|1|",
+				"#3", "1,T=1.5|1.5|",
+				"#4", "1,T=2|2|",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			output := runNormalize(t, input, c.options)
+			expected := strings.Join(c.expected, "\n")
+			if frames := timeFrames(output); frames != expected {
+				t.Fatalf("Expected:\n%v\nwrote:\n%v", expected, frames)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Package ops implements the jambon operations as a library. Every operation
+//  reads its ACMI inputs from io.Readers, writes any ACMI output to io.Writers
+//  and returns typed results, leaving all formatting to the caller.
+package ops
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// DefaultProgressInterval is how often progress is reported when no interval is set
+const DefaultProgressInterval = time.Millisecond * 200
+
+// Options are shared by every operation
+type Options struct {
+	// Concurrency is the number of parallel processing routines, defaulting to
+	//  GOMAXPROCS
+	Concurrency int
+	// OnInput is called with the index of each input right before it is read
+	OnInput func(index int)
+	// Progress is called periodically while each input is read
+	Progress tacview.ProgressFunc
+	// ProgressInterval is the minimum time between progress reports
+	ProgressInterval time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = runtime.GOMAXPROCS(-1)
+	}
+	if o.ProgressInterval <= 0 {
+		o.ProgressInterval = DefaultProgressInterval
+	}
+	return o
+}
+
+// progressSource is implemented by both tacview.Reader and tacview.Parser
+type progressSource interface {
+	SetProgress(time.Duration, tacview.ProgressFunc)
+}
+
+// begin signals the input with the given index is about to be read
+func (o *Options) begin(index int, source progressSource) {
+	if o.OnInput != nil {
+		o.OnInput(index)
+	}
+	if o.Progress != nil {
+		source.SetProgress(o.ProgressInterval, o.Progress)
+	}
+}
+
+// newReader creates a reader for the input with the given index which fails
+//  once the context is done
+func (o *Options) newReader(ctx context.Context, index int, input io.Reader) (*tacview.Reader, error) {
+	reader, err := tacview.NewReader(&contextReader{ctx: ctx, reader: input})
+	if err != nil {
+		return nil, err
+	}
+	o.begin(index, reader)
+	return reader, nil
+}
+
+// processInputs reads every input in order with the processor
+func processInputs(ctx context.Context, options Options, inputs []io.Reader, processor JambonProcessor) error {
+	for index, input := range inputs {
+		reader, err := options.newReader(ctx, index, input)
+		if err != nil {
+			return err
+		}
+
+		err = processor.ProcessFile(reader)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contextReader fails every read once its context is done, stopping any
+//  processing of the underlying reader
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	err := r.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// nopWriteCloser lets an io.Writer be used where a closer is required, leaving
+//  closing the writer to the caller
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// matchesPilot returns whether any of the names is one of the pilots, or true
+//  when no pilots are given
+func matchesPilot(pilots []string, names ...string) bool {
+	if len(pilots) == 0 {
+		return true
+	}
+	for _, pilot := range pilots {
+		for _, name := range names {
+			if name == pilot {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package ops
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+const opsTestHeader = "FileType=text/acmi/tacview\nFileVersion=2.2\n"
+
+const opsTestGlobal = "0,ReferenceTime=2021-07-24T04:00:00Z\n"
+
+// opsTestData joins the lines of a recording after the header and global object
+func opsTestData(lines ...string) string {
+	return opsTestHeader + opsTestGlobal + strings.Join(lines, "\n") + "\n"
+}
+
+// opsTestInputs returns a reader for each recording
+func opsTestInputs(data ...string) []io.Reader {
+	inputs := make([]io.Reader, len(data))
+	for idx, value := range data {
+		inputs[idx] = strings.NewReader(value)
+	}
+	return inputs
+}
+
+// timeFrames returns the written recording from its first time frame on, with
+//  time frame offsets shortened for readability
+func timeFrames(output string) string {
+	idx := strings.Index(output, "\n#")
+	if idx == -1 {
+		return ""
+	}
+
+	return shortenOffsets(output[idx+1:])
+}
+
+// shortenOffsets strips the trailing zeros of every time frame offset
+func shortenOffsets(output string) string {
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	for idx, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			lines[idx] = strings.TrimRight(strings.TrimRight(line, "0"), ".")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func runNormalize(t *testing.T, data string, options NormalizeOptions) string {
+	var output bytes.Buffer
+	err := Normalize(context.Background(), strings.NewReader(data), &output, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output.String()
+}
@@ -0,0 +1,361 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+const (
+	// Pictures are evaluated at most this often (in seconds) when calling on change
+	pictureSampleInterval = 1.0
+	// Groups with at least this many contacts are called heavy
+	pictureHeavyContacts = 3
+	// Groups with an altitude spread larger than this (in meters) are called as a stack
+	pictureStackSpread = 1524.0
+)
+
+// PictureGroup describes a group of hostile aircraft. Bearings are in degrees
+//  true, distances in meters and the track is nil when the group is not moving.
+type PictureGroup struct {
+	Objects         []uint64  `json:"objects"`
+	Contacts        int       `json:"contacts"`
+	Latitude        float64   `json:"latitude"`
+	Longitude       float64   `json:"longitude"`
+	MinAltitude     float64   `json:"min_altitude"`
+	MaxAltitude     float64   `json:"max_altitude"`
+	Track           *float64  `json:"track"`
+	BullseyeBearing *float64  `json:"bullseye_bearing"`
+	BullseyeRange   *float64  `json:"bullseye_range"`
+	BRAA            *BRAACall `json:"braa,omitempty"`
+}
+
+// Picture describes every hostile group at a point in time
+type Picture struct {
+	ReferenceTime time.Time       `json:"reference_time"`
+	Offset        float64         `json:"offset"`
+	Coalition     string          `json:"coalition"`
+	Groups        []*PictureGroup `json:"groups"`
+}
+
+var brevityNumbers = []string{"ZERO", "SINGLE", "TWO", "THREE", "FOUR", "FIVE", "SIX", "SEVEN", "EIGHT", "NINE"}
+
+var brevityTracks = []string{"NORTH", "NORTHEAST", "EAST", "SOUTHEAST", "SOUTH", "SOUTHWEST", "WEST", "NORTHWEST"}
+
+func brevityAltitude(altitude float64) string {
+	return fmt.Sprintf("%.0f THOUSAND", math.Round(MetersToFeet(altitude)/1000))
+}
+
+func brevityTrack(track float64) string {
+	return brevityTracks[int(math.Round(track/45))%len(brevityTracks)]
+}
+
+// Call returns the brevity call for the group, e.g. `GROUP BULLSEYE 045/32,
+//  22 THOUSAND, TRACK SOUTH, HOSTILE, 2 CONTACTS`
+func (g *PictureGroup) Call() string {
+	parts := make([]string, 0)
+	if g.BRAA != nil {
+		parts = append(parts, fmt.Sprintf("GROUP BRAA %03.0f/%.0f", g.BRAA.Bearing, MetersToNauticalMiles(g.BRAA.Range)))
+	} else if g.BullseyeBearing != nil {
+		parts = append(parts, fmt.Sprintf("GROUP BULLSEYE %03.0f/%.0f", *g.BullseyeBearing, MetersToNauticalMiles(*g.BullseyeRange)))
+	} else {
+		parts = append(parts, fmt.Sprintf("GROUP %.4f, %.4f", g.Latitude, g.Longitude))
+	}
+
+	if g.MaxAltitude-g.MinAltitude > pictureStackSpread {
+		parts = append(parts, fmt.Sprintf("STACK %v AND %v", brevityAltitude(g.MaxAltitude), brevityAltitude(g.MinAltitude)))
+	} else {
+		parts = append(parts, brevityAltitude((g.MaxAltitude+g.MinAltitude)/2))
+	}
+
+	if g.BRAA != nil && g.BRAA.Aspect != "" {
+		parts = append(parts, strings.ToUpper(g.BRAA.Aspect))
+	} else if g.Track != nil {
+		parts = append(parts, "TRACK "+brevityTrack(*g.Track))
+	}
+
+	parts = append(parts, "HOSTILE")
+	if g.Contacts >= pictureHeavyContacts {
+		parts = append(parts, "HEAVY")
+	}
+
+	if g.Contacts == 1 {
+		parts = append(parts, "1 CONTACT")
+	} else {
+		parts = append(parts, fmt.Sprintf("%v CONTACTS", g.Contacts))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Transcript returns the picture call followed by the call of each group
+func (p *Picture) Transcript() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%v %v PICTURE", strings.ToUpper(p.Coalition), FormatOffset(&tacview.Header{ReferenceTime: p.ReferenceTime}, p.Offset))
+	if len(p.Groups) == 0 {
+		builder.WriteString(", CLEAN\n")
+		return builder.String()
+	}
+
+	count := fmt.Sprintf("%v", len(p.Groups))
+	if len(p.Groups) < len(brevityNumbers) {
+		count = brevityNumbers[len(p.Groups)]
+	}
+	if len(p.Groups) == 1 {
+		fmt.Fprintf(&builder, ", %v GROUP\n", count)
+	} else {
+		fmt.Fprintf(&builder, ", %v GROUPS\n", count)
+	}
+
+	for _, group := range p.Groups {
+		fmt.Fprintf(&builder, "  %v\n", group.Call())
+	}
+	return builder.String()
+}
+
+// signature describes the shape of the picture, used to detect changes
+func (p *Picture) signature() string {
+	counts := make([]string, len(p.Groups))
+	for idx, group := range p.Groups {
+		counts[idx] = fmt.Sprintf("%v", group.Contacts)
+	}
+	sort.Strings(counts)
+	return strings.Join(counts, ",")
+}
+
+// PictureCaller walks a recording calling the picture of every hostile group
+//  at a fixed interval and (optionally) whenever the picture changes.
+type PictureCaller struct {
+	concurrency   int
+	coalition     string
+	interval      float64
+	onChange      bool
+	groupDistance float64
+	reference     *PositionReference
+	pictures      []*Picture
+}
+
+// NewPictureCaller creates a new PictureCaller for the given coalition. When
+//  reference is nil or has no bullseye the coalition's own bullseye object is
+//  used, the given reference is left unchanged.
+func NewPictureCaller(concurrency int, coalition string, interval float64, onChange bool, groupDistance float64, reference *PositionReference) *PictureCaller {
+	if reference == nil {
+		reference = &PositionReference{}
+	} else {
+		copied := *reference
+		reference = &copied
+	}
+	if reference.bullseye == nil && reference.bullseyeCoalition == "" {
+		reference.bullseyeCoalition = coalition
+	}
+
+	return &PictureCaller{
+		concurrency:   concurrency,
+		coalition:     coalition,
+		interval:      interval,
+		onChange:      onChange,
+		groupDistance: groupDistance,
+		reference:     reference,
+		pictures:      make([]*Picture, 0),
+	}
+}
+
+// Pictures returns every picture called, ordered by time
+func (c *PictureCaller) Pictures() []*Picture {
+	sort.SliceStable(c.pictures, func(i, j int) bool {
+		a := OffsetTime(c.pictures[i].ReferenceTime, c.pictures[i].Offset)
+		return a.Before(OffsetTime(c.pictures[j].ReferenceTime, c.pictures[j].Offset))
+	})
+	return c.pictures
+}
+
+// ProcessFile calls the pictures within the file
+func (c *PictureCaller) ProcessFile(reader *tacview.Reader) error {
+	motions := newMotionTracker(1)
+	nextCall := math.Inf(-1)
+	lastSample := math.Inf(-1)
+	lastSignature := ""
+
+	_, err := reconstruct(c.concurrency, reader, func(world *tacview.World, tf *tacview.TimeFrame) error {
+		motions.update(world, tf)
+
+		due := tf.Offset >= nextCall
+		if !due && (!c.onChange || tf.Offset-lastSample < pictureSampleInterval) {
+			return nil
+		}
+		lastSample = tf.Offset
+
+		picture := c.picture(world, motions, &reader.Header)
+		signature := picture.signature()
+		if due || signature != lastSignature {
+			c.pictures = append(c.pictures, picture)
+			nextCall = tf.Offset + c.interval
+			lastSignature = signature
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *PictureCaller) isHostile(state *tacview.ObjectState) bool {
+	if !state.HasTransform || !isAircraft(state.Object) {
+		return false
+	}
+
+	coalition := state.Object.Get("Coalition")
+	return coalition != nil && coalition.Value != "" && !strings.EqualFold(coalition.Value, c.coalition)
+}
+
+func (c *PictureCaller) picture(world *tacview.World, motions *motionTracker, header *tacview.Header) *Picture {
+	grid := newSpatialGrid(c.groupDistance, header)
+	parents := make(map[uint64]uint64)
+	for _, state := range world.Objects {
+		if !c.isHostile(state) {
+			continue
+		}
+
+		parents[state.Id()] = state.Id()
+		grid.insert(state.Id(), state.Transform)
+	}
+
+	var root func(id uint64) uint64
+	root = func(id uint64) uint64 {
+		if parents[id] != id {
+			parents[id] = root(parents[id])
+		}
+		return parents[id]
+	}
+
+	grid.pairs(func(a, b *gridEntry) {
+		if groundDistance(a.transform, b.transform) <= c.groupDistance {
+			parents[root(a.id)] = root(b.id)
+		}
+	})
+
+	members := make(map[uint64][]uint64)
+	for id := range parents {
+		members[root(id)] = append(members[root(id)], id)
+	}
+
+	picture := &Picture{
+		ReferenceTime: header.ReferenceTime,
+		Offset:        world.Offset,
+		Coalition:     c.coalition,
+		Groups:        make([]*PictureGroup, 0, len(members)),
+	}
+
+	for _, ids := range members {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		picture.Groups = append(picture.Groups, c.group(world, motions, ids))
+	}
+
+	// Groups are called closest to the bullseye first
+	sort.Slice(picture.Groups, func(i, j int) bool {
+		a, b := picture.Groups[i], picture.Groups[j]
+		if a.BullseyeRange != nil && b.BullseyeRange != nil && *a.BullseyeRange != *b.BullseyeRange {
+			return *a.BullseyeRange < *b.BullseyeRange
+		}
+		return a.Objects[0] < b.Objects[0]
+	})
+	return picture
+}
+
+func (c *PictureCaller) group(world *tacview.World, motions *motionTracker, ids []uint64) *PictureGroup {
+	group := &PictureGroup{
+		Objects:     ids,
+		Contacts:    len(ids),
+		MinAltitude: math.Inf(1),
+		MaxAltitude: math.Inf(-1),
+	}
+
+	var lead *tacview.ObjectState
+	var east, north float64
+	moving := 0
+	for _, id := range ids {
+		state := world.Get(id)
+		if lead == nil {
+			lead = state
+		}
+
+		group.Latitude += state.Transform.Latitude / float64(len(ids))
+		group.Longitude += state.Transform.Longitude / float64(len(ids))
+		group.MinAltitude = math.Min(group.MinAltitude, state.Transform.Altitude)
+		group.MaxAltitude = math.Max(group.MaxAltitude, state.Transform.Altitude)
+
+		if m := motions.get(id); m != nil && m.groundSpeed > 0 {
+			east += math.Sin(toRadians(m.track))
+			north += math.Cos(toRadians(m.track))
+			moving++
+		}
+	}
+
+	if moving > 0 {
+		track := math.Mod(toDegrees(math.Atan2(east, north))+360, 360)
+		group.Track = &track
+	}
+
+	center := tacview.Transform{
+		Latitude:  group.Latitude,
+		Longitude: group.Longitude,
+		Altitude:  (group.MinAltitude + group.MaxAltitude) / 2,
+	}
+	bullseye, braa := c.reference.describe(world, center, lead, motions.get(lead.Id()))
+	if bullseye != nil {
+		group.BullseyeBearing = &bullseye.Bearing
+		group.BullseyeRange = &bullseye.Range
+	}
+	group.BRAA = braa
+
+	return group
+}
+
+// PicturesOptions configures Pictures
+type PicturesOptions struct {
+	Options
+	// Coalition the picture is called for, every aircraft of another coalition
+	//  is hostile
+	Coalition string
+	// Interval is the number of seconds between picture calls, it must be positive
+	Interval float64
+	// OnChange also calls the picture whenever the number of groups or contacts
+	//  changes
+	OnChange bool
+	// GroupDistance is the maximum distance (in meters) between aircraft of the
+	//  same group, it must be positive
+	GroupDistance float64
+	// Reference describes groups relative to a bullseye or aircraft, defaulting
+	//  to the coalition's own bullseye object
+	Reference *PositionReference
+}
+
+// Pictures returns the GCI picture calls for the inputs, ordered by time
+func Pictures(ctx context.Context, inputs []io.Reader, options PicturesOptions) ([]*Picture, error) {
+	options.Options = options.Options.withDefaults()
+	if options.GroupDistance <= 0 {
+		return nil, fmt.Errorf("Group distance must be positive")
+	}
+	if options.Interval <= 0 {
+		return nil, fmt.Errorf("Picture interval must be positive")
+	}
+
+	caller := NewPictureCaller(
+		options.Concurrency,
+		options.Coalition,
+		options.Interval,
+		options.OnChange,
+		options.GroupDistance,
+		options.Reference,
+	)
+	err := processInputs(ctx, options.Options, inputs, caller)
+	if err != nil {
+		return nil, err
+	}
+	return caller.Pictures(), nil
+}
@@ -0,0 +1,121 @@
+package ops
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPictures(t *testing.T) {
+	data := opsTestData(
+		"#0",
+		"1,T=0|0|0,Type=Navaid+Static+Bullseye,Coalition=Allies",
+		"2,T=1|1|0,Type=Navaid+Static+Bullseye,Coalition=Enemies",
+		"a,T=0|0|1000,Type=Air+FixedWing,Coalition=Allies,Pilot=Viper",
+		// A pair 2.2km apart flying south
+		"b,T=0|0.5|6000,Type=Air+FixedWing,Coalition=Enemies",
+		"c,T=0.02|0.5|6500,Type=Air+FixedWing,Coalition=Enemies",
+		// A stacked group
+		"d,T=0.5|0.5|3000,Type=Air+FixedWing,Coalition=Enemies",
+		"e,T=0.5|0.51|9000,Type=Air+FixedWing,Coalition=Enemies",
+		"f,T=0.51|0.5|3000,Type=Air+FixedWing,Coalition=Enemies",
+		"#10",
+		"a,T=0|0.01|1000",
+		"b,T=0|0.49|6000",
+		"c,T=0.02|0.49|6500",
+	)
+
+	reference, err := ParsePositionReference("", "Viper")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Far from the reference point, 8km apart along the parallel
+	distant := opsTestData(
+		"#0",
+		"1,T=60|60|0,Type=Navaid+Static+Bullseye,Coalition=Allies",
+		"b,T=60|60.1|3000,Type=Air+FixedWing,Coalition=Enemies",
+		"c,T=60.1434|60.1|3000,Type=Air+FixedWing,Coalition=Enemies",
+		"#10", "b,T=60|60.1|3000",
+	)
+
+	cases := []struct {
+		name      string
+		data      string
+		coalition string
+		reference *PositionReference
+		expected  []string
+	}{
+		{
+			name:      "own bullseye",
+			data:      data,
+			coalition: "Allies",
+			expected: []string{
+				"ALLIES 2021-07-24T04:00:00Z (0) PICTURE, CLEAN",
+				"ALLIES 2021-07-24T04:00:10Z (10) PICTURE, TWO GROUPS",
+				"  GROUP BULLSEYE 001/29, 21 THOUSAND, TRACK SOUTH, HOSTILE, 2 CONTACTS",
+				"  GROUP BULLSEYE 045/43, STACK 30 THOUSAND AND 10 THOUSAND, HOSTILE, HEAVY, 3 CONTACTS",
+			},
+		},
+		{
+			name:      "braa",
+			data:      data,
+			coalition: "Allies",
+			reference: reference,
+			expected: []string{
+				"ALLIES 2021-07-24T04:00:00Z (0) PICTURE, CLEAN",
+				"ALLIES 2021-07-24T04:00:10Z (10) PICTURE, TWO GROUPS",
+				"  GROUP BRAA 001/29, 21 THOUSAND, HOT, HOSTILE, 2 CONTACTS",
+				"  GROUP BRAA 046/42, STACK 30 THOUSAND AND 10 THOUSAND, DRAG, HOSTILE, HEAVY, 3 CONTACTS",
+			},
+		},
+		{
+			name:      "hostile coalition",
+			data:      data,
+			coalition: "Enemies",
+			expected: []string{
+				"ENEMIES 2021-07-24T04:00:00Z (0) PICTURE, CLEAN",
+				"ENEMIES 2021-07-24T04:00:10Z (10) PICTURE, SINGLE GROUP",
+				"  GROUP BULLSEYE 225/84, 3 THOUSAND, TRACK NORTH, HOSTILE, 1 CONTACT",
+			},
+		},
+		{
+			name:      "far from the reference point",
+			data:      distant,
+			coalition: "Allies",
+			expected: []string{
+				"ALLIES 2021-07-24T04:00:00Z (0) PICTURE, CLEAN",
+				"ALLIES 2021-07-24T04:00:10Z (10) PICTURE, TWO GROUPS",
+				"  GROUP BULLSEYE 000/6, 10 THOUSAND, HOSTILE, 1 CONTACT",
+				"  GROUP BULLSEYE 036/7, 10 THOUSAND, HOSTILE, 1 CONTACT",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pictures, err := Pictures(context.Background(), opsTestInputs(c.data), PicturesOptions{
+				Coalition:     c.coalition,
+				Interval:      10,
+				GroupDistance: 5556,
+				Reference:     c.reference,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var transcript strings.Builder
+			for _, picture := range pictures {
+				transcript.WriteString(picture.Transcript())
+			}
+			expected := strings.Join(c.expected, "\n") + "\n"
+			if transcript.String() != expected {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", expected, transcript.String())
+			}
+		})
+	}
+
+	if reference.bullseyeCoalition != "" {
+		t.Fatalf("Expected the reference to be left unchanged, found bullseye coalition %v", reference.bullseyeCoalition)
+	}
+}
@@ -0,0 +1,151 @@
+package ops
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// PilotSortie describes a single object flown by a pilot
+type PilotSortie struct {
+	ObjectId uint64    `json:"object_id"`
+	Airframe string    `json:"airframe"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	// Duration of the sortie in seconds
+	Duration float64 `json:"duration"`
+	// Gap in seconds between the end of the previous sortie and this one
+	Gap float64 `json:"gap"`
+}
+
+// Pilot describes every sortie flown by a single pilot
+type Pilot struct {
+	Name    string         `json:"name"`
+	Sorties []*PilotSortie `json:"sorties"`
+	// Airframes maps an airframe name to the seconds spent flying it
+	Airframes map[string]float64 `json:"airframes"`
+	// FlightTime is the total seconds spent across all sorties
+	FlightTime float64 `json:"flight_time"`
+}
+
+// PilotTracker groups objects into per-pilot sessions based on the `Pilot`
+//  property. Multiple files may be processed, sorties are ordered by their
+//  absolute start time.
+type PilotTracker struct {
+	concurrency int
+	pilots      map[string]*Pilot
+}
+
+// NewPilotTracker creates a new PilotTracker
+func NewPilotTracker(concurrency int) *PilotTracker {
+	return &PilotTracker{
+		concurrency: concurrency,
+		pilots:      make(map[string]*Pilot),
+	}
+}
+
+// ProcessFile records the sorties of every pilot within the file
+func (p *PilotTracker) ProcessFile(reader *tacview.Reader) error {
+	world, err := reconstruct(p.concurrency, reader, func(world *tacview.World, tf *tacview.TimeFrame) error {
+		for _, state := range world.Removed {
+			p.record(&reader.Header, state, tf.Offset)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, state := range world.Objects {
+		p.record(&reader.Header, state, world.Offset)
+	}
+	return nil
+}
+
+func (p *PilotTracker) record(header *tacview.Header, state *tacview.ObjectState, end float64) {
+	pilotName := state.Object.Get("Pilot")
+	if pilotName == nil || pilotName.Value == "" {
+		return
+	}
+
+	pilot, ok := p.pilots[pilotName.Value]
+	if !ok {
+		pilot = &Pilot{Name: pilotName.Value}
+		p.pilots[pilotName.Value] = pilot
+	}
+
+	sortie := &PilotSortie{
+		ObjectId: state.Id(),
+		Start:    OffsetTime(header.ReferenceTime, state.Spawned),
+		End:      OffsetTime(header.ReferenceTime, end),
+		Duration: end - state.Spawned,
+	}
+	if name := state.Object.Get("Name"); name != nil {
+		sortie.Airframe = name.Value
+	}
+
+	pilot.Sorties = append(pilot.Sorties, sortie)
+}
+
+// Pilots returns every pilot seen, ordered by name
+func (p *PilotTracker) Pilots() []*Pilot {
+	pilots := make([]*Pilot, 0, len(p.pilots))
+	for _, pilot := range p.pilots {
+		sort.Slice(pilot.Sorties, func(i, j int) bool {
+			return pilot.Sorties[i].Start.Before(pilot.Sorties[j].Start)
+		})
+
+		pilot.Airframes = make(map[string]float64)
+		pilot.FlightTime = 0
+		for idx, sortie := range pilot.Sorties {
+			sortie.Gap = 0
+			if idx > 0 {
+				sortie.Gap = sortie.Start.Sub(pilot.Sorties[idx-1].End).Seconds()
+			}
+
+			pilot.Airframes[sortie.Airframe] += sortie.Duration
+			pilot.FlightTime += sortie.Duration
+		}
+
+		pilots = append(pilots, pilot)
+	}
+
+	sort.Slice(pilots, func(i, j int) bool {
+		return pilots[i].Name < pilots[j].Name
+	})
+	return pilots
+}
+
+// FormatSeconds formats a number of seconds as a duration rounded to the second
+func FormatSeconds(seconds float64) string {
+	return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+}
+
+// PilotsOptions configures Pilots
+type PilotsOptions struct {
+	Options
+	// Pilots only reports the pilots with the given names when set
+	Pilots []string
+}
+
+// Pilots returns the sessions of every pilot across the inputs, ordered by name
+func Pilots(ctx context.Context, inputs []io.Reader, options PilotsOptions) ([]*Pilot, error) {
+	options.Options = options.Options.withDefaults()
+
+	tracker := NewPilotTracker(options.Concurrency)
+	err := processInputs(ctx, options.Options, inputs, tracker)
+	if err != nil {
+		return nil, err
+	}
+
+	pilots := make([]*Pilot, 0)
+	for _, pilot := range tracker.Pilots() {
+		if matchesPilot(options.Pilots, pilot.Name) {
+			pilots = append(pilots, pilot)
+		}
+	}
+	return pilots, nil
+}
@@ -0,0 +1,71 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPilots(t *testing.T) {
+	// The second file starts an hour later and is given first
+	later := opsTestHeader + "0,ReferenceTime=2021-07-24T05:00:00Z\n" + strings.Join([]string{
+		"#10", "5,Pilot=Tracer,Name=F-16C",
+		"#70", "-5",
+	}, "\n") + "\n"
+	earlier := opsTestData(
+		"#0", "1,Pilot=Tracer,Name=F-16C", "2,Pilot=Hawk,Name=F-14B", "3,Name=AIM-120C",
+		"#600", "-1",
+		"#900", "4,Pilot=Tracer,Name=F-18C",
+		"#1200", "-4",
+	)
+
+	cases := []struct {
+		name     string
+		pilots   []string
+		expected []string
+	}{
+		{
+			name: "all pilots",
+			expected: []string{
+				"Hawk 1200s F-14B=1200 [2:1200+0]",
+				"Tracer 960s F-16C=660 F-18C=300 [1:600+0 4:300+300 5:60+2410]",
+			},
+		},
+		{
+			name:     "filtered",
+			pilots:   []string{"Tracer"},
+			expected: []string{"Tracer 960s F-16C=660 F-18C=300 [1:600+0 4:300+300 5:60+2410]"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pilots, err := Pilots(context.Background(), opsTestInputs(later, earlier), PilotsOptions{Pilots: c.pilots})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Pilots are described as `name flight_time airframes [id:duration+gap]`
+			found := make([]string, len(pilots))
+			for idx, pilot := range pilots {
+				found[idx] = fmt.Sprintf("%v %vs", pilot.Name, pilot.FlightTime)
+				for _, airframe := range []string{"F-14B", "F-16C", "F-18C"} {
+					if seconds, ok := pilot.Airframes[airframe]; ok {
+						found[idx] += fmt.Sprintf(" %v=%v", airframe, seconds)
+					}
+				}
+
+				sorties := make([]string, len(pilot.Sorties))
+				for idx, sortie := range pilot.Sorties {
+					sorties[idx] = fmt.Sprintf("%v:%v+%v", sortie.ObjectId, sortie.Duration, sortie.Gap)
+				}
+				found[idx] += " [" + strings.Join(sorties, " ") + "]"
+			}
+
+			if strings.Join(found, "\n") != strings.Join(c.expected, "\n") {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", strings.Join(c.expected, "\n"), strings.Join(found, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// DefaultRealTimePort is the port used when a server does not include one
+const DefaultRealTimePort = 42674
+
+// DefaultRecordUsername is the username used when none is provided
+const DefaultRecordUsername = "jambon-record"
+
+// RecordOptions configures Record
+type RecordOptions struct {
+	Options
+	// Server is the address of the TacView real-time server
+	Server   string
+	Username string
+	Password string
+}
+
+// Record writes the recording streamed by a TacView real-time server to the
+//  output until the server disconnects or the context is done. Everything
+//  received before the context is done is kept and no error is returned.
+func Record(ctx context.Context, output io.Writer, options RecordOptions) error {
+	options.Options = options.Options.withDefaults()
+
+	server := options.Server
+	if strings.Index(server, ":") == -1 {
+		server = fmt.Sprintf("%s:%d", server, DefaultRealTimePort)
+	}
+
+	username := options.Username
+	if username == "" {
+		username = DefaultRecordUsername
+	}
+
+	reader, err := tacview.NewRealTimeReaderContext(ctx, server, username, options.Password)
+	if err != nil {
+		return err
+	}
+	options.begin(0, reader)
+
+	writer, err := tacview.NewWriter(nopWriteCloser{output}, &reader.Header)
+	if err != nil {
+		return err
+	}
+
+	err = processTimeFramesContext(ctx, 1, reader, writer.WriteTimeFrame)
+	if err != nil && err != ctx.Err() {
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,330 @@
+package ops
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+	"github.com/b1naryth1ef/jambon/tacview/geo"
+)
+
+// Region describes a geographic area
+type Region interface {
+	Contains(latitude, longitude float64) bool
+}
+
+type boundingBoxRegion struct {
+	minLatitude  float64
+	minLongitude float64
+	maxLatitude  float64
+	maxLongitude float64
+}
+
+func (b *boundingBoxRegion) Contains(latitude, longitude float64) bool {
+	return latitude >= b.minLatitude && latitude <= b.maxLatitude &&
+		longitude >= b.minLongitude && longitude <= b.maxLongitude
+}
+
+type circleRegion struct {
+	latitude  float64
+	longitude float64
+	radius    float64
+}
+
+// NewCircleRegion creates a region of every point within the radius (in meters)
+//  of a coordinate
+func NewCircleRegion(latitude, longitude, radius float64) Region {
+	return &circleRegion{latitude: latitude, longitude: longitude, radius: radius}
+}
+
+func (c *circleRegion) Contains(latitude, longitude float64) bool {
+	return geo.Distance(geo.Point{Latitude: c.latitude, Longitude: c.longitude}, geo.Point{Latitude: latitude, Longitude: longitude}) <= c.radius
+}
+
+type geoPoint struct {
+	latitude  float64
+	longitude float64
+}
+
+// polygonRegion contains one or more polygons, each made up of an outer ring
+//  followed by any number of holes.
+type polygonRegion struct {
+	polygons [][][]geoPoint
+}
+
+func (p *polygonRegion) Contains(latitude, longitude float64) bool {
+	for _, rings := range p.polygons {
+		if len(rings) == 0 || !ringContains(rings[0], latitude, longitude) {
+			continue
+		}
+
+		inHole := false
+		for _, hole := range rings[1:] {
+			if ringContains(hole, latitude, longitude) {
+				inHole = true
+				break
+			}
+		}
+
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+func ringContains(ring []geoPoint, latitude, longitude float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.latitude > latitude) != (b.latitude > latitude) &&
+			longitude < (b.longitude-a.longitude)*(latitude-a.latitude)/(b.latitude-a.latitude)+a.longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+var distanceUnits = []struct {
+	suffix string
+	meters float64
+}{
+	{"nm", 1852},
+	{"km", 1000},
+	{"ft", 0.3048},
+	{"m", 1},
+}
+
+// ParseDistance parses a distance with an optional unit suffix (nm, km, ft or
+//  m) returning the distance in meters. Distances without a unit are meters.
+func ParseDistance(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	scale := 1.0
+	for _, unit := range distanceUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			scale = unit.meters
+			break
+		}
+	}
+
+	distance, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to parse distance '%v'", value)
+	}
+	return distance * scale, nil
+}
+
+func parseFloatList(value string, count int) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != count {
+		return nil, fmt.Errorf("Expected %v comma separated values, found '%v'", count, value)
+	}
+
+	result := make([]float64, count)
+	for idx, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse number '%v'", part)
+		}
+		result[idx] = number
+	}
+	return result, nil
+}
+
+// ParseLatLon parses a `lat,lon` coordinate pair
+func ParseLatLon(value string) (float64, float64, error) {
+	values, err := parseFloatList(value, 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	return values[0], values[1], nil
+}
+
+// ParseBoundingBoxRegion parses a region provided as `minLat,minLon,maxLat,maxLon`
+func ParseBoundingBoxRegion(value string) (Region, error) {
+	values, err := parseFloatList(value, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	return &boundingBoxRegion{
+		minLatitude:  math.Min(values[0], values[2]),
+		minLongitude: math.Min(values[1], values[3]),
+		maxLatitude:  math.Max(values[0], values[2]),
+		maxLongitude: math.Max(values[1], values[3]),
+	}, nil
+}
+
+// ParseCircleRegion parses a region provided as `lat,lon,radius`
+func ParseCircleRegion(value string) (Region, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("Expected lat,lon,radius for circle region, found '%v'", value)
+	}
+
+	latitude, longitude, err := ParseLatLon(strings.Join(parts[:2], ","))
+	if err != nil {
+		return nil, err
+	}
+
+	radius, err := ParseDistance(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCircleRegion(latitude, longitude, radius), nil
+}
+
+type geoJSONObject struct {
+	Type        string           `json:"type"`
+	Coordinates json.RawMessage  `json:"coordinates"`
+	Geometry    *geoJSONObject   `json:"geometry"`
+	Geometries  []*geoJSONObject `json:"geometries"`
+	Features    []*geoJSONObject `json:"features"`
+}
+
+// LoadGeoJSONRegion loads every Polygon and MultiPolygon geometry from a GeoJSON file
+func LoadGeoJSONRegion(path string) (Region, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var object geoJSONObject
+	err = json.Unmarshal(data, &object)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse GeoJSON file '%v': %v", path, err)
+	}
+
+	result := &polygonRegion{}
+	err = result.collect(&object)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.polygons) == 0 {
+		return nil, fmt.Errorf("No polygons found in GeoJSON file '%v'", path)
+	}
+	return result, nil
+}
+
+func (p *polygonRegion) collect(object *geoJSONObject) error {
+	switch object.Type {
+	case "FeatureCollection":
+		for _, feature := range object.Features {
+			err := p.collect(feature)
+			if err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if object.Geometry != nil {
+			return p.collect(object.Geometry)
+		}
+	case "GeometryCollection":
+		for _, geometry := range object.Geometries {
+			err := p.collect(geometry)
+			if err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var coordinates [][][]float64
+		err := json.Unmarshal(object.Coordinates, &coordinates)
+		if err != nil {
+			return err
+		}
+		p.polygons = append(p.polygons, geoJSONRings(coordinates))
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		err := json.Unmarshal(object.Coordinates, &coordinates)
+		if err != nil {
+			return err
+		}
+		for _, polygon := range coordinates {
+			p.polygons = append(p.polygons, geoJSONRings(polygon))
+		}
+	}
+	return nil
+}
+
+// GeoJSON positions are ordered longitude, latitude
+func geoJSONRings(coordinates [][][]float64) [][]geoPoint {
+	rings := make([][]geoPoint, 0, len(coordinates))
+	for _, ring := range coordinates {
+		points := make([]geoPoint, 0, len(ring))
+		for _, position := range ring {
+			if len(position) < 2 {
+				continue
+			}
+			points = append(points, geoPoint{latitude: position[1], longitude: position[0]})
+		}
+		rings = append(rings, points)
+	}
+	return rings
+}
+
+// regionFilter removes objects from time frames while they are outside of a
+//  region. Objects leaving the region are removed and objects re-entering the
+//  region are re-created with their full state. Objects without a known
+//  position are always kept.
+type regionFilter struct {
+	region  Region
+	world   *tacview.World
+	visible map[uint64]struct{}
+}
+
+func newRegionFilter(r Region, header *tacview.Header) *regionFilter {
+	return &regionFilter{
+		region:  r,
+		world:   tacview.NewWorld(header),
+		visible: make(map[uint64]struct{}),
+	}
+}
+
+func (r *regionFilter) Filter(tf *tacview.TimeFrame) error {
+	err := r.world.Apply(tf)
+	if err != nil {
+		return err
+	}
+
+	objects := make([]*tacview.Object, 0, len(tf.Objects))
+	for _, object := range tf.Objects {
+		if object.Id == 0 {
+			objects = append(objects, object)
+			continue
+		}
+
+		_, wasVisible := r.visible[object.Id]
+		if object.Deleted {
+			if wasVisible {
+				delete(r.visible, object.Id)
+				objects = append(objects, object)
+			}
+			continue
+		}
+
+		state := r.world.Get(object.Id)
+		inside := !state.HasTransform || r.region.Contains(state.Transform.Latitude, state.Transform.Longitude)
+
+		if inside && wasVisible {
+			objects = append(objects, object)
+		} else if inside {
+			r.visible[object.Id] = struct{}{}
+			objects = append(objects, state.Object.Clone())
+		} else if wasVisible {
+			delete(r.visible, object.Id)
+			removed := tacview.NewObject(object.Id)
+			removed.Deleted = true
+			objects = append(objects, removed)
+		}
+	}
+	tf.Objects = objects
+
+	return nil
+}
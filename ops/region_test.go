@@ -0,0 +1,158 @@
+package ops
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDistance(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected float64
+		valid    bool
+	}{
+		{"100", 100, true},
+		{"100m", 100, true},
+		{"2 km", 2000, true},
+		{"10nm", 18520, true},
+		{"1000ft", 304.8, true},
+		{"nm", 0, false},
+		{"ten", 0, false},
+	}
+
+	for _, c := range cases {
+		distance, err := ParseDistance(c.value)
+		if (err == nil) != c.valid || math.Abs(distance-c.expected) > 1e-9 {
+			t.Fatalf("Parsing '%v': expected %v (valid=%v), got %v (%v)", c.value, c.expected, c.valid, distance, err)
+		}
+	}
+}
+
+func TestRegionContains(t *testing.T) {
+	geoJSON := `{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Polygon",
+		"coordinates":[[[0,0],[2,0],[2,2],[0,2],[0,0]],[[0.5,0.5],[1,0.5],[1,1],[0.5,1],[0.5,0.5]]]}}]}`
+	path := filepath.Join(t.TempDir(), "region.geojson")
+	err := os.WriteFile(path, []byte(geoJSON), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bbox, err := ParseBoundingBoxRegion("1,1,0,0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	circle, err := ParseCircleRegion("0,0,60nm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	polygon, err := LoadGeoJSONRegion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                string
+		region              Region
+		latitude, longitude float64
+		expected            bool
+	}{
+		{"bbox inside", bbox, 0.5, 0.5, true},
+		{"bbox edge", bbox, 1, 0, true},
+		{"bbox outside", bbox, 0.5, 1.5, false},
+		{"circle inside", circle, 0.9, 0, true},
+		{"circle outside", circle, 0.8, 0.8, false},
+		{"polygon inside", polygon, 1.5, 1.5, true},
+		{"polygon hole", polygon, 0.75, 0.75, false},
+		{"polygon outside", polygon, 2.5, 1, false},
+	}
+
+	for _, c := range cases {
+		if c.region.Contains(c.latitude, c.longitude) != c.expected {
+			t.Fatalf("%v: expected %v for %v,%v", c.name, c.expected, c.latitude, c.longitude)
+		}
+	}
+
+	for _, value := range []string{"1,2,3", "a,b,c,d"} {
+		if _, err := ParseBoundingBoxRegion(value); err == nil {
+			t.Fatalf("Expected an error parsing bounding box '%v'", value)
+		}
+	}
+	for _, value := range []string{"1,2", "1,2,far"} {
+		if _, err := ParseCircleRegion(value); err == nil {
+			t.Fatalf("Expected an error parsing circle '%v'", value)
+		}
+	}
+}
+
+func TestRegionFilter(t *testing.T) {
+	region, err := ParseBoundingBoxRegion("0,0,1,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := opsTestData(
+		"#1", "1,T=0.5|0.5|100,Name=A", "2,T=5|5|100,Name=B", "3,Name=C",
+		"#2", "1,T=2|0.5|100", "2,T=5.1|5|100",
+		"#3", "1,T=3|0.5|",
+		"#4", "1,T=0.6|0.6|", "2,T=0.5|0.5|",
+		"#5", "1,T=0.7|0.7|", "-2",
+		"#6", "1,T=2||", "-3",
+		"#7", "-1",
+	)
+	expected := strings.Join([]string{
+		// Objects without a position are always kept
+		"#1", "1,T=0.5|0.5|100,Name=A", "3,Name=C",
+		// Objects leaving the region are removed
+		"#2", "-1",
+		"#3",
+		// Objects entering the region are created with their full state
+		"#4", "1,T=0.6|0.6|100,Name=A", "2,T=0.5|0.5|100,Name=B",
+		"#5", "1,T=0.7|0.7|", "-2",
+		"#6", "-1", "-3",
+		// Objects removed while outside of the region are not removed again
+		"#7",
+	}, "\n")
+
+	output := runNormalize(t, input, NormalizeOptions{Region: region})
+	if frames := timeFrames(output); frames != expected {
+		t.Fatalf("Expected:\n%v\nwrote:\n%v", expected, frames)
+	}
+}
+
+func TestTrimRegion(t *testing.T) {
+	region, err := ParseBoundingBoxRegion("0,0,1,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := opsTestData(
+		"#1", "1,T=0.5|0.5|100,Name=A", "2,T=5|5|100,Name=B",
+		"#2", "1,T=2|0.5|100",
+		"#3", "1,T=0.6|0.6|",
+		"#4", "-1",
+	)
+
+	var output bytes.Buffer
+	err = Trim(context.Background(), strings.NewReader(input), &output, TrimOptions{Start: "1.5", End: "3.5", Region: region})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"0,ReferenceTime=2021-07-24T04:00:01.5Z",
+		"1,T=0.5|0.5|100,Name=A",
+		"#0.5", "-1",
+		"#1.5", "1,T=0.6|0.6|100,Name=A",
+	}, "\n")
+	// The initial time frame keeps objects alive at the start and the time frame
+	//  right at the start is not dropped
+	trimmed := shortenOffsets(strings.TrimPrefix(output.String(), "\ufeff"+opsTestHeader))
+	if trimmed != expected {
+		t.Fatalf("Expected:\n%v\nwrote:\n%v", expected, trimmed)
+	}
+}
@@ -0,0 +1,226 @@
+package ops
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// PropertyRules describes a set of property-level transformations applied to
+//  every object (excluding the global object) before it is written. Rules are
+//  applied in order: drop, rename, set and finally transform rounding.
+type PropertyRules struct {
+	// Drop lists property keys that will be removed from every object
+	Drop []string `json:"drop"`
+	// Rename maps an existing property key to a new key
+	Rename map[string]string `json:"rename"`
+	// Set forces property values on objects matching a set of properties
+	Set []*propertySetRule `json:"set"`
+	// TransformPrecision rounds each `T` component to the given number of decimals
+	TransformPrecision *int `json:"transform_precision"`
+}
+
+// propertySetRule forces properties on any object whose (renamed) properties
+//  match all of the given key/value pairs. Matches are evaluated against the
+//  full known state of the object, not just the properties in the current frame.
+type propertySetRule struct {
+	Match      map[string]string `json:"match"`
+	Properties map[string]string `json:"properties"`
+}
+
+func LoadPropertyRules(path string) (*PropertyRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules PropertyRules
+	err = json.Unmarshal(data, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse rules file '%v': %v", path, err)
+	}
+
+	if rules.TransformPrecision != nil && *rules.TransformPrecision < 0 {
+		return nil, fmt.Errorf("Invalid transform precision %v", *rules.TransformPrecision)
+	}
+
+	return &rules, nil
+}
+
+// propertyRewriter applies PropertyRules to a stream of time frames. Frames must
+//  be provided in order as the rewriter tracks object state between frames.
+type propertyRewriter struct {
+	rules *PropertyRules
+	drop  map[string]struct{}
+
+	// Tracked values for keys referenced in set rule matches
+	matchState map[uint64]map[string]string
+	// Last values emitted for keys forced by set rules
+	forcedState map[uint64]map[string]string
+}
+
+func newPropertyRewriter(rules *PropertyRules) *propertyRewriter {
+	drop := make(map[string]struct{})
+	for _, key := range rules.Drop {
+		drop[key] = struct{}{}
+	}
+
+	return &propertyRewriter{
+		rules:       rules,
+		drop:        drop,
+		matchState:  make(map[uint64]map[string]string),
+		forcedState: make(map[uint64]map[string]string),
+	}
+}
+
+// Filter rewrites all objects within the time frame, removing any objects which
+//  no longer have any properties.
+func (p *propertyRewriter) Filter(tf *tacview.TimeFrame) error {
+	objects := make([]*tacview.Object, 0, len(tf.Objects))
+	for _, object := range tf.Objects {
+		if object.Id == 0 {
+			objects = append(objects, object)
+			continue
+		}
+
+		if object.Deleted {
+			delete(p.matchState, object.Id)
+			delete(p.forcedState, object.Id)
+			objects = append(objects, object)
+			continue
+		}
+
+		err := p.applyObject(object)
+		if err != nil {
+			return err
+		}
+
+		if len(object.Properties) > 0 {
+			objects = append(objects, object)
+		}
+	}
+	tf.Objects = objects
+	return nil
+}
+
+func (p *propertyRewriter) applyObject(object *tacview.Object) error {
+	// Renames are resolved against the original keys, a renamed property
+	//  replaces any property already using its new key
+	var replaced map[string]struct{}
+	if len(p.rules.Rename) > 0 {
+		replaced = make(map[string]struct{})
+		for _, property := range object.Properties {
+			if _, ok := p.drop[property.Key]; ok {
+				continue
+			}
+			if newKey, ok := p.rules.Rename[property.Key]; ok {
+				replaced[newKey] = struct{}{}
+			}
+		}
+	}
+
+	// Assigning a new slice rebuilds the key index of the object
+	properties := make([]*tacview.Property, 0, len(object.Properties))
+	for _, property := range object.Properties {
+		if _, ok := p.drop[property.Key]; ok {
+			continue
+		}
+
+		if newKey, ok := p.rules.Rename[property.Key]; ok {
+			property.Key = newKey
+		} else if _, ok := replaced[property.Key]; ok {
+			continue
+		}
+
+		properties = append(properties, property)
+	}
+	object.Properties = properties
+
+	if len(p.rules.Set) > 0 {
+		p.applySetRules(object)
+	}
+
+	if p.rules.TransformPrecision != nil {
+		transform := object.Get("T")
+		if transform != nil {
+			rounded, err := roundTransform(transform.Value, *p.rules.TransformPrecision)
+			if err != nil {
+				return err
+			}
+			transform.Value = rounded
+		}
+	}
+
+	return nil
+}
+
+func (p *propertyRewriter) applySetRules(object *tacview.Object) {
+	state, ok := p.matchState[object.Id]
+	if !ok {
+		state = make(map[string]string)
+		p.matchState[object.Id] = state
+	}
+
+	for _, rule := range p.rules.Set {
+		for key := range rule.Match {
+			if property := object.Get(key); property != nil {
+				state[key] = property.Value
+			}
+		}
+	}
+
+	forced, ok := p.forcedState[object.Id]
+	if !ok {
+		forced = make(map[string]string)
+		p.forcedState[object.Id] = forced
+	}
+
+	for _, rule := range p.rules.Set {
+		matched := true
+		for k, v := range rule.Match {
+			if value, ok := state[k]; !ok || value != v {
+				matched = false
+				break
+			}
+		}
+
+		if !matched {
+			continue
+		}
+
+		for k, v := range rule.Properties {
+			// Only emit the forced value if the object is updating it or we have
+			//  not yet emitted it.
+			if lastValue, ok := forced[k]; ok && lastValue == v && object.Get(k) == nil {
+				continue
+			}
+
+			object.Set(k, v)
+			forced[k] = v
+		}
+	}
+}
+
+func roundTransform(value string, precision int) (string, error) {
+	parts := strings.Split(value, "|")
+	scale := math.Pow(10, float64(precision))
+	for idx, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return "", fmt.Errorf("Failed to parse transform component `%v`: %v", part, err)
+		}
+
+		parts[idx] = strconv.FormatFloat(math.Round(number*scale)/scale, 'f', -1, 64)
+	}
+
+	return strings.Join(parts, "|"), nil
+}
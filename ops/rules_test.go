@@ -0,0 +1,132 @@
+package ops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPropertyRules(t *testing.T) {
+	precision := 2
+
+	cases := []struct {
+		name     string
+		rules    PropertyRules
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "drop",
+			rules:    PropertyRules{Drop: []string{"AOA", "Throttle"}},
+			input:    []string{"#1", "1,T=1|2|3,AOA=2,Name=A,Throttle=1"},
+			expected: []string{"#1", "1,T=1|2|3,Name=A"},
+		},
+		{
+			name:     "objects without properties are removed",
+			rules:    PropertyRules{Drop: []string{"AOA"}},
+			input:    []string{"#1", "1,T=1|2|3,AOA=2", "#2", "1,AOA=3", "2,AOA=1,Name=B", "#3", "-1"},
+			expected: []string{"#1", "1,T=1|2|3", "#2", "2,Name=B", "#3", "-1"},
+		},
+		{
+			name:     "rename keeps position",
+			rules:    PropertyRules{Rename: map[string]string{"Name": "ShortName"}},
+			input:    []string{"#1", "1,Name=F-16C,T=1|2|3"},
+			expected: []string{"#1", "1,ShortName=F-16C,T=1|2|3"},
+		},
+		{
+			name:     "rename replaces the existing key",
+			rules:    PropertyRules{Rename: map[string]string{"Name": "ShortName"}},
+			input:    []string{"#1", "1,Name=F-16C,ShortName=Viper"},
+			expected: []string{"#1", "1,ShortName=F-16C"},
+		},
+		{
+			name:     "rename chains use the original keys",
+			rules:    PropertyRules{Rename: map[string]string{"Name": "ShortName", "ShortName": "Label"}},
+			input:    []string{"#1", "1,Name=F-16C,ShortName=Viper"},
+			expected: []string{"#1", "1,ShortName=F-16C,Label=Viper"},
+		},
+		{
+			name:     "drop applies before rename",
+			rules:    PropertyRules{Drop: []string{"Name"}, Rename: map[string]string{"Name": "ShortName"}},
+			input:    []string{"#1", "1,Name=F-16C,Pilot=A"},
+			expected: []string{"#1", "1,Pilot=A"},
+		},
+		{
+			name: "set matches the known state",
+			rules: PropertyRules{Set: []*propertySetRule{
+				{Match: map[string]string{"Coalition": "Enemies"}, Properties: map[string]string{"Color": "Red"}},
+			}},
+			input: []string{
+				"#1", "1,Coalition=Enemies,Color=Blue", "2,Coalition=Allies,Color=Blue",
+				"#2", "1,Color=Blue",
+				"#3", "1,T=1|2|3",
+				"#4", "-1",
+				"#5", "1,Coalition=Allies,Color=Blue",
+			},
+			expected: []string{
+				"#1", "1,Coalition=Enemies,Color=Red", "2,Coalition=Allies,Color=Blue",
+				"#2", "1,Color=Red",
+				"#3", "1,T=1|2|3",
+				"#4", "-1",
+				"#5", "1,Coalition=Allies,Color=Blue",
+			},
+		},
+		{
+			name: "set matches renamed keys",
+			rules: PropertyRules{
+				Rename: map[string]string{"Side": "Coalition"},
+				Set: []*propertySetRule{
+					{Match: map[string]string{"Coalition": "Enemies"}, Properties: map[string]string{"Color": "Red"}},
+				},
+			},
+			input:    []string{"#1", "1,Side=Enemies"},
+			expected: []string{"#1", "1,Coalition=Enemies,Color=Red"},
+		},
+		{
+			name:     "transform precision",
+			rules:    PropertyRules{TransformPrecision: &precision},
+			input:    []string{"#1", "1,T=1.23456|2.5||100.004|0.999"},
+			expected: []string{"#1", "1,T=1.23|2.5||100|1"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules := c.rules
+			output := runNormalize(t, opsTestData(c.input...), NormalizeOptions{Rules: &rules})
+			if frames := timeFrames(output); frames != strings.Join(c.expected, "\n") {
+				t.Fatalf("Expected:\n%v\nwrote:\n%v", strings.Join(c.expected, "\n"), frames)
+			}
+		})
+	}
+}
+
+func TestLoadPropertyRules(t *testing.T) {
+	directory := t.TempDir()
+
+	cases := []struct {
+		contents string
+		valid    bool
+	}{
+		{`{"drop":["AOA"],"rename":{"Name":"ShortName"},"transform_precision":3}`, true},
+		{`{"transform_precision":-1}`, false},
+		{`{"drop":`, false},
+	}
+
+	for idx, c := range cases {
+		path := filepath.Join(directory, "rules.json")
+		err := os.WriteFile(path, []byte(c.contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rules, err := LoadPropertyRules(path)
+		if (err == nil) != c.valid {
+			t.Fatalf("Case %v: expected valid=%v, got %v", idx, c.valid, err)
+		}
+		if c.valid && (len(rules.Drop) != 1 || rules.Rename["Name"] != "ShortName" || *rules.TransformPrecision != 3) {
+			t.Fatalf("Case %v: rules were not loaded: %+v", idx, rules)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// searchQuery describes the conditions an object must meet to be matched
+type searchQuery struct {
+	properties  map[string]string
+	regions     []Region
+	minAltitude float64
+	maxAltitude float64
+	from        float64
+	until       float64
+}
+
+// constrained returns whether the query has any spatial or temporal constraints
+func (q *searchQuery) constrained() bool {
+	return len(q.regions) > 0 ||
+		!math.IsInf(q.minAltitude, -1) || !math.IsInf(q.maxAltitude, 1) ||
+		!math.IsInf(q.from, -1) || !math.IsInf(q.until, 1)
+}
+
+func (q *searchQuery) matchesProperties(object *tacview.Object) bool {
+	for k, v := range q.properties {
+		if res := object.Get(k); res == nil || res.Value != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *searchQuery) matchesState(state *tacview.ObjectState) bool {
+	if !q.matchesProperties(state.Object) {
+		return false
+	}
+
+	if len(q.regions) > 0 || !math.IsInf(q.minAltitude, -1) || !math.IsInf(q.maxAltitude, 1) {
+		if !state.HasTransform {
+			return false
+		}
+
+		if state.Transform.Altitude < q.minAltitude || state.Transform.Altitude > q.maxAltitude {
+			return false
+		}
+
+		for _, r := range q.regions {
+			if !r.Contains(state.Transform.Latitude, state.Transform.Longitude) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// SearchPosition is the position of a matched object, optionally described
+//  relative to a PositionReference
+type SearchPosition struct {
+	Latitude  float64       `json:"latitude"`
+	Longitude float64       `json:"longitude"`
+	Altitude  float64       `json:"altitude"`
+	Bullseye  *BullseyeCall `json:"bullseye,omitempty"`
+	BRAA      *BRAACall     `json:"braa,omitempty"`
+}
+
+func newSearchPosition(transform tacview.Transform) *SearchPosition {
+	return &SearchPosition{
+		Latitude:  transform.Latitude,
+		Longitude: transform.Longitude,
+		Altitude:  transform.Altitude,
+	}
+}
+
+func (p *SearchPosition) String() string {
+	result := fmt.Sprintf("%v, %v at %vm", p.Latitude, p.Longitude, p.Altitude)
+	if p.Bullseye != nil {
+		result += fmt.Sprintf(" (%v)", p.Bullseye)
+	}
+	if p.BRAA != nil {
+		result += fmt.Sprintf(" (%v)", p.BRAA)
+	}
+	return result
+}
+
+// Search result outcomes
+const (
+	searchOutcomeAlive     = "alive"
+	searchOutcomeDestroyed = "destroyed"
+	searchOutcomeLanded    = "landed"
+	searchOutcomeLeftArea  = "left_area"
+	searchOutcomeTimeout   = "timeout"
+	searchOutcomeDespawned = "despawned"
+)
+
+// SearchResult describes an object matched by Search
+type SearchResult struct {
+	// Object contains the final full state of the object
+	Object *tacview.Object `json:"object"`
+	// FirstSeen is the offset the object was spawned at
+	FirstSeen float64 `json:"first_seen"`
+	// LastSeen is the offset of the last time frame which mentions the object
+	LastSeen float64 `json:"last_seen"`
+	// Removed is the offset the object was removed at (if it was removed)
+	Removed *float64 `json:"removed,omitempty"`
+	// Destroyed is the offset of the Destroyed event for the object (if one exists)
+	Destroyed       *float64        `json:"destroyed,omitempty"`
+	Outcome         string          `json:"outcome"`
+	SpawnPosition   *SearchPosition `json:"spawn_position,omitempty"`
+	RemovalPosition *SearchPosition `json:"removal_position,omitempty"`
+	// MatchedAt and Position describe when and where a spatial or temporal
+	//  query was first matched
+	MatchedAt *float64        `json:"matched_at,omitempty"`
+	Position  *SearchPosition `json:"position,omitempty"`
+	// ReferenceTime is the reference time of the searched recording
+	ReferenceTime time.Time `json:"-"`
+
+	state     *tacview.ObjectState
+	lastEvent string
+}
+
+// finish records the outcome of the search result once the object is removed
+//  or the end of the recording is reached.
+func (r *SearchResult) finish(removed *float64) {
+	r.Object = r.state.Object
+	r.Removed = removed
+
+	if removed != nil && r.state.HasTransform {
+		r.RemovalPosition = newSearchPosition(r.state.Transform)
+	}
+
+	if r.Destroyed != nil {
+		r.Outcome = searchOutcomeDestroyed
+	} else if removed == nil {
+		r.Outcome = searchOutcomeAlive
+	} else if r.lastEvent == "Landed" {
+		r.Outcome = searchOutcomeLanded
+	} else if r.lastEvent == "LeftArea" {
+		r.Outcome = searchOutcomeLeftArea
+	} else if r.lastEvent == "Timeout" {
+		r.Outcome = searchOutcomeTimeout
+	} else {
+		r.Outcome = searchOutcomeDespawned
+	}
+}
+
+// search matches objects against the reconstructed state of each object,
+//  matching objects which meet the query at any point within its time window.
+//  Positions are also described relative to the reference when one is given.
+func search(concurrency int, reader *tacview.Reader, query *searchQuery, reference *PositionReference) ([]*SearchResult, error) {
+	results := make([]*SearchResult, 0)
+	// Matched results for objects which are still alive
+	live := make(map[uint64]*SearchResult)
+	world := tacview.NewWorld(&reader.Header)
+	motions := newMotionTracker(1)
+	windowOpen := false
+
+	describe := func(position *SearchPosition, state *tacview.ObjectState, transform tacview.Transform) {
+		if reference == nil || position == nil {
+			return
+		}
+		position.Bullseye, position.BRAA = reference.describe(world, transform, state, motions.get(state.Id()))
+	}
+
+	evaluate := func(state *tacview.ObjectState, offset float64) {
+		if _, ok := live[state.Id()]; ok || !query.matchesState(state) {
+			return
+		}
+
+		result := &SearchResult{
+			FirstSeen:     state.Spawned,
+			LastSeen:      state.Updated,
+			ReferenceTime: reader.Header.ReferenceTime,
+			state:         state,
+		}
+		if state.HasTransform {
+			result.SpawnPosition = newSearchPosition(state.SpawnTransform)
+			describe(result.SpawnPosition, state, state.SpawnTransform)
+			// The reference aircraft position is only known for objects
+			//  spawned in the current time frame
+			if state.Spawned != world.Offset {
+				result.SpawnPosition.BRAA = nil
+			}
+		}
+		if query.constrained() {
+			matchedAt := offset
+			result.MatchedAt = &matchedAt
+			if state.HasTransform {
+				result.Position = newSearchPosition(state.Transform)
+				describe(result.Position, state, state.Transform)
+			}
+		}
+
+		live[state.Id()] = result
+		results = append(results, result)
+	}
+
+	evaluateAll := func(offset float64) {
+		windowOpen = true
+		for _, state := range world.Objects {
+			evaluate(state, offset)
+		}
+	}
+
+	process := func(tf *tacview.TimeFrame) error {
+		// The window started between the previous time frame and this one
+		if !windowOpen && tf.Offset > query.from {
+			evaluateAll(query.from)
+		}
+
+		err := world.Apply(tf)
+		if err != nil {
+			return err
+		}
+		if reference != nil {
+			motions.update(world, tf)
+		}
+
+		for _, object := range tf.Objects {
+			if result, ok := live[object.Id]; ok {
+				result.LastSeen = tf.Offset
+			}
+		}
+
+		// Objects are matched before the events and removals of the time frame
+		//  are recorded so those apply to objects matched within it
+		if tf.Offset >= query.from && tf.Offset <= query.until {
+			if !windowOpen {
+				evaluateAll(tf.Offset)
+			} else {
+				for _, object := range tf.Objects {
+					if object.Id == 0 || object.Deleted {
+						continue
+					}
+					evaluate(world.Get(object.Id), tf.Offset)
+				}
+			}
+		}
+
+		for _, event := range world.Events {
+			for _, id := range event.Objects {
+				result, ok := live[id]
+				if !ok {
+					continue
+				}
+
+				result.lastEvent = event.Type
+				if event.Type == "Destroyed" && result.Destroyed == nil {
+					destroyed := tf.Offset
+					result.Destroyed = &destroyed
+				}
+			}
+		}
+
+		for _, state := range world.Removed {
+			result, ok := live[state.Id()]
+			if !ok || result.state != state {
+				continue
+			}
+
+			removed := tf.Offset
+			result.finish(&removed)
+			describe(result.RemovalPosition, state, state.Transform)
+			delete(live, state.Id())
+		}
+
+		return nil
+	}
+
+	initialTimeFrame := reader.Header.InitialTimeFrame
+	err := process(&initialTimeFrame)
+	if err != nil {
+		return nil, err
+	}
+
+	err = processTimeFrames(concurrency, reader, process)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range live {
+		result.finish(nil)
+	}
+
+	return results, nil
+}
+
+// SearchOptions configures Search. At least one property or constraint must be
+//  provided.
+type SearchOptions struct {
+	Options
+	// Properties are key=value pairs every matched object must have
+	Properties map[string]string
+	// Regions only match objects within every region
+	Regions []Region
+	// MinAltitude and MaxAltitude only match objects within the altitudes (in
+	//  meters) when set
+	MinAltitude *float64
+	MaxAltitude *float64
+	// From and Until only match objects within the time window when set
+	From  Time
+	Until Time
+	// AliveAt only matches objects alive at the given time, it cannot be
+	//  combined with From or Until
+	AliveAt Time
+	// Reference describes matched positions relative to a bullseye or aircraft
+	Reference *PositionReference
+}
+
+// newSearchQuery creates the query described by the options for a recording
+func newSearchQuery(options *SearchOptions, header *tacview.Header) (*searchQuery, error) {
+	query := &searchQuery{
+		properties:  options.Properties,
+		regions:     options.Regions,
+		minAltitude: math.Inf(-1),
+		maxAltitude: math.Inf(1),
+		from:        math.Inf(-1),
+		until:       math.Inf(1),
+	}
+
+	if options.MinAltitude != nil {
+		query.minAltitude = *options.MinAltitude
+	}
+	if options.MaxAltitude != nil {
+		query.maxAltitude = *options.MaxAltitude
+	}
+
+	var err error
+	if options.From != "" {
+		query.from, err = options.From.Offset(header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Until != "" {
+		query.until, err = options.Until.Offset(header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.AliveAt != "" {
+		if options.From != "" || options.Until != "" {
+			return nil, fmt.Errorf("Search alive at time cannot be combined with a start or end time")
+		}
+
+		query.from, err = options.AliveAt.Offset(header)
+		if err != nil {
+			return nil, err
+		}
+		query.until = query.from
+	}
+
+	if query.from > query.until {
+		return nil, fmt.Errorf("Search start time is after the end time")
+	}
+
+	if len(query.properties) == 0 && !query.constrained() {
+		return nil, fmt.Errorf("No properties to search for")
+	}
+
+	return query, nil
+}
+
+// Search returns every object within the input matching the options, sorted by
+//  object id and the time it was first seen
+func Search(ctx context.Context, input io.Reader, options SearchOptions) ([]*SearchResult, error) {
+	options.Options = options.Options.withDefaults()
+
+	reader, err := options.newReader(ctx, 0, input)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := newSearchQuery(&options, &reader.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := search(options.Concurrency, reader, query, options.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Object.Id == results[j].Object.Id {
+			return results[i].FirstSeen < results[j].FirstSeen
+		}
+		return results[i].Object.Id < results[j].Object.Id
+	})
+	return results, nil
+}
@@ -0,0 +1,198 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// searchTestData has objects starting over the 0,0,1,1 bounding box (A), entering
+//  it (B), above it (C) and spawning within it later (D)
+var searchTestData = opsTestData(
+	"#1", "1,T=0.5|0.5|1000,Name=A", "2,T=5|5|1000,Name=B", "3,T=0.5|0.5|5000,Name=C",
+	"#2", "2,T=0.5|0.5|1000",
+	"#3", "1,T=5|5|",
+	"#4", "-3",
+	"#5", "4,T=0.6|0.6|1000,Name=D",
+)
+
+// searchMatches describes each result as `id@matched_at`
+func searchMatches(results []*SearchResult) string {
+	matches := make([]string, len(results))
+	for idx, result := range results {
+		matches[idx] = fmt.Sprint(result.Object.Id)
+		if result.MatchedAt != nil {
+			matches[idx] += fmt.Sprintf("@%v", *result.MatchedAt)
+		}
+	}
+	return strings.Join(matches, " ")
+}
+
+func TestSearchQueries(t *testing.T) {
+	region, err := ParseBoundingBoxRegion("0,0,1,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxAltitude := 2000.0
+
+	cases := []struct {
+		name     string
+		options  SearchOptions
+		expected string
+	}{
+		{
+			name:     "properties",
+			options:  SearchOptions{Properties: map[string]string{"Name": "D"}},
+			expected: "4",
+		},
+		{
+			name:     "region",
+			options:  SearchOptions{Regions: []Region{region}},
+			expected: "1@1 2@2 3@1 4@5",
+		},
+		{
+			name:     "region and altitude",
+			options:  SearchOptions{Regions: []Region{region}, MaxAltitude: &maxAltitude},
+			expected: "1@1 2@2 4@5",
+		},
+		{
+			name:     "region and properties",
+			options:  SearchOptions{Regions: []Region{region}, Properties: map[string]string{"Name": "B"}},
+			expected: "2@2",
+		},
+		{
+			name:     "window",
+			options:  SearchOptions{Regions: []Region{region}, From: "3", Until: "4"},
+			expected: "2@3 3@3",
+		},
+		{
+			name:     "window by clock time",
+			options:  SearchOptions{Regions: []Region{region}, From: "04:00:03", Until: "2021-07-24T04:00:04Z"},
+			expected: "2@3 3@3",
+		},
+		{
+			name:     "alive between time frames",
+			options:  SearchOptions{AliveAt: "4.5"},
+			expected: "1@4.5 2@4.5",
+		},
+		{
+			name:     "alive after the recording",
+			options:  SearchOptions{AliveAt: "10"},
+			expected: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			results, err := Search(context.Background(), strings.NewReader(searchTestData), c.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matches := searchMatches(results); matches != c.expected {
+				t.Fatalf("Expected matches '%v', found '%v'", c.expected, matches)
+			}
+		})
+	}
+}
+
+func TestSearchQueryErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		options SearchOptions
+	}{
+		{"no constraints", SearchOptions{}},
+		{"alive at with a window", SearchOptions{AliveAt: "1", From: "0"}},
+		{"empty window", SearchOptions{From: "4", Until: "3"}},
+		{"invalid time", SearchOptions{From: "noon"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Search(context.Background(), strings.NewReader(searchTestData), c.options)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+		})
+	}
+}
+
+func TestSearchLifetimes(t *testing.T) {
+	data := opsTestData(
+		"#1", "1,T=1|2|100,Name=A", "2,T=3|4|100,Name=B", "3,T=5|6|100,Name=C", "4,Name=D", "5,Name=E",
+		"#2", "1,T=1.5||", "0,Event=Destroyed|1|",
+		"#3", "-1", "0,Event=Landed|2|", "2,T=3|4|0",
+		"#4", "-2", "0,Event=LeftArea|3|", "-3", "4,Color=Red",
+		"#5", "-5",
+	)
+
+	results, err := Search(context.Background(), strings.NewReader(data), SearchOptions{AliveAt: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		outcome   string
+		lifetime  string
+		object    string
+		spawn     string
+		removal   string
+		destroyed bool
+	}{
+		{searchOutcomeDestroyed, "1-3 removed 3", "T=1.5|2|100,Name=A", "2, 1 at 100m", "2, 1.5 at 100m", true},
+		{searchOutcomeLanded, "1-4 removed 4", "T=3|4|0,Name=B", "4, 3 at 100m", "4, 3 at 0m", false},
+		{searchOutcomeLeftArea, "1-4 removed 4", "T=5|6|100,Name=C", "6, 5 at 100m", "6, 5 at 100m", false},
+		{searchOutcomeAlive, "1-4", "Name=D,Color=Red", "", "", false},
+		{searchOutcomeDespawned, "1-5 removed 5", "Name=E", "", "", false},
+	}
+
+	if len(results) != len(cases) {
+		t.Fatalf("Expected %v results, found %v", len(cases), len(results))
+	}
+	for idx, c := range cases {
+		result := results[idx]
+
+		lifetime := fmt.Sprintf("%v-%v", result.FirstSeen, result.LastSeen)
+		if result.Removed != nil {
+			lifetime += fmt.Sprintf(" removed %v", *result.Removed)
+		}
+		properties := make([]string, 0)
+		for _, property := range result.Object.Properties {
+			properties = append(properties, property.Key+"="+property.Value)
+		}
+		spawn, removal := "", ""
+		if result.SpawnPosition != nil {
+			spawn = result.SpawnPosition.String()
+		}
+		if result.RemovalPosition != nil {
+			removal = result.RemovalPosition.String()
+		}
+
+		if result.Outcome != c.outcome || lifetime != c.lifetime || strings.Join(properties, ",") != c.object ||
+			spawn != c.spawn || removal != c.removal || (result.Destroyed != nil) != c.destroyed {
+			t.Fatalf("Object %v: expected %+v, found %v %v %v '%v' '%v' %v",
+				result.Object.Id, c, result.Outcome, lifetime, properties, spawn, removal, result.Destroyed)
+		}
+	}
+}
+
+func TestSearchMatchFrameEvents(t *testing.T) {
+	data := opsTestData(
+		"#1", "1,T=1|2|100,Name=A",
+		"#2", "1,T=1.5||", "0,Event=Destroyed|1|",
+		"#3", "-1",
+	)
+
+	results, err := Search(context.Background(), strings.NewReader(data), SearchOptions{From: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The object is first matched in the time frame of its Destroyed event
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, found %v", len(results))
+	}
+	if result := results[0]; result.Outcome != searchOutcomeDestroyed || result.Destroyed == nil || *result.Destroyed != 2 {
+		t.Fatalf("Expected the object to be destroyed at 2, found %v %v", result.Outcome, result.Destroyed)
+	}
+}
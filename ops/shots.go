@@ -0,0 +1,342 @@
+package ops
+
+import (
+	"context"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+const (
+	shotLauncherRange = 200.0
+	// Seconds after a missile is removed in which a target destruction is
+	//  still attributed to the shot
+	shotDestroyedGrace = 5.0
+)
+
+// Shot describes a single missile launch. Distances are in meters, speeds in
+//  meters per second, angles in degrees and times are offsets in seconds.
+type Shot struct {
+	ReferenceTime    time.Time `json:"reference_time"`
+	WeaponId         uint64    `json:"weapon_id"`
+	Weapon           string    `json:"weapon"`
+	LauncherId       uint64    `json:"launcher_id"`
+	Launcher         string    `json:"launcher"`
+	Pilot            string    `json:"pilot"`
+	TargetId         uint64    `json:"target_id"`
+	Target           string    `json:"target"`
+	TargetPilot      string    `json:"target_pilot"`
+	Launch           float64   `json:"launch"`
+	LaunchRange      float64   `json:"launch_range"`
+	Aspect           float64   `json:"aspect"`
+	LauncherAltitude float64   `json:"launcher_altitude"`
+	TargetAltitude   float64   `json:"target_altitude"`
+	ClosingSpeed     float64   `json:"closing_speed"`
+	TimeOfFlight     float64   `json:"time_of_flight"`
+	ClosestApproach  float64   `json:"closest_approach"`
+	TargetDestroyed  bool      `json:"target_destroyed"`
+	// Bullseye and BRAA describe the launch position when a position reference
+	//  is configured
+	Bullseye *BullseyeCall `json:"bullseye,omitempty"`
+	BRAA     *BRAACall     `json:"braa,omitempty"`
+}
+
+// ShotAnalyzer attributes each missile to its launcher and intended target and
+//  measures the shot geometry and outcome.
+type ShotAnalyzer struct {
+	concurrency int
+	reference   *PositionReference
+	shots       []*Shot
+}
+
+// NewShotAnalyzer creates a new ShotAnalyzer. The launch position of each shot
+//  is described relative to the reference when it isn't nil.
+func NewShotAnalyzer(concurrency int, reference *PositionReference) *ShotAnalyzer {
+	return &ShotAnalyzer{concurrency: concurrency, reference: reference, shots: make([]*Shot, 0)}
+}
+
+// Shots returns every shot seen, ordered by launch time
+func (s *ShotAnalyzer) Shots() []*Shot {
+	sort.SliceStable(s.shots, func(i, j int) bool {
+		a := OffsetTime(s.shots[i].ReferenceTime, s.shots[i].Launch)
+		return a.Before(OffsetTime(s.shots[j].ReferenceTime, s.shots[j].Launch))
+	})
+	return s.shots
+}
+
+// shotSnapshot records the state of a potential target at launch time
+type shotSnapshot struct {
+	state           *tacview.ObjectState
+	transform       tacview.Transform
+	motion          *motion
+	closestApproach float64
+}
+
+type shotInFlight struct {
+	shot              *Shot
+	missile           *tacview.ObjectState
+	launcher          *tacview.ObjectState
+	launcherTransform tacview.Transform
+	launcherMotion    *motion
+	lockedTarget      uint64
+	candidates        map[uint64]*shotSnapshot
+}
+
+type shotProcessor struct {
+	header    *tacview.Header
+	reference *PositionReference
+	motions   *motionTracker
+	inFlight  map[uint64]*shotInFlight
+	finished  []*shotInFlight
+	destroyed map[uint64][]float64
+}
+
+// ProcessFile records every missile shot within the file
+func (s *ShotAnalyzer) ProcessFile(reader *tacview.Reader) error {
+	processor := &shotProcessor{
+		header:    &reader.Header,
+		reference: s.reference,
+		motions:   newMotionTracker(1),
+		inFlight:  make(map[uint64]*shotInFlight),
+		finished:  make([]*shotInFlight, 0),
+		destroyed: make(map[uint64][]float64),
+	}
+
+	world, err := reconstruct(s.concurrency, reader, processor.process)
+	if err != nil {
+		return err
+	}
+
+	for _, flight := range processor.inFlight {
+		processor.land(flight, world.Offset)
+	}
+
+	for _, flight := range processor.finished {
+		s.shots = append(s.shots, processor.complete(flight))
+	}
+	return nil
+}
+
+func copyMotion(m *motion) *motion {
+	if m == nil {
+		return nil
+	}
+	result := *m
+	return &result
+}
+
+func coalition(object *tacview.Object) string {
+	if property := object.Get("Coalition"); property != nil {
+		return property.Value
+	}
+	return ""
+}
+
+func (p *shotProcessor) process(world *tacview.World, tf *tacview.TimeFrame) error {
+	p.motions.update(world, tf)
+
+	for _, state := range world.Created {
+		if state.Object.HasTags("Weapon", "Missile") {
+			p.launch(world, state, tf.Offset)
+		}
+	}
+
+	for _, event := range world.Events {
+		if event.Type != "Destroyed" {
+			continue
+		}
+
+		for _, id := range event.Objects {
+			p.destroyed[id] = append(p.destroyed[id], tf.Offset)
+		}
+	}
+
+	for _, object := range tf.Objects {
+		if flight, ok := p.inFlight[object.Id]; ok && flight.missile.HasTransform {
+			p.track(flight)
+		}
+	}
+
+	for _, state := range world.Removed {
+		if flight, ok := p.inFlight[state.Id()]; ok && flight.missile == state {
+			p.land(flight, tf.Offset)
+		}
+	}
+
+	return nil
+}
+
+func (p *shotProcessor) launch(world *tacview.World, missile *tacview.ObjectState, offset float64) {
+	flight := &shotInFlight{
+		shot: &Shot{
+			ReferenceTime: p.header.ReferenceTime,
+			WeaponId:      missile.Id(),
+			Launch:        offset,
+		},
+		missile:    missile,
+		candidates: make(map[uint64]*shotSnapshot),
+	}
+
+	// The launch position is that of the launcher, or of the missile itself
+	//  when the launcher is unknown
+	launcher := findLauncher(world, missile, shotLauncherRange)
+	source := missile
+	if launcher != nil {
+		source = launcher
+	}
+	if source.HasTransform {
+		flight.shot.Bullseye, flight.shot.BRAA = p.reference.describe(world, source.Transform, source, p.motions.get(source.Id()))
+	}
+
+	if launcher != nil {
+		flight.launcher = launcher
+		flight.launcherTransform = launcher.Transform
+		flight.launcherMotion = copyMotion(p.motions.get(launcher.Id()))
+
+		if locked := launcher.Object.Get("LockedTarget"); locked != nil {
+			id, err := strconv.ParseUint(locked.Value, 16, 64)
+			if err == nil && world.Get(id) != nil {
+				flight.lockedTarget = id
+			}
+		}
+	}
+
+	launcherCoalition := ""
+	if launcher != nil {
+		launcherCoalition = coalition(launcher.Object)
+	}
+
+	for _, state := range world.Objects {
+		if state == launcher || !state.HasTransform || !isPlatform(state.Object) {
+			continue
+		}
+
+		if flight.lockedTarget != 0 && state.Id() != flight.lockedTarget {
+			continue
+		}
+
+		targetCoalition := coalition(state.Object)
+		if flight.lockedTarget == 0 && launcherCoalition != "" && launcherCoalition == targetCoalition {
+			continue
+		}
+
+		flight.candidates[state.Id()] = &shotSnapshot{
+			state:           state,
+			transform:       state.Transform,
+			motion:          copyMotion(p.motions.get(state.Id())),
+			closestApproach: math.Inf(1),
+		}
+	}
+
+	p.inFlight[missile.Id()] = flight
+	p.track(flight)
+}
+
+// track updates the closest approach of the missile to every potential target
+func (p *shotProcessor) track(flight *shotInFlight) {
+	if !flight.missile.HasTransform {
+		return
+	}
+
+	for _, candidate := range flight.candidates {
+		distance := slantRange(flight.missile.Transform, candidate.state.Transform)
+		if distance < candidate.closestApproach {
+			candidate.closestApproach = distance
+		}
+	}
+}
+
+func (p *shotProcessor) land(flight *shotInFlight, offset float64) {
+	delete(p.inFlight, flight.missile.Id())
+	flight.shot.TimeOfFlight = offset - flight.shot.Launch
+	p.finished = append(p.finished, flight)
+}
+
+// complete selects the target of the shot and computes the launch geometry
+func (p *shotProcessor) complete(flight *shotInFlight) *Shot {
+	shot := flight.shot
+	if property := flight.missile.Object.Get("Name"); property != nil {
+		shot.Weapon = property.Value
+	}
+
+	if flight.launcher != nil {
+		shot.LauncherId = flight.launcher.Id()
+		shot.LauncherAltitude = flight.launcherTransform.Altitude
+		if property := flight.launcher.Object.Get("Name"); property != nil {
+			shot.Launcher = property.Value
+		}
+		if property := flight.launcher.Object.Get("Pilot"); property != nil {
+			shot.Pilot = property.Value
+		}
+	}
+
+	var target *shotSnapshot
+	for _, candidate := range flight.candidates {
+		if target == nil || candidate.closestApproach < target.closestApproach {
+			target = candidate
+		}
+	}
+
+	if target == nil {
+		return shot
+	}
+
+	shot.TargetId = target.state.Id()
+	shot.TargetAltitude = target.transform.Altitude
+	shot.ClosestApproach = target.closestApproach
+	if property := target.state.Object.Get("Name"); property != nil {
+		shot.Target = property.Value
+	}
+	if property := target.state.Object.Get("Pilot"); property != nil {
+		shot.TargetPilot = property.Value
+	}
+
+	if flight.launcher != nil {
+		shot.LaunchRange = slantRange(flight.launcherTransform, target.transform)
+		shot.Aspect = aspectAngle(target.transform, heading(target.state, target.motion), flight.launcherTransform)
+		shot.ClosingSpeed = closingSpeed(flight.launcherTransform, flight.launcherMotion, target.transform, target.motion)
+	}
+
+	impact := shot.Launch + shot.TimeOfFlight
+	for _, destroyed := range p.destroyed[shot.TargetId] {
+		if destroyed >= shot.Launch && destroyed <= impact+shotDestroyedGrace {
+			shot.TargetDestroyed = true
+			break
+		}
+	}
+
+	return shot
+}
+
+// ShotsOptions configures Shots
+type ShotsOptions struct {
+	Options
+	// Pilots only reports shots taken by the pilots with the given names when set
+	Pilots []string
+	// Reference describes the launch position of each shot relative to a
+	//  bullseye or aircraft when set
+	Reference *PositionReference
+}
+
+// Shots returns every missile shot across the inputs, ordered by launch time
+func Shots(ctx context.Context, inputs []io.Reader, options ShotsOptions) ([]*Shot, error) {
+	options.Options = options.Options.withDefaults()
+
+	analyzer := NewShotAnalyzer(options.Concurrency, options.Reference)
+	err := processInputs(ctx, options.Options, inputs, analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	shots := make([]*Shot, 0)
+	for _, shot := range analyzer.Shots() {
+		if matchesPilot(options.Pilots, shot.Pilot) {
+			shots = append(shots, shot)
+		}
+	}
+	return shots, nil
+}
@@ -0,0 +1,92 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestShots(t *testing.T) {
+	data := opsTestData(
+		"#0",
+		"1,T=0|0|5000,Type=Air+FixedWing,Name=F-16C,Pilot=Tracer,Coalition=Allies",
+		"2,T=0|0.1|6000,Type=Air+FixedWing,Name=Su-27,Pilot=Hawk,Coalition=Enemies",
+		"3,T=0.05|0.05|3000,Type=Air+FixedWing,Name=Su-25,Coalition=Enemies",
+		"4,T=0.05|0|3000,Type=Air+FixedWing,Name=F-16C,Pilot=Viper,Coalition=Allies",
+		// The launcher and target close head on at about 110m/s each
+		"#10", "1,T=0|0.01|5000", "2,T=0|0.09|6000", "4,T=0.05|0|3000",
+		"#20", "2,T=0|0.08|6000", "5,T=0|0.01|5000,Type=Weapon+Missile,Name=AIM-120C,Parent=1",
+		"#30", "2,T=0|0.07|6000", "5,T=0|0.04|5600",
+		"#40", "2,T=0|0.06|6000", "5,T=0|0.0601|6000", "0,Event=Destroyed|2|",
+		"#41", "-5",
+		// The locked target is chosen over the closer enemy
+		"#50", "4,LockedTarget=3", "6,T=0.05|0.0001|3000,Type=Weapon+Missile,Name=AIM-9X",
+		"#55", "6,T=0.01|0.055|5500",
+		"#60", "-6",
+	)
+
+	cases := []struct {
+		name     string
+		pilots   []string
+		bullseye string
+		braaFrom string
+		expected []string
+	}{
+		{
+			name: "all shots",
+			expected: []string{
+				"AIM-120C 1>2 at 20 range=7811 aspect=180 closing=219 tof=21 closest=11 destroyed=true",
+				"AIM-9X 4>3 at 50 range=5531 aspect=0 closing=0 tof=10 closest=5139 destroyed=false",
+			},
+		},
+		{
+			name:     "filtered",
+			pilots:   []string{"Tracer"},
+			expected: []string{"AIM-120C 1>2 at 20 range=7811 aspect=180 closing=219 tof=21 closest=11 destroyed=true"},
+		},
+		{
+			// The launch positions are described, without a BRAA from the
+			//  reference aircraft to itself
+			name:     "positions",
+			bullseye: "0,0",
+			braaFrom: "Viper",
+			expected: []string{
+				"AIM-120C 1>2 at 20 range=7811 aspect=180 closing=219 tof=21 closest=11 destroyed=true " +
+					"bullseye 000/1, 16404ft braa 281/3, 16404ft, beam",
+				"AIM-9X 4>3 at 50 range=5531 aspect=0 closing=0 tof=10 closest=5139 destroyed=false " +
+					"bullseye 090/3, 9843ft",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			reference, err := ParsePositionReference(c.bullseye, c.braaFrom)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			shots, err := Shots(context.Background(), opsTestInputs(data), ShotsOptions{Pilots: c.pilots, Reference: reference})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := make([]string, len(shots))
+			for idx, shot := range shots {
+				found[idx] = fmt.Sprintf("%v %v>%v at %v range=%.0f aspect=%.0f closing=%.0f tof=%v closest=%.0f destroyed=%v",
+					shot.Weapon, shot.LauncherId, shot.TargetId, shot.Launch, shot.LaunchRange, shot.Aspect,
+					shot.ClosingSpeed, shot.TimeOfFlight, shot.ClosestApproach, shot.TargetDestroyed)
+				if shot.Bullseye != nil {
+					found[idx] += fmt.Sprintf(" %v", shot.Bullseye)
+				}
+				if shot.BRAA != nil {
+					found[idx] += fmt.Sprintf(" %v", shot.BRAA)
+				}
+			}
+			if strings.Join(found, "\n") != strings.Join(c.expected, "\n") {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", strings.Join(c.expected, "\n"), strings.Join(found, "\n"))
+			}
+		})
+	}
+}
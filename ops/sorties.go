@@ -0,0 +1,483 @@
+package ops
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// Sortie outcomes
+const (
+	SortieOutcomeLanded       = "landed"
+	SortieOutcomeCrashed      = "crashed"
+	SortieOutcomeShotDown     = "shot down"
+	SortieOutcomeEjected      = "ejected"
+	SortieOutcomeMissionEnded = "mission ended"
+	SortieOutcomeDespawned    = "despawned"
+)
+
+// Thresholds used to detect sortie phases from reconstructed motion. Speeds
+//  are in meters per second, heights and distances in meters.
+const (
+	sortieTaxiSpeed       = 2.5
+	sortieStoppedSpeed    = 1.0
+	sortieTakeOffSpeed    = 40.0
+	sortieLandedSpeed     = 25.0
+	sortieTakeOffHeight   = 15.0
+	sortieClimbHeight     = 100.0
+	sortieLandedHeight    = 40.0
+	sortieAerodromeRange  = 10000.0
+	sortieLauncherRange   = 200.0
+	sortieEjectionRange   = 500.0
+	sortieShotDownRange   = 250.0
+	sortieShotDownSeconds = 10.0
+)
+
+// Sortie describes a single flight from block out to block in. All times are
+//  offsets in seconds from the reference time.
+type Sortie struct {
+	ReferenceTime time.Time `json:"reference_time"`
+	ObjectId      uint64    `json:"object_id"`
+	Pilot         string    `json:"pilot"`
+	Airframe      string    `json:"airframe"`
+	Departure     string    `json:"departure"`
+	Arrival       string    `json:"arrival"`
+	BlockOut      float64   `json:"block_out"`
+	// TakeOff is nil when the aircraft spawned in the air
+	TakeOff *float64 `json:"takeoff"`
+	// Landing is nil when the aircraft never landed
+	Landing          *float64       `json:"landing"`
+	BlockIn          float64        `json:"block_in"`
+	BlockDuration    float64        `json:"block_duration"`
+	AirborneDuration float64        `json:"airborne_duration"`
+	Weapons          map[string]int `json:"weapons"`
+	Outcome          string         `json:"outcome"`
+	// Bullseye and BRAA describe where the sortie ended when a position
+	//  reference is configured
+	Bullseye *BullseyeCall `json:"bullseye,omitempty"`
+	BRAA     *BRAACall     `json:"braa,omitempty"`
+
+	airborneStart *float64
+}
+
+// SortieTracker detects sorties from takeoff and landing events and the
+//  reconstructed altitude and speed of every aircraft.
+type SortieTracker struct {
+	concurrency int
+	reference   *PositionReference
+	sorties     []*Sortie
+}
+
+// NewSortieTracker creates a new SortieTracker. The end of each sortie is
+//  described relative to the reference when it isn't nil.
+func NewSortieTracker(concurrency int, reference *PositionReference) *SortieTracker {
+	return &SortieTracker{concurrency: concurrency, reference: reference, sorties: make([]*Sortie, 0)}
+}
+
+// Sorties returns every sortie seen, ordered by block out time
+func (s *SortieTracker) Sorties() []*Sortie {
+	sort.SliceStable(s.sorties, func(i, j int) bool {
+		a := OffsetTime(s.sorties[i].ReferenceTime, s.sorties[i].BlockOut)
+		return a.Before(OffsetTime(s.sorties[j].ReferenceTime, s.sorties[j].BlockOut))
+	})
+	return s.sorties
+}
+
+// ProcessFile records every sortie within the file
+func (s *SortieTracker) ProcessFile(reader *tacview.Reader) error {
+	processor := &sortieProcessor{
+		header:     &reader.Header,
+		reference:  s.reference,
+		motions:    newMotionTracker(1),
+		aircraft:   make(map[uint64]*sortieAircraft),
+		aerodromes: make(map[uint64]*tacview.ObjectState),
+		launchers:  make(map[uint64]uint64),
+	}
+
+	world, err := reconstruct(s.concurrency, reader, processor.process)
+	if err != nil {
+		return err
+	}
+
+	for _, aircraft := range processor.aircraft {
+		processor.finish(aircraft, world.Offset, false)
+	}
+
+	s.sorties = append(s.sorties, processor.sorties...)
+	return nil
+}
+
+// sortieAircraft tracks the flight state of a single aircraft
+type sortieAircraft struct {
+	state          *tacview.ObjectState
+	initialized    bool
+	airborne       bool
+	groundAltitude float64
+	destroyed      bool
+	ejected        bool
+	current        *Sortie
+}
+
+type recentWeapon struct {
+	offset    float64
+	transform tacview.Transform
+	launcher  uint64
+}
+
+type sortieProcessor struct {
+	header        *tacview.Header
+	reference     *PositionReference
+	world         *tacview.World
+	motions       *motionTracker
+	aircraft      map[uint64]*sortieAircraft
+	aerodromes    map[uint64]*tacview.ObjectState
+	launchers     map[uint64]uint64
+	recentWeapons []recentWeapon
+	sorties       []*Sortie
+}
+
+func (p *sortieProcessor) process(world *tacview.World, tf *tacview.TimeFrame) error {
+	p.world = world
+	p.motions.update(world, tf)
+
+	for _, state := range world.Created {
+		object := state.Object
+		if object.HasTags("Aerodrome") {
+			p.aerodromes[state.Id()] = state
+		} else if isAircraft(object) {
+			p.aircraft[state.Id()] = &sortieAircraft{state: state}
+		} else if object.HasTags("Weapon") {
+			p.recordLaunch(state)
+		} else if object.HasTags("Parachutist") {
+			p.recordEjection(state)
+		}
+	}
+
+	for _, event := range world.Events {
+		for _, id := range event.Objects {
+			aircraft, ok := p.aircraft[id]
+			if !ok {
+				continue
+			}
+
+			switch event.Type {
+			case "TakenOff":
+				if !aircraft.airborne {
+					p.takeOff(aircraft, tf.Offset)
+				}
+			case "Landed":
+				if aircraft.airborne {
+					p.land(aircraft, tf.Offset)
+				}
+			case "Destroyed":
+				aircraft.destroyed = true
+			}
+		}
+	}
+
+	for _, object := range tf.Objects {
+		if aircraft, ok := p.aircraft[object.Id]; ok && !object.Deleted {
+			p.step(aircraft, tf.Offset)
+		}
+	}
+
+	// Weapons are recorded first so they are considered for aircraft removed
+	//  within the same time frame
+	for _, state := range world.Removed {
+		if state.Object.HasTags("Weapon") {
+			p.recentWeapons = append(p.recentWeapons, recentWeapon{
+				offset:    tf.Offset,
+				transform: state.Transform,
+				launcher:  p.launchers[state.Id()],
+			})
+			delete(p.launchers, state.Id())
+		}
+	}
+
+	for _, state := range world.Removed {
+		if aircraft, ok := p.aircraft[state.Id()]; ok && aircraft.state == state {
+			p.finish(aircraft, tf.Offset, true)
+			delete(p.aircraft, state.Id())
+		}
+	}
+
+	for len(p.recentWeapons) > 0 && tf.Offset-p.recentWeapons[0].offset > sortieShotDownSeconds {
+		p.recentWeapons = p.recentWeapons[1:]
+	}
+
+	return nil
+}
+
+func (p *sortieProcessor) recordLaunch(weapon *tacview.ObjectState) {
+	launcher := findLauncher(p.world, weapon, sortieLauncherRange)
+	if launcher == nil {
+		return
+	}
+	p.launchers[weapon.Id()] = launcher.Id()
+
+	aircraft, ok := p.aircraft[launcher.Id()]
+	if !ok || aircraft.current == nil {
+		return
+	}
+
+	name := "Unknown"
+	if property := weapon.Object.Get("Name"); property != nil {
+		name = property.Value
+	}
+	aircraft.current.Weapons[name]++
+}
+
+func (p *sortieProcessor) recordEjection(parachutist *tacview.ObjectState) {
+	if !parachutist.HasTransform {
+		return
+	}
+
+	var closest *sortieAircraft
+	closestDistance := sortieEjectionRange
+	for _, aircraft := range p.aircraft {
+		if !aircraft.state.HasTransform {
+			continue
+		}
+
+		distance := slantRange(parachutist.Transform, aircraft.state.Transform)
+		if distance <= closestDistance {
+			closest = aircraft
+			closestDistance = distance
+		}
+	}
+
+	if closest != nil {
+		closest.ejected = true
+	}
+}
+
+// nearestAerodrome returns the name and altitude of the closest aerodrome
+func (p *sortieProcessor) nearestAerodrome(transform tacview.Transform) (string, float64, bool) {
+	var closest *tacview.ObjectState
+	closestDistance := sortieAerodromeRange
+	for _, aerodrome := range p.aerodromes {
+		if !aerodrome.HasTransform {
+			continue
+		}
+
+		distance := groundDistance(transform, aerodrome.Transform)
+		if distance <= closestDistance {
+			closest = aerodrome
+			closestDistance = distance
+		}
+	}
+
+	if closest == nil {
+		return "", 0, false
+	}
+
+	name := ""
+	if property := closest.Object.Get("Name"); property != nil {
+		name = property.Value
+	}
+	return name, closest.Transform.Altitude, true
+}
+
+func (p *sortieProcessor) step(aircraft *sortieAircraft, offset float64) {
+	m := p.motions.get(aircraft.state.Id())
+	if m == nil {
+		return
+	}
+	altitude := aircraft.state.Transform.Altitude
+
+	if !aircraft.initialized {
+		aircraft.initialized = true
+		aircraft.groundAltitude = altitude
+		if m.groundSpeed > sortieTakeOffSpeed {
+			// Spawned in the air
+			aircraft.airborne = true
+			aircraft.current = p.newSortie(aircraft, aircraft.state.Spawned)
+			aircraft.current.airborneStart = &aircraft.current.BlockOut
+			return
+		}
+	}
+
+	if aircraft.airborne {
+		ground := aircraft.groundAltitude
+		if _, aerodromeAltitude, ok := p.nearestAerodrome(aircraft.state.Transform); ok {
+			ground = aerodromeAltitude
+		}
+
+		if m.groundSpeed < sortieLandedSpeed && altitude < ground+sortieLandedHeight {
+			p.land(aircraft, offset)
+		}
+		return
+	}
+
+	if aircraft.current == nil && m.groundSpeed > sortieTaxiSpeed {
+		aircraft.current = p.newSortie(aircraft, offset)
+	}
+
+	if (m.groundSpeed > sortieTakeOffSpeed && altitude > aircraft.groundAltitude+sortieTakeOffHeight) ||
+		altitude > aircraft.groundAltitude+sortieClimbHeight {
+		p.takeOff(aircraft, offset)
+		return
+	}
+
+	if m.groundSpeed < sortieTakeOffSpeed {
+		aircraft.groundAltitude = altitude
+	}
+
+	if aircraft.current != nil && aircraft.current.Landing != nil && m.groundSpeed < sortieStoppedSpeed {
+		p.closeSortie(aircraft, offset, SortieOutcomeLanded)
+	}
+}
+
+func (p *sortieProcessor) newSortie(aircraft *sortieAircraft, offset float64) *Sortie {
+	sortie := &Sortie{
+		ReferenceTime: p.header.ReferenceTime,
+		ObjectId:      aircraft.state.Id(),
+		BlockOut:      offset,
+		Weapons:       make(map[string]int),
+	}
+	sortie.Departure, _, _ = p.nearestAerodrome(aircraft.state.Transform)
+	return sortie
+}
+
+func (p *sortieProcessor) takeOff(aircraft *sortieAircraft, offset float64) {
+	// A takeoff after landing without stopping begins a new sortie
+	if aircraft.current != nil && aircraft.current.Landing != nil {
+		p.closeSortie(aircraft, offset, SortieOutcomeLanded)
+	}
+
+	if aircraft.current == nil {
+		aircraft.current = p.newSortie(aircraft, offset)
+	}
+
+	takeOff := offset
+	aircraft.current.TakeOff = &takeOff
+	aircraft.current.airborneStart = &takeOff
+	aircraft.current.Departure, _, _ = p.nearestAerodrome(aircraft.state.Transform)
+	aircraft.airborne = true
+}
+
+func (p *sortieProcessor) land(aircraft *sortieAircraft, offset float64) {
+	aircraft.airborne = false
+	aircraft.groundAltitude = aircraft.state.Transform.Altitude
+	if aircraft.current == nil {
+		return
+	}
+
+	landing := offset
+	aircraft.current.Landing = &landing
+	aircraft.current.Arrival, _, _ = p.nearestAerodrome(aircraft.state.Transform)
+}
+
+// hostileWeaponNear returns whether a weapon not launched by the aircraft was
+//  recently close to it
+func (p *sortieProcessor) hostileWeaponNear(aircraft *sortieAircraft) bool {
+	for _, weapon := range p.recentWeapons {
+		if weapon.launcher != aircraft.state.Id() &&
+			slantRange(weapon.transform, aircraft.state.Transform) <= sortieShotDownRange {
+			return true
+		}
+	}
+
+	for _, state := range p.world.Objects {
+		if !state.HasTransform || !state.Object.HasTags("Weapon") || p.launchers[state.Id()] == aircraft.state.Id() {
+			continue
+		}
+
+		if slantRange(state.Transform, aircraft.state.Transform) <= sortieShotDownRange {
+			return true
+		}
+	}
+	return false
+}
+
+// finish closes the current sortie of an aircraft when it is removed or the
+//  recording ends
+func (p *sortieProcessor) finish(aircraft *sortieAircraft, offset float64, removed bool) {
+	if aircraft.current == nil {
+		return
+	}
+
+	landed := aircraft.current.Landing != nil && !aircraft.airborne
+
+	var outcome string
+	if aircraft.ejected {
+		outcome = SortieOutcomeEjected
+	} else if landed {
+		outcome = SortieOutcomeLanded
+	} else if !removed {
+		outcome = SortieOutcomeMissionEnded
+	} else if aircraft.airborne && p.hostileWeaponNear(aircraft) {
+		outcome = SortieOutcomeShotDown
+	} else if aircraft.destroyed || (aircraft.airborne && aircraft.state.Transform.Altitude < aircraft.groundAltitude+sortieClimbHeight) {
+		outcome = SortieOutcomeCrashed
+	} else {
+		outcome = SortieOutcomeDespawned
+	}
+
+	p.closeSortie(aircraft, offset, outcome)
+}
+
+func (p *sortieProcessor) closeSortie(aircraft *sortieAircraft, offset float64, outcome string) {
+	sortie := aircraft.current
+	aircraft.current = nil
+
+	// Aircraft which only taxied are not considered to have flown a sortie
+	if sortie.airborneStart == nil {
+		return
+	}
+
+	sortie.BlockIn = offset
+	sortie.BlockDuration = sortie.BlockIn - sortie.BlockOut
+	sortie.Outcome = outcome
+
+	airborneEnd := offset
+	if sortie.Landing != nil {
+		airborneEnd = *sortie.Landing
+	}
+	sortie.AirborneDuration = airborneEnd - *sortie.airborneStart
+
+	if property := aircraft.state.Object.Get("Pilot"); property != nil {
+		sortie.Pilot = property.Value
+	}
+	if property := aircraft.state.Object.Get("Name"); property != nil {
+		sortie.Airframe = property.Value
+	}
+	if aircraft.state.HasTransform {
+		sortie.Bullseye, sortie.BRAA = p.reference.describe(p.world, aircraft.state.Transform, aircraft.state,
+			p.motions.get(aircraft.state.Id()))
+	}
+
+	p.sorties = append(p.sorties, sortie)
+}
+
+// SortiesOptions configures Sorties
+type SortiesOptions struct {
+	Options
+	// Pilots only reports sorties flown by the pilots with the given names when set
+	Pilots []string
+	// Reference describes where each sortie ended relative to a bullseye or
+	//  aircraft when set
+	Reference *PositionReference
+}
+
+// Sorties returns every sortie flown across the inputs, ordered by block out time
+func Sorties(ctx context.Context, inputs []io.Reader, options SortiesOptions) ([]*Sortie, error) {
+	options.Options = options.Options.withDefaults()
+
+	tracker := NewSortieTracker(options.Concurrency, options.Reference)
+	err := processInputs(ctx, options.Options, inputs, tracker)
+	if err != nil {
+		return nil, err
+	}
+
+	sorties := make([]*Sortie, 0)
+	for _, sortie := range tracker.Sorties() {
+		if matchesPilot(options.Pilots, sortie.Pilot) {
+			sorties = append(sorties, sortie)
+		}
+	}
+	return sorties, nil
+}
@@ -0,0 +1,143 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// describeSortie formats a sortie as
+//  `id pilot departure-arrival block_out/takeoff/landing/block_in airborne outcome weapons`
+func describeSortie(sortie *Sortie) string {
+	optional := func(value *float64) string {
+		if value == nil {
+			return "-"
+		}
+		return fmt.Sprint(*value)
+	}
+
+	pilot := sortie.Pilot
+	if pilot == "" {
+		pilot = "-"
+	}
+	result := fmt.Sprintf("%v %v %v-%v %v/%v/%v/%v %v %v",
+		sortie.ObjectId, pilot, sortie.Departure, sortie.Arrival,
+		sortie.BlockOut, optional(sortie.TakeOff), optional(sortie.Landing), sortie.BlockIn,
+		sortie.AirborneDuration, sortie.Outcome,
+	)
+
+	weapons := make([]string, 0, len(sortie.Weapons))
+	for name, count := range sortie.Weapons {
+		weapons = append(weapons, fmt.Sprintf("%v=%v", name, count))
+	}
+	sort.Strings(weapons)
+	return strings.TrimSpace(result + " " + strings.Join(weapons, " "))
+}
+
+func TestSorties(t *testing.T) {
+	aerodromes := []string{
+		"10,T=0|0|10,Type=Ground+Static+Aerodrome,Name=Batumi",
+		"11,T=0|0.3|20,Type=Ground+Static+Aerodrome,Name=Kobuleti",
+	}
+
+	cases := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name: "ground start landed",
+			input: []string{
+				"#0", "1,T=0|0|10,Type=Air+FixedWing,Name=F-16C,Pilot=Tracer",
+				// Taxiing begins the sortie
+				"#10", "1,T=0|0.0005|10",
+				"#20", "1,T=0|0.005|10",
+				"#30", "1,T=0|0.01|200",
+				"#40", "1,T=0|0.1|2000",
+				"#50", "2,T=0|0.1|2000,Type=Weapon+Missile,Name=AIM-9X,Parent=1",
+				"#60", "-2",
+				"#100", "1,T=0|0.3|10",
+				"#110", "1,T=0|0.3005|10",
+				// Stopping ends the sortie
+				"#120", "1,T=0|0.3005|10",
+			},
+			expected: []string{"1 Tracer Batumi-Kobuleti 10/30/110/120 80 landed AIM-9X=1"},
+		},
+		{
+			name: "taxi only",
+			input: []string{
+				"#0", "1,T=0|0|10,Type=Air+FixedWing,Name=F-16C",
+				"#10", "1,T=0|0.0005|10",
+				"#20", "1,T=0|0.0005|10",
+				"#30", "-1",
+			},
+			expected: []string{},
+		},
+		{
+			name: "takeoff and landing events",
+			input: []string{
+				"#0", "8,T=0|0|10,Type=Air+Rotorcraft,Name=UH-1H",
+				"#10", "8,T=0|0.0003|12",
+				"#20", "8,T=0|0.0006|60", "0,Event=TakenOff|8|",
+				"#30", "8,T=0|0.0009|70",
+				"#40", "8,T=0|0.0012|60", "0,Event=Landed|8|",
+				"#50", "8,T=0|0.0012|60",
+			},
+			expected: []string{"8 - Batumi-Batumi 10/20/40/50 20 landed"},
+		},
+		{
+			name: "air start shot down and mission ended",
+			input: []string{
+				"#0", "3,T=1|1|5000,Type=Air+FixedWing,Name=Su-27,Pilot=Hawk", "1,T=1|2|5000,Type=Air+FixedWing,Name=F-16C,Pilot=Tracer",
+				"#10", "3,T=1|1.01|5000", "1,T=1|2.01|5000",
+				"#20", "4,T=1|1.0102|5000,Type=Weapon+Missile,Name=AIM-120C,Parent=1",
+				"#30", "-4", "-3",
+				"#40", "1,T=1|2.02|5000",
+			},
+			expected: []string{
+				"3 Hawk - 0/-/-/30 30 shot down",
+				"1 Tracer - 0/-/-/40 40 mission ended AIM-120C=1",
+			},
+		},
+		{
+			name: "ejected",
+			input: []string{
+				"#0", "5,T=1|1|3000,Type=Air+FixedWing,Name=A-10C",
+				"#10", "5,T=1|1.01|3000",
+				"#20", "6,T=1|1.0101|2990,Type=Parachutist",
+				"#30", "-5",
+			},
+			expected: []string{"5 - - 0/-/-/30 30 ejected"},
+		},
+		{
+			name: "crashed",
+			input: []string{
+				"#0", "7,T=1|1|3000,Type=Air+FixedWing,Name=F-5E",
+				"#10", "7,T=1|1.01|1000",
+				"#20", "7,T=1|1.02|50", "0,Event=Destroyed|7|",
+				"#30", "-7",
+			},
+			expected: []string{"7 - - 0/-/-/30 30 crashed"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			input := opsTestData(append(append([]string{}, aerodromes...), c.input...)...)
+			sorties, err := Sorties(context.Background(), opsTestInputs(input), SortiesOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := make([]string, len(sorties))
+			for idx, sortie := range sorties {
+				found[idx] = describeSortie(sortie)
+			}
+			if strings.Join(found, "\n") != strings.Join(c.expected, "\n") {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", strings.Join(c.expected, "\n"), strings.Join(found, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// lockSlots are the object properties which reference a targeted object
+var lockSlots = []string{
+	"LockedTarget", "LockedTarget2", "LockedTarget3", "LockedTarget4", "LockedTarget5",
+	"LockedTarget6", "LockedTarget7", "LockedTarget8", "LockedTarget9", "FocusedTarget",
+}
+
+// Lock describes a single period in which an object held a target in one of
+//  its lock slots. Times are offsets in seconds.
+type Lock struct {
+	ReferenceTime   time.Time `json:"reference_time"`
+	Slot            string    `json:"slot"`
+	SourceId        uint64    `json:"source_id"`
+	Source          string    `json:"source"`
+	SourcePilot     string    `json:"source_pilot"`
+	SourceCoalition string    `json:"source_coalition"`
+	TargetId        uint64    `json:"target_id"`
+	Target          string    `json:"target"`
+	TargetPilot     string    `json:"target_pilot"`
+	TargetCoalition string    `json:"target_coalition"`
+	RadarMode       string    `json:"radar_mode"`
+	Start           float64   `json:"start"`
+	End             float64   `json:"end"`
+	Duration        float64   `json:"duration"`
+	// Shots contains the ids of the weapons launched by the source while the
+	//  lock was held
+	Shots           []uint64 `json:"shots"`
+	TargetDestroyed bool     `json:"target_destroyed"`
+	// Bullseye and BRAA describe the position of the target when the lock was
+	//  acquired, if a position reference is configured
+	Bullseye *BullseyeCall `json:"bullseye,omitempty"`
+	BRAA     *BRAACall     `json:"braa,omitempty"`
+}
+
+// ShotPreceded returns whether a weapon was launched while the lock was held
+func (l *Lock) ShotPreceded() bool {
+	return len(l.Shots) > 0
+}
+
+// TargetingAnalyzer builds a timeline of every lock held within recordings
+type TargetingAnalyzer struct {
+	concurrency int
+	reference   *PositionReference
+	locks       []*Lock
+}
+
+// NewTargetingAnalyzer creates a new TargetingAnalyzer. The target of each lock
+//  is described relative to the reference when it isn't nil.
+func NewTargetingAnalyzer(concurrency int, reference *PositionReference) *TargetingAnalyzer {
+	return &TargetingAnalyzer{concurrency: concurrency, reference: reference, locks: make([]*Lock, 0)}
+}
+
+// Locks returns every lock seen, ordered by start time
+func (t *TargetingAnalyzer) Locks() []*Lock {
+	sort.SliceStable(t.locks, func(i, j int) bool {
+		a := OffsetTime(t.locks[i].ReferenceTime, t.locks[i].Start)
+		return a.Before(OffsetTime(t.locks[j].ReferenceTime, t.locks[j].Start))
+	})
+	return t.locks
+}
+
+// heldLock tracks a lock while it is held, and afterwards until the weapons
+//  launched during it have landed
+type heldLock struct {
+	lock *Lock
+	// Offset until which a destruction of the target is attributed to the lock
+	killUntil float64
+}
+
+type lockKey struct {
+	source uint64
+	slot   string
+}
+
+type targetingProcessor struct {
+	header    *tacview.Header
+	reference *PositionReference
+	held      map[lockKey]*heldLock
+	finished  []*heldLock
+	inFlight  map[uint64][]*heldLock
+	destroyed map[uint64][]float64
+}
+
+// ProcessFile records every lock within the file
+func (t *TargetingAnalyzer) ProcessFile(reader *tacview.Reader) error {
+	processor := &targetingProcessor{
+		header:    &reader.Header,
+		reference: t.reference,
+		held:      make(map[lockKey]*heldLock),
+		finished:  make([]*heldLock, 0),
+		inFlight:  make(map[uint64][]*heldLock),
+		destroyed: make(map[uint64][]float64),
+	}
+
+	world, err := reconstruct(t.concurrency, reader, processor.process)
+	if err != nil {
+		return err
+	}
+
+	for key, held := range processor.held {
+		processor.release(key, held, world.Offset)
+	}
+	for id := range processor.inFlight {
+		processor.land(id, world.Offset)
+	}
+
+	for _, held := range processor.finished {
+		for _, destroyed := range processor.destroyed[held.lock.TargetId] {
+			if destroyed >= held.lock.Start && destroyed <= held.killUntil {
+				held.lock.TargetDestroyed = true
+				break
+			}
+		}
+		t.locks = append(t.locks, held.lock)
+	}
+	return nil
+}
+
+// lockedTarget returns the id of the object referenced by a lock slot (if any)
+func lockedTarget(world *tacview.World, object *tacview.Object, slot string) uint64 {
+	property := object.Get(slot)
+	if property == nil || property.Value == "" {
+		return 0
+	}
+
+	id, err := strconv.ParseUint(property.Value, 16, 64)
+	if err != nil || id == 0 || world.Get(id) == nil {
+		return 0
+	}
+	return id
+}
+
+func (p *targetingProcessor) process(world *tacview.World, tf *tacview.TimeFrame) error {
+	for _, event := range world.Events {
+		if event.Type != "Destroyed" {
+			continue
+		}
+
+		for _, id := range event.Objects {
+			p.destroyed[id] = append(p.destroyed[id], tf.Offset)
+		}
+	}
+
+	for _, state := range world.Created {
+		if state.Object.HasTags("Weapon") {
+			p.launch(world, state)
+		}
+	}
+
+	for _, object := range tf.Objects {
+		state := world.Get(object.Id)
+		if state == nil {
+			continue
+		}
+
+		for _, slot := range lockSlots {
+			key := lockKey{source: object.Id, slot: slot}
+			target := lockedTarget(world, state.Object, slot)
+
+			held, ok := p.held[key]
+			if ok && held.lock.TargetId == target {
+				continue
+			}
+			if ok {
+				p.release(key, held, tf.Offset)
+			}
+			if target != 0 {
+				p.acquire(world, state, key, target, tf.Offset)
+			}
+		}
+	}
+
+	// Locks end when either the source or the target is removed
+	for _, state := range world.Removed {
+		if _, ok := p.inFlight[state.Id()]; ok {
+			p.land(state.Id(), tf.Offset)
+		}
+
+		for key, held := range p.held {
+			if key.source == state.Id() || held.lock.TargetId == state.Id() {
+				p.release(key, held, tf.Offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *targetingProcessor) acquire(world *tacview.World, source *tacview.ObjectState, key lockKey, target uint64, offset float64) {
+	lock := &Lock{
+		ReferenceTime:   p.header.ReferenceTime,
+		Slot:            key.slot,
+		SourceId:        source.Id(),
+		SourceCoalition: coalition(source.Object),
+		TargetId:        target,
+		TargetCoalition: coalition(world.Get(target).Object),
+		Start:           offset,
+		Shots:           make([]uint64, 0),
+	}
+
+	if property := source.Object.Get("Name"); property != nil {
+		lock.Source = property.Value
+	}
+	if property := source.Object.Get("Pilot"); property != nil {
+		lock.SourcePilot = property.Value
+	}
+	if property := source.Object.Get("RadarMode"); property != nil {
+		lock.RadarMode = property.Value
+	}
+	if property := world.Get(target).Object.Get("Name"); property != nil {
+		lock.Target = property.Value
+	}
+	if property := world.Get(target).Object.Get("Pilot"); property != nil {
+		lock.TargetPilot = property.Value
+	}
+	if state := world.Get(target); state.HasTransform {
+		lock.Bullseye, lock.BRAA = p.reference.describe(world, state.Transform, state, nil)
+	}
+
+	p.held[key] = &heldLock{lock: lock}
+}
+
+func (p *targetingProcessor) release(key lockKey, held *heldLock, offset float64) {
+	delete(p.held, key)
+	held.lock.End = offset
+	held.lock.Duration = offset - held.lock.Start
+	held.killUntil = math.Max(held.killUntil, offset)
+	p.finished = append(p.finished, held)
+}
+
+// launch attributes a weapon to every lock held by its launcher
+func (p *targetingProcessor) launch(world *tacview.World, weapon *tacview.ObjectState) {
+	launcher := findLauncher(world, weapon, shotLauncherRange)
+	if launcher == nil {
+		return
+	}
+
+	for key, held := range p.held {
+		if key.source != launcher.Id() {
+			continue
+		}
+
+		held.lock.Shots = append(held.lock.Shots, weapon.Id())
+		p.inFlight[weapon.Id()] = append(p.inFlight[weapon.Id()], held)
+	}
+}
+
+// land extends the kill window of every lock the weapon was launched under
+func (p *targetingProcessor) land(weapon uint64, offset float64) {
+	for _, held := range p.inFlight[weapon] {
+		held.killUntil = math.Max(held.killUntil, offset+shotDestroyedGrace)
+	}
+	delete(p.inFlight, weapon)
+}
+
+// TargetingNode is an object within a TargetingGraph
+type TargetingNode struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Pilot     string `json:"pilot"`
+	Coalition string `json:"coalition"`
+}
+
+// TargetingEdge aggregates every lock held by one object on another
+type TargetingEdge struct {
+	Source   string  `json:"source"`
+	Target   string  `json:"target"`
+	Locks    int     `json:"locks"`
+	Duration float64 `json:"duration"`
+	Shots    int     `json:"shots"`
+	Kill     bool    `json:"kill"`
+}
+
+// TargetingGraph is a directed graph of which objects locked which
+type TargetingGraph struct {
+	Nodes []*TargetingNode `json:"nodes"`
+	Edges []*TargetingEdge `json:"edges"`
+}
+
+// NewTargetingGraph builds a graph from a set of locks. Objects are identified
+//  by their hex id, prefixed with the recording's reference time when locks
+//  from several recordings are combined. Only the part of each lock within the
+//  optional from and until times counts towards the duration of an edge.
+func NewTargetingGraph(locks []*Lock, from Time, until Time) (*TargetingGraph, error) {
+	recordings := make(map[time.Time]bool)
+	for _, lock := range locks {
+		recordings[lock.ReferenceTime] = true
+	}
+
+	nodeId := func(reference time.Time, id uint64) string {
+		if len(recordings) > 1 {
+			return fmt.Sprintf("%v/%x", reference.Format(time.RFC3339), id)
+		}
+		return fmt.Sprintf("%x", id)
+	}
+
+	graph := &TargetingGraph{Nodes: make([]*TargetingNode, 0), Edges: make([]*TargetingEdge, 0)}
+	nodes := make(map[string]*TargetingNode)
+	edges := make(map[[2]string]*TargetingEdge)
+	addNode := func(id, name, pilot, coalition string) {
+		if _, ok := nodes[id]; ok {
+			return
+		}
+		nodes[id] = &TargetingNode{Id: id, Name: name, Pilot: pilot, Coalition: coalition}
+		graph.Nodes = append(graph.Nodes, nodes[id])
+	}
+
+	for _, lock := range locks {
+		start, end, err := lockWindow(lock, from, until)
+		if err != nil {
+			return nil, err
+		}
+
+		source := nodeId(lock.ReferenceTime, lock.SourceId)
+		target := nodeId(lock.ReferenceTime, lock.TargetId)
+		addNode(source, lock.Source, lock.SourcePilot, lock.SourceCoalition)
+		addNode(target, lock.Target, lock.TargetPilot, lock.TargetCoalition)
+
+		edge, ok := edges[[2]string{source, target}]
+		if !ok {
+			edge = &TargetingEdge{Source: source, Target: target}
+			edges[[2]string{source, target}] = edge
+			graph.Edges = append(graph.Edges, edge)
+		}
+
+		edge.Locks++
+		edge.Duration += math.Max(0, math.Min(lock.End, end)-math.Max(lock.Start, start))
+		edge.Shots += len(lock.Shots)
+		edge.Kill = edge.Kill || lock.TargetDestroyed
+	}
+
+	return graph, nil
+}
+
+var dotCoalitionColors = map[string]string{
+	"allies":  "blue",
+	"enemies": "red",
+}
+
+// WriteDOT writes the graph in the graphviz DOT format
+func (g *TargetingGraph) WriteDOT(writer io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("digraph targeting {\n")
+
+	for _, node := range g.Nodes {
+		label := node.Name
+		if node.Pilot != "" {
+			label = fmt.Sprintf("%v (%v)", node.Pilot, node.Name)
+		}
+
+		color, ok := dotCoalitionColors[strings.ToLower(node.Coalition)]
+		if !ok {
+			color = "black"
+		}
+		fmt.Fprintf(&builder, "  %q [label=%q, color=%v];\n", node.Id, label, color)
+	}
+
+	for _, edge := range g.Edges {
+		label := fmt.Sprintf("%v locks, %v", edge.Locks, FormatSeconds(edge.Duration))
+		if edge.Shots > 0 {
+			label += fmt.Sprintf(", %v shots", edge.Shots)
+		}
+
+		style := "solid"
+		if edge.Kill {
+			style = "bold"
+			label += ", kill"
+		}
+		fmt.Fprintf(&builder, "  %q -> %q [label=%q, style=%v];\n", edge.Source, edge.Target, label, style)
+	}
+
+	builder.WriteString("}\n")
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// lockWindow returns the offsets of the optional from and until times within
+//  the recording of a lock, unbounded when not set
+func lockWindow(lock *Lock, from Time, until Time) (float64, float64, error) {
+	header := &tacview.Header{ReferenceTime: lock.ReferenceTime}
+	start, end := math.Inf(-1), math.Inf(1)
+	if from != "" {
+		offset, err := from.Offset(header)
+		if err != nil {
+			return 0, 0, err
+		}
+		start = offset
+	}
+
+	if until != "" {
+		offset, err := until.Offset(header)
+		if err != nil {
+			return 0, 0, err
+		}
+		end = offset
+	}
+	return start, end, nil
+}
+
+// lockWithin returns whether a lock was held at any point between the optional
+//  from and until times
+func lockWithin(lock *Lock, from Time, until Time) (bool, error) {
+	start, end, err := lockWindow(lock, from, until)
+	if err != nil {
+		return false, err
+	}
+	return lock.End >= start && lock.Start <= end, nil
+}
+
+// TargetingOptions configures Targeting
+type TargetingOptions struct {
+	Options
+	// From and Until only report locks held within the time window when set
+	From  Time
+	Until Time
+	// Pilots only reports locks held by or on the pilots with the given names
+	//  when set
+	Pilots []string
+	// Reference describes the target of each lock relative to a bullseye or
+	//  aircraft when set
+	Reference *PositionReference
+}
+
+// Targeting returns every radar lock held across the inputs, ordered by start
+//  time
+func Targeting(ctx context.Context, inputs []io.Reader, options TargetingOptions) ([]*Lock, error) {
+	options.Options = options.Options.withDefaults()
+
+	analyzer := NewTargetingAnalyzer(options.Concurrency, options.Reference)
+	err := processInputs(ctx, options.Options, inputs, analyzer)
+	if err != nil {
+		return nil, err
+	}
+
+	locks := make([]*Lock, 0)
+	for _, lock := range analyzer.Locks() {
+		within, err := lockWithin(lock, options.From, options.Until)
+		if err != nil {
+			return nil, err
+		}
+
+		if within && matchesPilot(options.Pilots, lock.SourcePilot, lock.TargetPilot) {
+			locks = append(locks, lock)
+		}
+	}
+	return locks, nil
+}
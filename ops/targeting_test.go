@@ -0,0 +1,151 @@
+package ops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var targetingTestData = opsTestData(
+	"#0",
+	"1,T=0|0|5000,Type=Air+FixedWing,Name=F-16C,Pilot=Hawk,Coalition=Allies",
+	"2,T=0|0.5|5000,Type=Air+FixedWing,Name=MiG-29,Pilot=Ivan,Coalition=Enemies",
+	"3,T=0.1|0.5|5000,Type=Air+FixedWing,Name=Su-27,Pilot=Boris,Coalition=Enemies",
+	"#10", "1,LockedTarget=2,RadarMode=1",
+	"#15", "2,LockedTarget=1",
+	"#20", "4,T=0|0.001|5000,Type=Weapon+Missile,Name=AIM-120C,Parent=1",
+	// Switching targets releases the first lock
+	"#30", "1,LockedTarget=3",
+	"#40", "-4", "0,Event=Destroyed|2|",
+	"#41", "-2",
+	"#50", "1,LockedTarget=",
+	"#60", "1,FocusedTarget=3",
+	"#70", "3,T=0.1|0.5|5000",
+)
+
+func TestTargeting(t *testing.T) {
+	cases := []struct {
+		name     string
+		options  TargetingOptions
+		expected []string
+	}{
+		{
+			name: "every lock",
+			expected: []string{
+				"LockedTarget 1>2 10-30 mode=1 shots=[4] destroyed=true",
+				"LockedTarget 2>1 15-41 mode= shots=[] destroyed=false",
+				"LockedTarget 1>3 30-50 mode=1 shots=[] destroyed=false",
+				"FocusedTarget 1>3 60-70 mode=1 shots=[] destroyed=false",
+			},
+		},
+		{
+			name:    "window",
+			options: TargetingOptions{From: "35", Until: "04:00:55"},
+			expected: []string{
+				"LockedTarget 2>1 15-41 mode= shots=[] destroyed=false",
+				"LockedTarget 1>3 30-50 mode=1 shots=[] destroyed=false",
+			},
+		},
+		{
+			name:    "pilot",
+			options: TargetingOptions{Pilots: []string{"Ivan"}},
+			expected: []string{
+				"LockedTarget 1>2 10-30 mode=1 shots=[4] destroyed=true",
+				"LockedTarget 2>1 15-41 mode= shots=[] destroyed=false",
+			},
+		},
+		{
+			// Targets are described from the reference aircraft when locked
+			name:    "braa",
+			options: TargetingOptions{Pilots: []string{"Boris"}, Reference: &PositionReference{braaPilot: "Hawk"}},
+			expected: []string{
+				"LockedTarget 1>3 30-50 mode=1 shots=[] destroyed=false braa 011/30, 16404ft, drag",
+				"FocusedTarget 1>3 60-70 mode=1 shots=[] destroyed=false braa 011/30, 16404ft, drag",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			locks, err := Targeting(context.Background(), opsTestInputs(targetingTestData), c.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := make([]string, len(locks))
+			for idx, lock := range locks {
+				found[idx] = fmt.Sprintf("%v %v>%v %v-%v mode=%v shots=%v destroyed=%v", lock.Slot, lock.SourceId,
+					lock.TargetId, lock.Start, lock.End, lock.RadarMode, lock.Shots, lock.TargetDestroyed)
+				if lock.BRAA != nil {
+					found[idx] += fmt.Sprintf(" %v", lock.BRAA)
+				}
+			}
+			if strings.Join(found, "\n") != strings.Join(c.expected, "\n") {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", strings.Join(c.expected, "\n"), strings.Join(found, "\n"))
+			}
+		})
+	}
+}
+
+func TestTargetingGraph(t *testing.T) {
+	cases := []struct {
+		name     string
+		options  TargetingOptions
+		expected []string
+	}{
+		{
+			name: "every lock",
+			expected: []string{
+				"digraph targeting {",
+				`  "1" [label="Hawk (F-16C)", color=blue];`,
+				`  "2" [label="Ivan (MiG-29)", color=red];`,
+				`  "3" [label="Boris (Su-27)", color=red];`,
+				`  "1" -> "2" [label="1 locks, 20s, 1 shots, kill", style=bold];`,
+				`  "2" -> "1" [label="1 locks, 26s", style=solid];`,
+				`  "1" -> "3" [label="2 locks, 30s", style=solid];`,
+				"}",
+			},
+		},
+		{
+			// Only the time within the window counts towards the duration
+			name:    "window",
+			options: TargetingOptions{From: "35", Until: "04:00:55"},
+			expected: []string{
+				"digraph targeting {",
+				`  "2" [label="Ivan (MiG-29)", color=red];`,
+				`  "1" [label="Hawk (F-16C)", color=blue];`,
+				`  "3" [label="Boris (Su-27)", color=red];`,
+				`  "2" -> "1" [label="1 locks, 6s", style=solid];`,
+				`  "1" -> "3" [label="1 locks, 15s", style=solid];`,
+				"}",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			locks, err := Targeting(context.Background(), opsTestInputs(targetingTestData), c.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			graph, err := NewTargetingGraph(locks, c.options.From, c.options.Until)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output bytes.Buffer
+			err = graph.WriteDOT(&output)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := strings.Join(c.expected, "\n") + "\n"
+			if output.String() != expected {
+				t.Fatalf("Expected:\n%v\nfound:\n%v", expected, output.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package ops
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// TrimOptions configures Trim
+type TrimOptions struct {
+	Options
+	// Start and End are the times the output starts and ends at, defaulting to
+	//  the start and end of the recording when unset
+	Start Time
+	End   Time
+	// Region only keeps objects while they are within the region when set
+	Region Region
+}
+
+// headerRawReader returns an already read header to the trimming functions
+type headerRawReader struct {
+	tacview.RawReader
+	header *tacview.Header
+}
+
+func (r *headerRawReader) ReadHeader() (*tacview.Header, error) {
+	return r.header, nil
+}
+
+// Trim copies the ACMI input between the start and end times to the output
+//  without decoding the objects of time frames
+func Trim(ctx context.Context, input io.Reader, output io.Writer, options TrimOptions) error {
+	options.Options = options.Options.withDefaults()
+
+	parser, err := tacview.NewParser(input)
+	if err != nil {
+		return err
+	}
+	options.begin(0, parser)
+
+	header, err := parser.ReadHeader()
+	if err != nil {
+		return err
+	}
+
+	start, end := 0.0, math.Inf(1)
+	if options.Start != "" {
+		start, err = options.Start.Offset(header)
+		if err != nil {
+			return err
+		}
+	}
+	if options.End != "" {
+		end, err = options.End.Offset(header)
+		if err != nil {
+			return err
+		}
+	}
+
+	reader := &headerRawReader{RawReader: parser, header: header}
+	if options.Region != nil {
+		return tacview.TrimRawFilteredContext(ctx, reader, tacview.NewRawWriter(output), start, end, func(header *tacview.Header) tacview.FrameFilter {
+			return newRegionFilter(options.Region, header)
+		})
+	}
+	return tacview.TrimRawContext(ctx, reader, tacview.NewRawWriter(output), start, end)
+}
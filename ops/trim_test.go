@@ -0,0 +1,86 @@
+package ops
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTrim(t *testing.T) {
+	input := opsTestData(
+		"#1", "1,T=0|0|100,Name=A",
+		"#2", "1,T=1|1|", "2,T=5|5|100,Name=B",
+		"#3", "-1",
+		"#4", "2,T=6|6|",
+	)
+
+	cases := []struct {
+		name     string
+		start    Time
+		end      Time
+		expected []string
+	}{
+		{
+			name:  "offsets",
+			start: "1.5",
+			end:   "3",
+			expected: []string{
+				"0,ReferenceTime=2021-07-24T04:00:01.5Z", "1,T=0|0|100,Name=A",
+				"#0.5", "1,T=1|1|", "2,T=5|5|100,Name=B",
+				"#1.5", "-1",
+			},
+		},
+		{
+			name:  "clock times",
+			start: "04:00:02",
+			end:   "2021-07-24T04:00:03Z",
+			expected: []string{
+				"0,ReferenceTime=2021-07-24T04:00:02Z", "1,T=0|0|100,Name=A",
+				"#0", "1,T=1|1|", "2,T=5|5|100,Name=B",
+				"#1", "-1",
+			},
+		},
+		{
+			name:  "until the end",
+			start: "3",
+			expected: []string{
+				"0,ReferenceTime=2021-07-24T04:00:03Z", "1,T=1|1|100,Name=A", "2,T=5|5|100,Name=B",
+				"#0", "-1",
+				"#1", "2,T=6|6|",
+			},
+		},
+		{
+			name: "unset",
+			expected: []string{
+				"0,ReferenceTime=2021-07-24T04:00:00Z",
+				"#1", "1,T=0|0|100,Name=A",
+				"#2", "1,T=1|1|", "2,T=5|5|100,Name=B",
+				"#3", "-1",
+				"#4", "2,T=6|6|",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Trim(context.Background(), strings.NewReader(input), &output, TrimOptions{Start: c.start, End: c.end})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := strings.Join(c.expected, "\n")
+			trimmed := shortenOffsets(strings.TrimPrefix(output.String(), "\ufeff"+opsTestHeader))
+			if trimmed != expected {
+				t.Fatalf("Expected:\n%v\nwrote:\n%v", expected, trimmed)
+			}
+		})
+	}
+
+	var output bytes.Buffer
+	err := Trim(context.Background(), strings.NewReader(input), &output, TrimOptions{Start: "soon"})
+	if err == nil {
+		t.Fatal("Expected an error for an invalid start time")
+	}
+}
@@ -0,0 +1,126 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// parseOffsetTime parses a point in time within a recording and returns it as an
+//  offset from the header reference time. Supported formats are an offset in
+//  seconds, an RFC3339 timestamp, or a UTC clock time (15:04 or 15:04:05) on the
+//  day of the recording.
+func parseOffsetTime(value string, header *tacview.Header) (float64, error) {
+	offset, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		return offset, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return timestamp.Sub(header.ReferenceTime).Seconds(), nil
+	}
+
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		clock, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+
+		reference := header.ReferenceTime.UTC()
+		timestamp := time.Date(
+			reference.Year(), reference.Month(), reference.Day(),
+			clock.Hour(), clock.Minute(), clock.Second(), 0, time.UTC,
+		)
+
+		// Clock times before the reference time belong to the following day
+		if timestamp.Before(reference) {
+			timestamp = timestamp.Add(time.Hour * 24)
+		}
+		return timestamp.Sub(reference).Seconds(), nil
+	}
+
+	return 0, fmt.Errorf("Failed to parse time '%v'", value)
+}
+
+// Time is a point in time within a recording given as an offset in seconds, an
+//  RFC3339 timestamp or a UTC clock time (15:04 or 15:04:05) on the day of the
+//  recording. The empty Time is unset.
+type Time string
+
+// Offset returns the time as an offset from the header reference time
+func (t Time) Offset(header *tacview.Header) (float64, error) {
+	return parseOffsetTime(string(t), header)
+}
+
+// OffsetTime returns the absolute time of an offset from a reference time
+func OffsetTime(reference time.Time, offset float64) time.Time {
+	return reference.Add(time.Duration(offset * float64(time.Second)))
+}
+
+// FormatOffset formats an offset within a recording as `timestamp (offset)`
+func FormatOffset(header *tacview.Header, offset float64) string {
+	date := OffsetTime(header.ReferenceTime, offset)
+	return fmt.Sprintf("%v (%v)", date.Format(time.RFC3339), offset)
+}
+
+// processTimeFrames calls fn with every time frame from the reader in order. When
+//  concurrency is larger than one time frames are parsed in parallel while still
+//  being processed in file order.
+func processTimeFrames(concurrency int, reader *tacview.Reader, fn func(*tacview.TimeFrame) error) error {
+	return processTimeFramesContext(context.Background(), concurrency, reader, fn)
+}
+
+// processTimeFramesContext processes time frames like processTimeFrames until the
+//  context is done.
+func processTimeFramesContext(ctx context.Context, concurrency int, reader *tacview.Reader, fn func(*tacview.TimeFrame) error) error {
+	done := make(chan error, 1)
+	timeFrames := make(chan *tacview.TimeFrame)
+
+	go func() {
+		defer close(done)
+
+		var err error
+		for tf := range timeFrames {
+			// After an error we keep draining so the reader can finish
+			if err != nil {
+				continue
+			}
+			err = fn(tf)
+		}
+
+		done <- err
+	}()
+
+	err := reader.ProcessTimeFramesContext(ctx, tacview.ProcessOptions{Concurrency: concurrency, Ordered: true}, timeFrames)
+	if err != nil {
+		return err
+	}
+
+	return <-done
+}
+
+// reconstruct applies the header's initial time frame and then every time frame
+//  from the reader to a World, calling fn after each time frame is applied.
+func reconstruct(concurrency int, reader *tacview.Reader, fn func(*tacview.World, *tacview.TimeFrame) error) (*tacview.World, error) {
+	world := tacview.NewWorld(&reader.Header)
+
+	apply := func(tf *tacview.TimeFrame) error {
+		err := world.Apply(tf)
+		if err != nil {
+			return err
+		}
+		return fn(world, tf)
+	}
+
+	initialTimeFrame := reader.Header.InitialTimeFrame
+	err := apply(&initialTimeFrame)
+	if err != nil {
+		return nil, err
+	}
+
+	return world, processTimeFrames(concurrency, reader, apply)
+}
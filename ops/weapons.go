@@ -0,0 +1,55 @@
+package ops
+
+import (
+	"strconv"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// findLauncher returns the object which launched a weapon. The weapons `Parent`
+//  property is used when present, otherwise the closest air, ground or sea
+//  object within maxDistance meters of the weapon is assumed to be the launcher.
+func findLauncher(world *tacview.World, weapon *tacview.ObjectState, maxDistance float64) *tacview.ObjectState {
+	if parent := weapon.Object.Get("Parent"); parent != nil {
+		id, err := strconv.ParseUint(parent.Value, 16, 64)
+		if err == nil {
+			if launcher := world.Get(id); launcher != nil {
+				return launcher
+			}
+		}
+	}
+
+	if !weapon.HasTransform {
+		return nil
+	}
+
+	var closest *tacview.ObjectState
+	closestDistance := maxDistance
+	for _, state := range world.Objects {
+		if state == weapon || !state.HasTransform || !isPlatform(state.Object) {
+			continue
+		}
+
+		distance := slantRange(weapon.Transform, state.Transform)
+		if distance <= closestDistance {
+			closest = state
+			closestDistance = distance
+		}
+	}
+	return closest
+}
+
+// isPlatform returns whether the object is a vehicle capable of launching weapons
+func isPlatform(object *tacview.Object) bool {
+	for _, tag := range []string{"Weapon", "Projectile", "Misc", "Static", "Parachutist", "Navaid"} {
+		if object.HasTags(tag) {
+			return false
+		}
+	}
+	return object.HasTags("Air") || object.HasTags("Ground") || object.HasTags("Sea")
+}
+
+// isAircraft returns whether the object is a fixed or rotary wing aircraft
+func isAircraft(object *tacview.Object) bool {
+	return object.HasTags("Air", "FixedWing") || object.HasTags("Air", "Rotorcraft")
+}
@@ -0,0 +1,110 @@
+package jambon
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// progressInterval is how often the progress bar is redrawn
+const progressInterval = time.Millisecond * 200
+
+const progressBarWidth = 30
+
+// progressBar renders the progress of reading an ACMI file as a single line on a
+//  terminal. A nil progressBar ignores all updates.
+type progressBar struct {
+	output io.Writer
+	// total is the size of the input in bytes, or zero when unknown
+	total int64
+}
+
+// newProgressBar creates a progress bar for an input of the given size, returning
+//  nil when stderr is not a terminal
+func newProgressBar(total int64) *progressBar {
+	stat, err := os.Stderr.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressBar{output: os.Stderr, total: total}
+}
+
+// tacViewSize returns the uncompressed size of an ACMI file, or zero if unknown
+func tacViewSize(path string) int64 {
+	if strings.HasSuffix(path, ".zip.acmi") {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return 0
+		}
+		defer reader.Close()
+
+		if len(reader.File) != 1 {
+			return 0
+		}
+		return int64(reader.File[0].UncompressedSize64)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return stat.Size()
+}
+
+func (b *progressBar) update(progress tacview.Progress) {
+	if b == nil {
+		return
+	}
+
+	status := fmt.Sprintf("%v read, %v/s, at %v", formatBytes(float64(progress.BytesRead)),
+		formatBytes(progress.Throughput), ops.FormatSeconds(progress.Offset))
+
+	if b.total <= 0 {
+		fmt.Fprintf(b.output, "\r%v\x1b[K", status)
+		return
+	}
+
+	fraction := float64(progress.BytesRead) / float64(b.total)
+	if fraction > 1 || progress.Done {
+		fraction = 1
+	}
+
+	filled := int(fraction * progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+
+	eta := "--"
+	if progress.Throughput > 0 {
+		remaining := float64(b.total-progress.BytesRead) / progress.Throughput
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta = ops.FormatSeconds(remaining)
+	}
+
+	fmt.Fprintf(b.output, "\r[%v] %3.0f%% %v, ETA %v\x1b[K", bar, fraction*100, status, eta)
+}
+
+// finish completes the progress bar line
+func (b *progressBar) finish() {
+	if b == nil {
+		return
+	}
+	fmt.Fprintln(b.output)
+}
+
+// formatBytes formats a byte count using binary units
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	unit := 0
+	for bytes >= 1024 && unit < len(units)-1 {
+		bytes /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %v", bytes, units[unit])
+}
@@ -0,0 +1,48 @@
+package jambon
+
+import (
+	"fmt"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/urfave/cli/v2"
+)
+
+var regionFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "region-bbox",
+		Usage: "only keep objects within a bounding box provided as `minLat,minLon,maxLat,maxLon`",
+	},
+	&cli.StringFlag{
+		Name:  "region-circle",
+		Usage: "only keep objects within a circle provided as `lat,lon,radius` (radius supports nm, km, ft and m units)",
+	},
+	&cli.PathFlag{
+		Name:  "region-geojson",
+		Usage: "only keep objects within the polygons of a GeoJSON file",
+	},
+}
+
+// regionFromContext returns the region (if one was provided) from the region flags
+func regionFromContext(ctx *cli.Context) (ops.Region, error) {
+	var result ops.Region
+	var err error
+	count := 0
+
+	if ctx.IsSet("region-bbox") {
+		count++
+		result, err = ops.ParseBoundingBoxRegion(ctx.String("region-bbox"))
+	}
+	if ctx.IsSet("region-circle") {
+		count++
+		result, err = ops.ParseCircleRegion(ctx.String("region-circle"))
+	}
+	if ctx.IsSet("region-geojson") {
+		count++
+		result, err = ops.LoadGeoJSONRegion(ctx.Path("region-geojson"))
+	}
+
+	if count > 1 {
+		return nil, fmt.Errorf("Only one region may be provided")
+	}
+	return result, err
+}
@@ -2,6 +2,7 @@ package tacview
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"hash/crc32"
@@ -38,26 +39,52 @@ func hashPassword64(password string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
-/// Creates a new Reader from a TacView Real Time server
+// / Creates a new Reader from a TacView Real Time server
 func NewRealTimeReader(connStr string, username string, password string) (*Reader, error) {
-	reader, err := newRealTimeReaderHash(connStr, username, password, hashPassword64)
+	return NewRealTimeReaderContext(context.Background(), connStr, username, password)
+}
+
+// NewRealTimeReaderContext creates a new Reader from a TacView Real Time server.
+//  The connection is closed once the context is done, which stops any reads in
+//  progress.
+func NewRealTimeReaderContext(ctx context.Context, connStr string, username string, password string) (*Reader, error) {
+	reader, err := newRealTimeReaderHash(ctx, connStr, username, password, hashPassword64)
 
 	if err == io.EOF && password != "" {
-		reader, err = newRealTimeReaderHash(connStr, username, password, hashPassword32)
+		reader, err = newRealTimeReaderHash(ctx, connStr, username, password, hashPassword32)
 		if err == io.EOF {
 			err = errors.New("EOF (possible incorrect password)")
 		}
 	}
 
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
 	return reader, err
 }
 
-func newRealTimeReaderHash(connStr string, username string, password string, hashFunc func(string) string) (*Reader, error) {
-	conn, err := net.Dial("tcp", connStr)
+func newRealTimeReaderHash(ctx context.Context, connStr string, username string, password string, hashFunc func(string) string) (*Reader, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", connStr)
 	if err != nil {
 		return nil, err
 	}
 
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+	}
+
+	reader, err := readRealTimeHandshake(conn, username, password, hashFunc)
+	if err != nil {
+		conn.Close()
+	}
+	return reader, err
+}
+
+func readRealTimeHandshake(conn net.Conn, username string, password string, hashFunc func(string) string) (*Reader, error) {
 	reader := bufio.NewReader(conn)
 
 	headerProtocol, err := reader.ReadString('\n')
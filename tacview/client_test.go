@@ -1,6 +1,10 @@
 package tacview
 
 import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
 	"testing"
 )
 
@@ -35,3 +39,42 @@ func TestHashPassword64(t *testing.T) {
 	testHash64("abc123",   "2bd464b05d7103f1", t)
 	testHash64("12345",    "6b40207b495297f4", t)
 }
+
+func TestRealTimeReaderCancel(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("XtraLib.Stream.0\nTacview.RealTimeTelemetry.0\nhost\n\x00"))
+		conn.Write([]byte(parserTestHeader + parserTestGlobal + "#1\n1,T=1|2|3\n#2\n"))
+		// Keep the connection open without sending anything else
+		io.Copy(ioutil.Discard, conn)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, err := NewRealTimeReaderContext(ctx, listener.Addr().String(), "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeFrames := make(chan *TimeFrame)
+	go func() {
+		for range timeFrames {
+			cancel()
+		}
+	}()
+
+	err = reader.ProcessTimeFramesContext(ctx, ProcessOptions{Concurrency: 1}, timeFrames)
+	if err != context.Canceled {
+		t.Fatalf("Expected the context to be canceled, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package tacview
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+// encoder serializes ACMI text by appending to a reusable buffer
+type encoder struct {
+	buffer []byte
+}
+
+// encoders holds encoders for the standalone Write and Serialize methods
+var encoders = sync.Pool{
+	New: func() interface{} {
+		return &encoder{buffer: make([]byte, 0, 4096)}
+	},
+}
+
+// getEncoder returns an empty encoder from the pool, which should be returned
+//  with encoders.Put once done
+func getEncoder() *encoder {
+	e := encoders.Get().(*encoder)
+	e.reset()
+	return e
+}
+
+// appendOffset appends a time frame offset line
+func appendOffset(dst []byte, offset float64) []byte {
+	dst = append(dst, '#')
+	dst = strconv.AppendFloat(dst, offset, 'f', 6, 64)
+	return append(dst, '\n')
+}
+
+// appendEscaped appends a property value, escaping line breaks and commas
+func appendEscaped(dst []byte, value string) []byte {
+	start := 0
+	for idx := 0; idx < len(value); idx++ {
+		c := value[idx]
+		if c != '\n' && c != ',' {
+			continue
+		}
+
+		dst = append(dst, value[start:idx]...)
+		dst = append(dst, '\\', c)
+		start = idx + 1
+	}
+	return append(dst, value[start:]...)
+}
+
+// appendObject appends an object line without its line break
+func appendObject(dst []byte, object *Object) []byte {
+	if object.Deleted {
+		dst = append(dst, '-')
+		return strconv.AppendUint(dst, object.Id, 16)
+	}
+
+	dst = strconv.AppendUint(dst, object.Id, 16)
+	if len(object.Properties) == 0 {
+		return append(dst, ',')
+	}
+
+	for _, property := range object.Properties {
+		dst = append(dst, ',')
+		dst = append(dst, property.Key...)
+		dst = append(dst, '=')
+		dst = appendEscaped(dst, property.Value)
+	}
+	return dst
+}
+
+func (e *encoder) reset() {
+	e.buffer = e.buffer[:0]
+}
+
+func (e *encoder) header(fileType string, fileVersion string) {
+	e.buffer = append(e.buffer, "FileType="...)
+	e.buffer = append(e.buffer, fileType...)
+	e.buffer = append(e.buffer, "\nFileVersion="...)
+	e.buffer = append(e.buffer, fileVersion...)
+	e.buffer = append(e.buffer, '\n')
+}
+
+func (e *encoder) timeFrame(tf *TimeFrame, includeOffset bool) {
+	if includeOffset {
+		e.buffer = appendOffset(e.buffer, tf.Offset)
+	}
+
+	for _, object := range tf.Objects {
+		e.buffer = appendObject(e.buffer, object)
+		e.buffer = append(e.buffer, '\n')
+	}
+}
+
+func (e *encoder) rawTimeFrame(tf *RawTimeFrame, includeOffset bool) {
+	if includeOffset {
+		e.buffer = appendOffset(e.buffer, tf.Offset)
+	}
+
+	for idx, line := range tf.Contents {
+		if idx > 0 {
+			e.buffer = append(e.buffer, '\n')
+		}
+		e.buffer = append(e.buffer, line...)
+	}
+	e.buffer = append(e.buffer, '\n')
+}
+
+// flush writes the encoded contents and resets the buffer
+func (e *encoder) flush(writer io.Writer) error {
+	_, err := writer.Write(e.buffer)
+	e.reset()
+	return err
+}
@@ -0,0 +1,105 @@
+package tacview
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func encoderTestTimeFrame() *TimeFrame {
+	tf := NewTimeFrame(
+		NewObject(0x1a,
+			Property{Key: "T", Value: "1|2|3"},
+			Property{Key: "Name", Value: "A, B"},
+			Property{Key: "Comments", Value: "First\nSecond"},
+		),
+		NewObject(0x2b),
+		&Object{Id: 0x3c, Deleted: true},
+	)
+	tf.Offset = 12.5
+	return tf
+}
+
+const encoderTestOutput = "#12.500000\n" +
+	"1a,T=1|2|3,Name=A\\, B,Comments=First\\\nSecond\n" +
+	"2b,\n" +
+	"-3c\n"
+
+func TestTimeFrameWrite(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := bufio.NewWriter(&buffer)
+
+	err := encoderTestTimeFrame().Write(writer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+
+	if buffer.String() != encoderTestOutput {
+		t.Fatalf("Output mismatch; expected %q, wrote %q.", encoderTestOutput, buffer.String())
+	}
+}
+
+func TestWritersMatch(t *testing.T) {
+	header := &Header{
+		FileType:         "text/acmi/tacview",
+		FileVersion:      "2.2",
+		InitialTimeFrame: *NewTimeFrame(NewObject(0, Property{Key: "ReferenceTime", Value: "2021-07-24T04:00:00Z"})),
+	}
+
+	parsed := nopCloser{&bytes.Buffer{}}
+	writer, err := NewWriter(parsed, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.WriteTimeFrame(encoderTestTimeFrame())
+	writer.Close()
+
+	raw := &bytes.Buffer{}
+	rawWriter := NewRawWriter(raw)
+	rawWriter.WriteHeader(header)
+	rawWriter.Write(encoderTestTimeFrame().ToRaw())
+
+	if parsed.String() != raw.String() {
+		t.Fatalf("Writer output %q does not match raw writer output %q.", parsed.String(), raw.String())
+	}
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	tf := encoderTestTimeFrame()
+	raw := tf.ToRaw()
+
+	parsed, err := raw.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for idx, object := range tf.Objects {
+		if serialized := parsed.Objects[idx].Serialize(); serialized != raw.Contents[idx] {
+			t.Fatalf("Round trip mismatch; expected %q, serialized %q.", raw.Contents[idx], serialized)
+		}
+		if object.Get("Comments") != nil && parsed.Objects[idx].Get("Comments").Value != "First\nSecond" {
+			t.Fatalf("Escaped value was not restored: %q", parsed.Objects[idx].Get("Comments").Value)
+		}
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	tf := encoderTestTimeFrame()
+	writer := &Writer{writer: bufio.NewWriter(&bytes.Buffer{})}
+
+	for i := 0; i < b.N; i++ {
+		writer.WriteTimeFrame(tf)
+		if i%1000 == 0 {
+			writer.writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// formatDMSComponent formats an angle as degrees, minutes and tenths of seconds
+func formatDMSComponent(value float64, positive, negative string, degreeDigits int) string {
+	hemisphere := positive
+	if value < 0 {
+		hemisphere = negative
+	}
+
+	// Round before splitting so 59.96 seconds carries into the minutes
+	tenths := int64(math.Round(math.Abs(value) * 36000))
+	degrees := tenths / 36000
+	minutes := (tenths % 36000) / 600
+	seconds := float64(tenths%600) / 10
+
+	return fmt.Sprintf("%s%0*d°%02d'%04.1f\"", hemisphere, degreeDigits, degrees, minutes, seconds)
+}
+
+// FormatDMS formats the latitude and longitude of a point as degrees, minutes
+//  and seconds, e.g. `N42°10'48.0" E042°29'24.0"`
+func FormatDMS(p Point) string {
+	return fmt.Sprintf(
+		"%s %s",
+		formatDMSComponent(p.Latitude, "N", "S", 2),
+		formatDMSComponent(p.Longitude, "E", "W", 3),
+	)
+}
+
+// UTM describes a position on the universal transverse mercator grid
+type UTM struct {
+	Zone     int
+	Band     byte
+	Easting  float64
+	Northing float64
+}
+
+const (
+	utmScale         = 0.9996
+	utmFalseEasting  = 500000.0
+	utmFalseNorthing = 10000000.0
+	utmBands         = "CDEFGHJKLMNPQRSTUVWXX"
+)
+
+// utmZone returns the UTM zone for a point, including the exceptions around
+//  Norway and Svalbard
+func utmZone(latitude, longitude float64) int {
+	zone := int(math.Floor((longitude+180)/6)) + 1
+	if zone > 60 {
+		zone = 1
+	}
+
+	if latitude >= 56 && latitude < 64 && longitude >= 3 && longitude < 12 {
+		return 32
+	}
+
+	if latitude >= 72 && latitude < 84 && longitude >= 0 && longitude < 42 {
+		switch {
+		case longitude < 9:
+			return 31
+		case longitude < 21:
+			return 33
+		case longitude < 33:
+			return 35
+		default:
+			return 37
+		}
+	}
+
+	return zone
+}
+
+// ToUTM converts a point into UTM coordinates. Points outside of the UTM grid
+//  (beyond 80°S and 84°N) return an error.
+func ToUTM(p Point) (UTM, error) {
+	if p.Latitude < -80 || p.Latitude >= 84 {
+		return UTM{}, fmt.Errorf("Latitude %v is outside of the UTM grid", p.Latitude)
+	}
+
+	longitude := math.Mod(p.Longitude+540, 360) - 180
+	zone := utmZone(p.Latitude, longitude)
+	centralMeridian := float64((zone-1)*6 - 180 + 3)
+
+	e2 := eccentricitySquared
+	e4 := e2 * e2
+	e6 := e4 * e2
+	ep2 := e2 / (1 - e2)
+
+	phi := toRadians(p.Latitude)
+	sinPhi, cosPhi := math.Sincos(phi)
+	tanPhi := math.Tan(phi)
+
+	N := SemiMajorAxis / math.Sqrt(1-e2*sinPhi*sinPhi)
+	T := tanPhi * tanPhi
+	C := ep2 * cosPhi * cosPhi
+	A := cosPhi * toRadians(longitude-centralMeridian)
+	M := SemiMajorAxis * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+
+	easting := utmScale*N*(A+(1-T+C)*math.Pow(A, 3)/6+
+		(5-18*T+T*T+72*C-58*ep2)*math.Pow(A, 5)/120) + utmFalseEasting
+	northing := utmScale * (M + N*tanPhi*(A*A/2+(5-T+9*C+4*C*C)*math.Pow(A, 4)/24+
+		(61-58*T+T*T+600*C-330*ep2)*math.Pow(A, 6)/720))
+	if p.Latitude < 0 {
+		northing += utmFalseNorthing
+	}
+
+	return UTM{
+		Zone:     zone,
+		Band:     utmBands[int(math.Floor((p.Latitude+80)/8))],
+		Easting:  easting,
+		Northing: northing,
+	}, nil
+}
+
+var (
+	mgrsColumnLetters = []string{"STUVWXYZ", "ABCDEFGH", "JKLMNPQR"}
+	mgrsRowLetters    = "ABCDEFGHJKLMNPQRSTUV"
+)
+
+// FormatMGRS formats a point as a military grid reference with the given
+//  number of digits (1 to 5) of easting and northing precision, e.g.
+//  `37T GG 12345 67890`
+func FormatMGRS(p Point, precision int) (string, error) {
+	if precision < 1 || precision > 5 {
+		return "", fmt.Errorf("MGRS precision must be between 1 and 5, found %v", precision)
+	}
+
+	utm, err := ToUTM(p)
+	if err != nil {
+		return "", err
+	}
+
+	column := int(math.Floor(utm.Easting/100000)) - 1
+	row := int(math.Floor(utm.Northing/100000)) % 20
+	if utm.Zone%2 == 0 {
+		row = (row + 5) % 20
+	}
+
+	columns := mgrsColumnLetters[utm.Zone%3]
+	if column < 0 || column >= len(columns) {
+		return "", fmt.Errorf("Easting %v is outside of UTM zone %v", utm.Easting, utm.Zone)
+	}
+
+	divisor := math.Pow(10, float64(5-precision))
+	easting := int(math.Floor(math.Mod(utm.Easting, 100000) / divisor))
+	northing := int(math.Floor(math.Mod(utm.Northing, 100000) / divisor))
+
+	return fmt.Sprintf(
+		"%d%c %c%c %0*d %0*d",
+		utm.Zone,
+		utm.Band,
+		columns[column],
+		mgrsRowLetters[row],
+		precision,
+		easting,
+		precision,
+		northing,
+	), nil
+}
@@ -0,0 +1,213 @@
+// Package geo provides geodesic calculations on the WGS-84 ellipsoid for
+//  coordinates found within ACMI files.
+package geo
+
+import (
+	"math"
+)
+
+// WGS-84 ellipsoid parameters
+const (
+	SemiMajorAxis = 6378137.0
+	Flattening    = 1 / 298.257223563
+	SemiMinorAxis = SemiMajorAxis * (1 - Flattening)
+	// MeanRadius is the mean radius of the earth used for spherical fallbacks
+	MeanRadius = 6371008.8
+)
+
+var eccentricitySquared = Flattening * (2 - Flattening)
+
+// Point is a geodetic position. Latitude and longitude are in degrees and
+//  altitude is in meters above the ellipsoid.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// ECEF is an earth-centered, earth-fixed cartesian position in meters
+type ECEF struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// ENU is a position in meters east, north and up of an origin point
+type ENU struct {
+	East  float64
+	North float64
+	Up    float64
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+func normalizeBearing(degrees float64) float64 {
+	return math.Mod(degrees+360, 360)
+}
+
+// Inverse solves the inverse geodesic problem between two points using
+//  Vincenty's formulae, returning the distance along the ellipsoid in meters
+//  and the initial and final true bearings in degrees. Nearly antipodal points
+//  for which the formulae do not converge fall back to a spherical solution.
+func Inverse(a, b Point) (float64, float64, float64) {
+	L := toRadians(b.Longitude - a.Longitude)
+	U1 := math.Atan((1 - Flattening) * math.Tan(toRadians(a.Latitude)))
+	U2 := math.Atan((1 - Flattening) * math.Tan(toRadians(b.Latitude)))
+	sinU1, cosU1 := math.Sincos(U1)
+	sinU2, cosU2 := math.Sincos(U2)
+
+	lambda := L
+	var sinLambda, cosLambda, sinSigma, cosSigma, sigma, cosSquaredAlpha, cos2SigmaM float64
+	converged := false
+	for iteration := 0; iteration < 200; iteration++ {
+		sinLambda, cosLambda = math.Sincos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0, 0, 0
+		}
+
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSquaredAlpha = 1 - sinAlpha*sinAlpha
+
+		cos2SigmaM = 0
+		if cosSquaredAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSquaredAlpha
+		}
+
+		C := Flattening / 16 * cosSquaredAlpha * (4 + Flattening*(4-3*cosSquaredAlpha))
+		previous := lambda
+		lambda = L + (1-C)*Flattening*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) < 1e-12 {
+			converged = true
+			break
+		}
+	}
+
+	if !converged {
+		bearing := sphericalBearing(a, b)
+		return sphericalDistance(a, b), bearing, normalizeBearing(sphericalBearing(b, a) + 180)
+	}
+
+	uSquared := cosSquaredAlpha * (SemiMajorAxis*SemiMajorAxis - SemiMinorAxis*SemiMinorAxis) / (SemiMinorAxis * SemiMinorAxis)
+	A := 1 + uSquared/16384*(4096+uSquared*(-768+uSquared*(320-175*uSquared)))
+	B := uSquared / 1024 * (256 + uSquared*(-128+uSquared*(74-47*uSquared)))
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+	distance := SemiMinorAxis * A * (sigma - deltaSigma)
+	initial := toDegrees(math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda))
+	final := toDegrees(math.Atan2(cosU1*sinLambda, -sinU1*cosU2+cosU1*sinU2*cosLambda))
+	return distance, normalizeBearing(initial), normalizeBearing(final)
+}
+
+// Distance returns the distance in meters along the surface of the ellipsoid
+//  between two points, ignoring altitude
+func Distance(a, b Point) float64 {
+	distance, _, _ := Inverse(a, b)
+	return distance
+}
+
+// Bearing returns the initial true bearing in degrees from one point to another
+func Bearing(a, b Point) float64 {
+	_, bearing, _ := Inverse(a, b)
+	return bearing
+}
+
+// SlantRange returns the straight line distance in meters between two points
+func SlantRange(a, b Point) float64 {
+	first, second := ToECEF(a), ToECEF(b)
+	return math.Sqrt(math.Pow(second.X-first.X, 2) + math.Pow(second.Y-first.Y, 2) + math.Pow(second.Z-first.Z, 2))
+}
+
+func sphericalDistance(a, b Point) float64 {
+	phiA, phiB := toRadians(a.Latitude), toRadians(b.Latitude)
+	deltaPhi := toRadians(b.Latitude - a.Latitude)
+	deltaLambda := toRadians(b.Longitude - a.Longitude)
+
+	h := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phiA)*math.Cos(phiB)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * MeanRadius * math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+}
+
+func sphericalBearing(a, b Point) float64 {
+	phiA, phiB := toRadians(a.Latitude), toRadians(b.Latitude)
+	deltaLambda := toRadians(b.Longitude - a.Longitude)
+
+	y := math.Sin(deltaLambda) * math.Cos(phiB)
+	x := math.Cos(phiA)*math.Sin(phiB) - math.Sin(phiA)*math.Cos(phiB)*math.Cos(deltaLambda)
+	return normalizeBearing(toDegrees(math.Atan2(y, x)))
+}
+
+// ToECEF converts a geodetic point into earth-centered, earth-fixed coordinates
+func ToECEF(p Point) ECEF {
+	sinPhi, cosPhi := math.Sincos(toRadians(p.Latitude))
+	sinLambda, cosLambda := math.Sincos(toRadians(p.Longitude))
+	N := SemiMajorAxis / math.Sqrt(1-eccentricitySquared*sinPhi*sinPhi)
+
+	return ECEF{
+		X: (N + p.Altitude) * cosPhi * cosLambda,
+		Y: (N + p.Altitude) * cosPhi * sinLambda,
+		Z: (N*(1-eccentricitySquared) + p.Altitude) * sinPhi,
+	}
+}
+
+// FromECEF converts earth-centered, earth-fixed coordinates into a geodetic point
+func FromECEF(e ECEF) Point {
+	p := math.Hypot(e.X, e.Y)
+	longitude := math.Atan2(e.Y, e.X)
+	latitude := math.Atan2(e.Z, p*(1-eccentricitySquared))
+
+	var altitude float64
+	for iteration := 0; iteration < 10; iteration++ {
+		sinPhi, cosPhi := math.Sincos(latitude)
+		N := SemiMajorAxis / math.Sqrt(1-eccentricitySquared*sinPhi*sinPhi)
+		altitude = p*cosPhi + (e.Z+eccentricitySquared*N*sinPhi)*sinPhi - N
+
+		next := math.Atan2(e.Z, p*(1-eccentricitySquared*N/(N+altitude)))
+		if math.Abs(next-latitude) < 1e-14 {
+			latitude = next
+			break
+		}
+		latitude = next
+	}
+
+	return Point{Latitude: toDegrees(latitude), Longitude: toDegrees(longitude), Altitude: altitude}
+}
+
+// ToENU returns the position of a point in the local east, north, up frame of
+//  the origin
+func ToENU(origin, p Point) ENU {
+	from, to := ToECEF(origin), ToECEF(p)
+	dx, dy, dz := to.X-from.X, to.Y-from.Y, to.Z-from.Z
+
+	sinPhi, cosPhi := math.Sincos(toRadians(origin.Latitude))
+	sinLambda, cosLambda := math.Sincos(toRadians(origin.Longitude))
+
+	return ENU{
+		East:  -sinLambda*dx + cosLambda*dy,
+		North: -sinPhi*cosLambda*dx - sinPhi*sinLambda*dy + cosPhi*dz,
+		Up:    cosPhi*cosLambda*dx + cosPhi*sinLambda*dy + sinPhi*dz,
+	}
+}
+
+// FromENU returns the geodetic point for a position in the local east, north,
+//  up frame of the origin
+func FromENU(origin Point, e ENU) Point {
+	sinPhi, cosPhi := math.Sincos(toRadians(origin.Latitude))
+	sinLambda, cosLambda := math.Sincos(toRadians(origin.Longitude))
+
+	from := ToECEF(origin)
+	return FromECEF(ECEF{
+		X: from.X - sinLambda*e.East - sinPhi*cosLambda*e.North + cosPhi*cosLambda*e.Up,
+		Y: from.Y + cosLambda*e.East - sinPhi*sinLambda*e.North + cosPhi*sinLambda*e.Up,
+		Z: from.Z + cosPhi*e.North + sinPhi*e.Up,
+	})
+}
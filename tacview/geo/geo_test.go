@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+func testClose(name string, value, expected, tolerance float64, t *testing.T) {
+	if math.Abs(value-expected) > tolerance {
+		t.Fatalf("%s mismatch; expected %v, calculated %v.", name, expected, value)
+	}
+}
+
+func TestInverse(t *testing.T) {
+	// Flinders Peak to Buninyong, the reference example from Vincenty's paper
+	flindersPeak := Point{Latitude: -(37 + 57/60.0 + 3.72030/3600), Longitude: 144 + 25/60.0 + 29.52440/3600}
+	buninyong := Point{Latitude: -(37 + 39/60.0 + 10.15610/3600), Longitude: 143 + 55/60.0 + 35.38390/3600}
+
+	distance, initial, final := Inverse(flindersPeak, buninyong)
+	testClose("Distance", distance, 54972.271, 0.001, t)
+	testClose("Initial bearing", initial, 306+52/60.0+5.37/3600, 1e-5, t)
+	testClose("Final bearing", final, 307+10/60.0+25.07/3600, 1e-5, t)
+
+	testClose("Coincident distance", Distance(buninyong, buninyong), 0, 0, t)
+	// Nearly antipodal points fall back to a spherical approximation
+	testClose("Antipodal distance", Distance(Point{0, 0, 0}, Point{0.5, 179.7, 0}), 19936288.579, 20000, t)
+}
+
+func TestSlantRange(t *testing.T) {
+	testClose("Vertical", SlantRange(Point{42, 42, 0}, Point{42, 42, 1000}), 1000, 1e-6, t)
+	testClose("Horizontal", SlantRange(Point{0, 0, 0}, Point{0, 0.001, 0}), 111.319, 0.001, t)
+}
+
+func TestECEF(t *testing.T) {
+	ecef := ToECEF(Point{0, 0, 0})
+	testClose("Equator X", ecef.X, SemiMajorAxis, 1e-6, t)
+	testClose("Pole Z", ToECEF(Point{90, 0, 0}).Z, SemiMinorAxis, 1e-6, t)
+
+	point := Point{Latitude: 42.18, Longitude: -122.49, Altitude: 3048}
+	roundTrip := FromECEF(ToECEF(point))
+	testClose("Latitude", roundTrip.Latitude, point.Latitude, 1e-9, t)
+	testClose("Longitude", roundTrip.Longitude, point.Longitude, 1e-9, t)
+	testClose("Altitude", roundTrip.Altitude, point.Altitude, 1e-6, t)
+}
+
+func TestENU(t *testing.T) {
+	origin := Point{Latitude: 42.18, Longitude: 42.49, Altitude: 100}
+
+	north := ToENU(origin, Point{Latitude: 42.19, Longitude: 42.49, Altitude: 100})
+	testClose("East", north.East, 0, 1e-6, t)
+	if north.North < 1100 || north.North > 1115 {
+		t.Fatalf("North mismatch; calculated %v.", north.North)
+	}
+
+	enu := ENU{East: 1500, North: -2500, Up: 300}
+	roundTrip := ToENU(origin, FromENU(origin, enu))
+	testClose("East", roundTrip.East, enu.East, 1e-6, t)
+	testClose("North", roundTrip.North, enu.North, 1e-6, t)
+	testClose("Up", roundTrip.Up, enu.Up, 1e-6, t)
+}
+
+func testMGRS(p Point, precision int, expected string, t *testing.T) {
+	mgrs, err := FormatMGRS(p, precision)
+	if err != nil {
+		t.Fatalf("Failed to format %v: %v", p, err)
+	}
+	if mgrs != expected {
+		t.Fatalf("MGRS mismatch for %v; expected %s, calculated %s.", p, expected, mgrs)
+	}
+}
+
+func TestFormatMGRS(t *testing.T) {
+	testMGRS(Point{0, 0, 0}, 5, "31N AA 66021 00000", t)
+	testMGRS(Point{0, 0, 0}, 1, "31N AA 6 0", t)
+	testMGRS(Point{0, 6.5, 0}, 1, "32N KF 2 0", t)
+
+	_, err := FormatMGRS(Point{85, 0, 0}, 5)
+	if err == nil {
+		t.Fatalf("Expected an error formatting a polar point")
+	}
+}
+
+func TestFormatDMS(t *testing.T) {
+	tests := map[Point]string{
+		{Latitude: 42.18, Longitude: 42.49}:        "N42°10'48.0\" E042°29'24.0\"",
+		{Latitude: -33.8568, Longitude: -151.2153}: "S33°51'24.5\" W151°12'55.1\"",
+		{Latitude: 0.999999, Longitude: 0}:         "N01°00'00.0\" E000°00'00.0\"",
+	}
+
+	for point, expected := range tests {
+		if formatted := FormatDMS(point); formatted != expected {
+			t.Fatalf("DMS mismatch for %v; expected %s, calculated %s.", point, expected, formatted)
+		}
+	}
+}
+
+func TestObjectPosition(t *testing.T) {
+	header := &tacview.Header{ReferenceLongitude: 40, ReferenceLatitude: 41}
+
+	object := &tacview.Object{Id: 1}
+	object.Set("T", "1.5|0.25|1000|0|0|90")
+	position, ok, err := ObjectPosition(header, object)
+	if err != nil || !ok {
+		t.Fatalf("Failed to read object position: %v", err)
+	}
+	testClose("Longitude", position.Longitude, 41.5, 1e-9, t)
+	testClose("Latitude", position.Latitude, 41.25, 1e-9, t)
+	testClose("Altitude", position.Altitude, 1000, 1e-9, t)
+
+	object.Set("T", "||900")
+	if _, ok, _ := ObjectPosition(header, object); ok {
+		t.Fatalf("Expected a partial transform to have no position")
+	}
+}
@@ -0,0 +1,43 @@
+package geo
+
+import (
+	"strings"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// FromTransform returns the position of a decoded transform
+func FromTransform(t tacview.Transform) Point {
+	return Point{Latitude: t.Latitude, Longitude: t.Longitude, Altitude: t.Altitude}
+}
+
+// ParseTransform decodes a raw `T` property value into a transform with
+//  absolute coordinates using the header's reference point. Omitted components
+//  are left as zero.
+func ParseTransform(header *tacview.Header, value string) (tacview.Transform, error) {
+	var transform tacview.Transform
+	err := transform.Update(value, header.ReferenceLongitude, header.ReferenceLatitude)
+	return transform, err
+}
+
+// ObjectPosition returns the absolute position of an object from its raw `T`
+//  property. The boolean is false when the object has no transform or the
+//  transform omits its longitude, latitude or altitude (which happens when
+//  only some components changed in a time frame).
+func ObjectPosition(header *tacview.Header, object *tacview.Object) (Point, bool, error) {
+	property := object.Get("T")
+	if property == nil {
+		return Point{}, false, nil
+	}
+
+	parts := strings.SplitN(property.Value, "|", 4)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return Point{}, false, nil
+	}
+
+	transform, err := ParseTransform(header, property.Value)
+	if err != nil {
+		return Point{}, false, err
+	}
+	return FromTransform(transform), true, nil
+}
@@ -0,0 +1,179 @@
+package tacview
+
+import (
+	"io"
+)
+
+// Next reads and parses the next time frame, which is then available from Frame.
+//  The header is read first if it was not read yet. Next returns false once the
+//  input was read completely or an error occurred, which is available from Err.
+func (p *Parser) Next() bool {
+	if p.err != nil {
+		return false
+	}
+
+	if p.header == nil {
+		_, err := p.ReadHeader()
+		if err != nil {
+			p.err = err
+			return false
+		}
+	}
+
+	frame, err := p.ReadTimeFrame(-1)
+	if err != nil {
+		p.frame = nil
+		if err != io.EOF {
+			p.err = err
+		}
+		return false
+	}
+
+	p.frame = frame
+	return true
+}
+
+// Frame returns the time frame read by the last call to Next
+func (p *Parser) Frame() *TimeFrame {
+	return p.frame
+}
+
+// Err returns the error which stopped Next, or nil if the input was read completely
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// Header returns the header, or nil if it was not read yet
+func (p *Parser) Header() *Header {
+	return p.header
+}
+
+// Next reads and parses the next time frame in order, which is then available
+//  from Frame. Unlike ProcessTimeFrames no routines are started, so iteration can
+//  be stopped at any point. Next must not be mixed with ProcessTimeFrames.
+func (r *Reader) Next() bool {
+	return r.parser.Next()
+}
+
+// Frame returns the time frame read by the last call to Next
+func (r *Reader) Frame() *TimeFrame {
+	return r.parser.Frame()
+}
+
+// Err returns the error which stopped Next, or nil if the input was read completely
+func (r *Reader) Err() error {
+	return r.parser.Err()
+}
+
+// ObjectIterator walks the reconstructed state of every object updated by each
+//  time frame of a Reader, starting with the header's initial time frame. The
+//  global object is not included; its merged properties are available from the
+//  World.
+type ObjectIterator struct {
+	reader *Reader
+	world  *World
+
+	initial bool
+	frame   *TimeFrame
+	index   int
+	state   *ObjectState
+	removed bool
+	err     error
+}
+
+// NewObjectIterator creates an ObjectIterator reading time frames from the reader
+func NewObjectIterator(reader *Reader) *ObjectIterator {
+	return &ObjectIterator{
+		reader:  reader,
+		world:   NewWorld(&reader.Header),
+		initial: true,
+	}
+}
+
+// Next advances to the next object updated by the current time frame, reading
+//  time frames as required. Next returns false once the input was read completely
+//  or an error occurred, which is available from Err.
+func (i *ObjectIterator) Next() bool {
+	for {
+		if i.err != nil {
+			return false
+		}
+
+		if i.frame != nil && i.index < len(i.frame.Objects) {
+			object := i.frame.Objects[i.index]
+			i.index++
+
+			if object.Id == 0 {
+				continue
+			}
+
+			i.state, i.removed = i.world.Get(object.Id), false
+			if object.Deleted {
+				i.state, i.removed = i.removedState(object.Id), true
+			}
+			if i.state == nil {
+				continue
+			}
+			return true
+		}
+
+		if !i.nextFrame() {
+			return false
+		}
+	}
+}
+
+// nextFrame applies the next time frame to the world
+func (i *ObjectIterator) nextFrame() bool {
+	if i.initial {
+		i.initial = false
+		initialTimeFrame := i.reader.Header.InitialTimeFrame
+		i.frame = &initialTimeFrame
+	} else if i.reader.Next() {
+		i.frame = i.reader.Frame()
+	} else {
+		i.frame = nil
+		i.err = i.reader.Err()
+		return false
+	}
+
+	i.index = 0
+	i.err = i.world.Apply(i.frame)
+	return i.err == nil
+}
+
+// removedState returns the final state of an object removed by the current time frame
+func (i *ObjectIterator) removedState(id uint64) *ObjectState {
+	for _, state := range i.world.Removed {
+		if state.Id() == id {
+			return state
+		}
+	}
+	return nil
+}
+
+// Object returns the reconstructed state of the current object after the current
+//  time frame was applied. The state is updated in place by later time frames.
+func (i *ObjectIterator) Object() *ObjectState {
+	return i.state
+}
+
+// Removed returns whether the current object was removed by the current time frame
+func (i *ObjectIterator) Removed() bool {
+	return i.removed
+}
+
+// Offset returns the offset of the current time frame
+func (i *ObjectIterator) Offset() float64 {
+	return i.world.Offset
+}
+
+// World returns the world the time frames are applied to
+func (i *ObjectIterator) World() *World {
+	return i.world
+}
+
+// Err returns the error which stopped Next, or nil if the input was read completely
+func (i *ObjectIterator) Err() error {
+	return i.err
+}
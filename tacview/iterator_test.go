@@ -0,0 +1,92 @@
+package tacview
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReaderNext(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(processTestData(50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for reader.Next() {
+		count++
+		if reader.Frame().Offset != float64(count) {
+			t.Fatalf("Expected time frame %v, read %v.", count, reader.Frame().Offset)
+		}
+		if count == 20 {
+			break
+		}
+	}
+	if reader.Err() != nil || count != 20 {
+		t.Fatalf("Expected to stop after 20 time frames, read %v (%v).", count, reader.Err())
+	}
+
+	// Iteration continues where it was stopped
+	for reader.Next() {
+		count++
+	}
+	if reader.Err() != nil || count != 50 {
+		t.Fatalf("Expected 50 time frames, read %v (%v).", count, reader.Err())
+	}
+}
+
+func TestReaderNextError(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(parserTestHeader + parserTestGlobal + "#1\n1,T=1|2|3\n#2\nxyz\n#3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for reader.Next() {
+		count++
+	}
+	if count != 1 || reader.Err() == nil {
+		t.Fatalf("Expected an error after one time frame, read %v (%v).", count, reader.Err())
+	}
+	if reader.Next() {
+		t.Fatal("Expected iteration to stay stopped after an error")
+	}
+}
+
+func TestObjectIterator(t *testing.T) {
+	data := parserTestHeader + parserTestGlobal +
+		"1,T=1|2|3,Name=A\n" +
+		"#1\n1,T=4||,Color=Red\n2,T=7|8|9,Name=B\n0,Event=Message|1|Hello\n" +
+		"#2\n-1\n-3\n" +
+		"#3\n2,Name=C\n"
+
+	reader, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	visited := make([]string, 0)
+	iterator := NewObjectIterator(reader)
+	for iterator.Next() {
+		state := iterator.Object()
+		visited = append(visited, fmt.Sprintf("%v:%x:%v:%v:%v", iterator.Offset(), state.Id(), iterator.Removed(),
+			state.Object.Get("T").Value, state.Object.Get("Name").Value))
+	}
+	if err := iterator.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"0:1:false:1|2|3:A",
+		"1:1:false:4|2|3:A",
+		"1:2:false:7|8|9:B",
+		"2:1:true:4|2|3:A",
+		"3:2:false:7|8|9:C",
+	}
+	if strings.Join(visited, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Object states mismatch; expected %v, visited %v.", expected, visited)
+	}
+	if len(iterator.World().Events) != 0 || iterator.World().Get(1) != nil {
+		t.Fatal("World was not updated by the final time frame")
+	}
+}
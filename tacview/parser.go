@@ -1,16 +1,11 @@
 package tacview
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
-	"strconv"
-	"strings"
 	"time"
-	"unicode"
-
-	"github.com/spkg/bom"
 )
 
 type HeaderReader interface {
@@ -23,120 +18,174 @@ type RawReader interface {
 	ReadRawTimeFrame(float64) (*RawTimeFrame, error)
 }
 
+// Parser is the ACMI parsing core shared by every reader. It reads the file
+//  header followed by time frames, either raw (as unparsed lines) or parsed.
+//  Both CRLF and LF line endings are supported, and blank lines and `//`
+//  comment lines are ignored.
 type Parser struct {
-	r *bufio.Reader
+	scanner *Scanner
+	// pending is set when the scanner's current line has not been consumed yet
+	pending bool
+	// objects caches the objects of the time frame being parsed by id
+	objects map[uint64]*Object
+	// counter counts the bytes read from the input, nil for in-memory buffers
+	counter  *countingReader
+	progress *progressReporter
+
+	// header, frame and err hold the state of iteration using Next
+	header *Header
+	frame  *TimeFrame
+	err    error
 }
 
 func NewParser(reader io.Reader) (*Parser, error) {
-	return &Parser{r: bufio.NewReader(bom.NewReader(reader))}, nil
+	counter := &countingReader{reader: reader}
+	return &Parser{scanner: NewScanner(counter), objects: make(map[uint64]*Object), counter: counter}, nil
+}
+
+// newBytesParser creates a parser over an in-memory buffer
+func newBytesParser() *Parser {
+	return &Parser{scanner: &Scanner{}, objects: make(map[uint64]*Object)}
+}
+
+// reset switches the parser to an in-memory buffer
+func (p *Parser) reset(data []byte) {
+	p.scanner.Reset(data)
+	p.pending = false
+}
+
+// peek scans the next line unless the current line has not been consumed yet
+func (p *Parser) peek() bool {
+	if !p.pending {
+		p.pending = p.scanner.Scan()
+	}
+	return p.pending
+}
+
+// eof returns the error which stopped the scanner, or io.EOF
+func (p *Parser) eof() error {
+	if err := p.scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
 }
 
+// ReadHeader reads the `Key=Value` header lines and the initial time frame
+//  containing the global object. Unknown header keys are ignored.
 func (p *Parser) ReadHeader() (*Header, error) {
 	var header Header
 
-	for {
-		prefix, err := p.r.Peek(1)
-		if err != nil {
-			return nil, err
+	for p.peek() && p.scanner.Kind() == ObjectLine {
+		// The header ends with the first object line
+		if _, err := p.scanner.Id(); err == nil {
+			break
 		}
 
-		if unicode.IsDigit(rune(prefix[0])) {
-			initialTimeFrame, err := p.ReadTimeFrame(0)
-			if err != nil {
-				return nil, err
-			}
-			header.InitialTimeFrame = *initialTimeFrame
-
-			globalObj := initialTimeFrame.Get(0)
-			if globalObj == nil {
-				return nil, fmt.Errorf("no global object found in initial time frame")
-			}
-
-			referenceTimeProperty := globalObj.Get("ReferenceTime")
-			if referenceTimeProperty == nil {
-				return nil, fmt.Errorf("global object is missing ReferenceTime")
-			}
-
-			referenceTime, err := time.Parse("2006-01-02T15:04:05Z", referenceTimeProperty.Value)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse ReferenceTime: `%v`", referenceTimeProperty.Value)
-			}
-
-			header.ReferenceTime = referenceTime
-			return &header, nil
+		line := p.scanner.Bytes()
+		idx := bytes.IndexByte(line, '=')
+		if idx == -1 {
+			return nil, fmt.Errorf("Unexpected header line: `%s`", line)
 		}
 
-		line, err := p.r.ReadBytes('\n')
-		if err != nil {
-			return nil, err
+		switch string(line[:idx]) {
+		case "FileType":
+			header.FileType = string(line[idx+1:])
+		case "FileVersion":
+			header.FileVersion = string(line[idx+1:])
 		}
+		p.pending = false
+	}
 
-		lineStr := string(line[:len(line)-1])
-		if strings.HasPrefix(lineStr, "FileType=") {
-			header.FileType = strings.SplitN(lineStr, "=", 2)[1]
-		} else if strings.HasPrefix(lineStr, "FileVersion=") {
-			header.FileVersion = strings.SplitN(lineStr, "=", 2)[1]
-		} else {
-			return nil, fmt.Errorf("Unexpected header line: '%v'", lineStr)
-		}
+	if err := p.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if header.FileType == "" {
+		return nil, fmt.Errorf("Missing FileType header")
+	}
+	if header.FileVersion == "" {
+		return nil, fmt.Errorf("Missing FileVersion header")
+	}
+
+	initialTimeFrame, err := p.ReadTimeFrame(0)
+	if err != nil {
+		return nil, err
 	}
+	header.InitialTimeFrame = *initialTimeFrame
 
+	err = header.readGlobalObject()
+	if err != nil {
+		return nil, err
+	}
+	p.header = &header
+	return &header, nil
 }
 
-var ErrInvalidTimeFrameHeader = errors.New("invalid time frame header")
+// readGlobalObject parses the reference time and point from the global object
+//  of the initial time frame
+func (h *Header) readGlobalObject() error {
+	globalObj := h.InitialTimeFrame.Get(0)
+	if globalObj == nil {
+		return fmt.Errorf("No global object found in initial time frame")
+	}
 
-func (p *Parser) ReadRawTimeFrame(offset float64) (*RawTimeFrame, error) {
-	if offset == -1 {
-		timeFrameHeaderPrefix, err := p.r.Peek(1)
-		if err != nil {
-			return nil, err
-		}
+	referenceTimeProperty := globalObj.Get("ReferenceTime")
+	if referenceTimeProperty == nil {
+		return fmt.Errorf("Global object is missing ReferenceTime")
+	}
 
-		if timeFrameHeaderPrefix[0] != '#' {
-			return nil, ErrInvalidTimeFrameHeader
-		}
+	referenceTime, err := time.Parse("2006-01-02T15:04:05Z", referenceTimeProperty.Value)
+	if err != nil {
+		return fmt.Errorf("Failed to parse ReferenceTime: `%v`", referenceTimeProperty.Value)
+	}
+	h.ReferenceTime = referenceTime
 
-		headerLine, err := p.r.ReadBytes('\n')
-		if err != nil {
-			return nil, err
-		}
+	return h.readReferencePoint(globalObj)
+}
 
-		offset, err = strconv.ParseFloat(string(headerLine[1:len(headerLine)-1]), 64)
-		if err != nil {
-			return nil, err
+var ErrInvalidTimeFrameHeader = errors.New("invalid time frame header")
+
+// readOffset reads the offset line of the next time frame
+func (p *Parser) readOffset() (float64, error) {
+	if !p.peek() {
+		err := p.eof()
+		if err == io.EOF {
+			p.reportDone()
 		}
+		return 0, err
 	}
 
-	lines := make([]string, 0)
-
-	var currentLine []byte
-	for {
-		linePrefix, err := p.r.Peek(1)
-		if err != nil {
-			return nil, err
-		}
+	if p.scanner.Kind() != TimeFrameLine {
+		return 0, ErrInvalidTimeFrameHeader
+	}
 
-		if linePrefix[0] == '#' {
-			break
-		}
+	offset, err := p.scanner.Offset()
+	if err != nil {
+		return 0, err
+	}
+	p.pending = false
+	p.reportProgress(offset)
+	return offset, nil
+}
 
-		line, err := p.r.ReadBytes('\n')
+// ReadRawTimeFrame reads the lines of the next time frame. When offset is -1 the
+//  time frame's offset line is read first, otherwise the given offset is used.
+func (p *Parser) ReadRawTimeFrame(offset float64) (*RawTimeFrame, error) {
+	if offset == -1 {
+		var err error
+		offset, err = p.readOffset()
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		// Escaped line, we have more to read
-		if line[len(line)-2] == '\\' {
-			currentLine = append(currentLine, line[:len(line)-2]...)
-			continue
-		}
+	lines := make([]string, 0)
+	for p.peek() && p.scanner.Kind() != TimeFrameLine {
+		lines = append(lines, string(p.scanner.Bytes()))
+		p.pending = false
+	}
 
-		if len(currentLine) != 0 {
-			lines = append(lines, string(append(currentLine, line[:len(line)-1]...)))
-			currentLine = []byte{}
-		} else {
-			lines = append(lines, string(line[:len(line)-1]))
-		}
+	if err := p.scanner.Err(); err != nil {
+		return nil, err
 	}
 
 	return &RawTimeFrame{
@@ -145,61 +194,58 @@ func (p *Parser) ReadRawTimeFrame(offset float64) (*RawTimeFrame, error) {
 	}, nil
 }
 
+// ReadTimeFrame reads and parses the next time frame. When offset is -1 the
+//  time frame's offset line is read first, otherwise the given offset is used.
 func (p *Parser) ReadTimeFrame(offset float64) (*TimeFrame, error) {
-	rawTimeFrame, err := p.ReadRawTimeFrame(offset)
-	if err != nil {
-		return nil, err
+	if offset == -1 {
+		var err error
+		offset, err = p.readOffset()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return rawTimeFrame.Parse()
-}
-
-func parseObjectLine(line string) (*Object, error) {
-	isDelete := false
+	timeFrame := NewTimeFrame()
+	timeFrame.Offset = offset
 
-	if line[0] == '-' {
-		line = line[1:]
-		isDelete = true
+	for id := range p.objects {
+		delete(p.objects, id)
 	}
 
-	parts := strings.SplitN(line, ",", 2)
+	for p.peek() && p.scanner.Kind() != TimeFrameLine {
+		p.pending = false
 
-	objectId, err := strconv.ParseUint(parts[0], 16, 64)
-	if err != nil {
-		return nil, err
+		err := applyObjectLine(p.scanner, timeFrame, p.objects)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	object := &Object{
-		Id:         objectId,
-		Properties: make([]*Property, 0),
-		Deleted:    isDelete,
+	if err := p.scanner.Err(); err != nil {
+		return nil, err
 	}
+	return timeFrame, nil
+}
 
-	if !isDelete {
-		var props []string
-		if strings.Contains(parts[1], `\,`) {
-			var err error
-			props, err = splitPropertyTokens(parts[1])
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			// Fast-er path
-			props = strings.Split(parts[1], ",")
-		}
-
-		for _, part := range props {
-			if len(part) == 0 {
-				continue
-			}
-			partSplit := strings.SplitN(part, "=", 2)
-			if len(partSplit) != 2 {
-				return nil, fmt.Errorf("Failed to parse property: `%v`", part)
-			}
+// applyObjectLine applies the scanner's current object or remove line to the
+//  time frame. Multiple lines for the same object within a time frame are
+//  merged into a single object using the objects cache.
+func applyObjectLine(scanner *Scanner, timeFrame *TimeFrame, objects map[uint64]*Object) error {
+	objectId, err := scanner.Id()
+	if err != nil {
+		return fmt.Errorf("Failed to parse object line `%s`: %v", scanner.Bytes(), err)
+	}
 
-			object.Properties = append(object.Properties, &Property{Key: partSplit[0], Value: partSplit[1]})
-		}
+	object, ok := objects[objectId]
+	if !ok {
+		object = NewObject(objectId)
+		objects[objectId] = object
+		timeFrame.Objects = append(timeFrame.Objects, object)
 	}
 
-	return object, nil
+	if scanner.Kind() == RemoveLine {
+		object.Deleted = true
+		return nil
+	}
+	return scanner.readProperties(object)
 }
@@ -0,0 +1,267 @@
+package tacview
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+const parserTestHeader = "FileType=text/acmi/tacview\nFileVersion=2.2\n"
+
+const parserTestGlobal = "0,ReferenceTime=2021-07-24T04:00:00Z,ReferenceLongitude=40,ReferenceLatitude=41\n"
+
+// formatParsed serializes a parsed header and time frames for comparison
+func formatParsed(header *Header, timeFrames []*TimeFrame) string {
+	var buffer bytes.Buffer
+	writer := bufio.NewWriter(&buffer)
+
+	header.InitialTimeFrame.Write(writer, false)
+	for _, tf := range timeFrames {
+		tf.Write(writer, true)
+	}
+	writer.Flush()
+	return buffer.String()
+}
+
+func parseWithParser(data string) (string, error) {
+	parser, err := NewParser(strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	header, err := parser.ReadHeader()
+	if err != nil {
+		return "", err
+	}
+
+	timeFrames := make([]*TimeFrame, 0)
+	for {
+		tf, err := parser.ReadTimeFrame(-1)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		timeFrames = append(timeFrames, tf)
+	}
+	return formatParsed(header, timeFrames), nil
+}
+
+func parseRaw(data string) (string, error) {
+	parser, err := NewParser(strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	header, err := parser.ReadHeader()
+	if err != nil {
+		return "", err
+	}
+
+	timeFrames := make([]*TimeFrame, 0)
+	for {
+		raw, err := parser.ReadRawTimeFrame(-1)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		tf, err := raw.Parse()
+		if err != nil {
+			return "", err
+		}
+		timeFrames = append(timeFrames, tf)
+	}
+	return formatParsed(header, timeFrames), nil
+}
+
+func parseWithReader(data string) (string, error) {
+	reader, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	timeFrames := make([]*TimeFrame, 0)
+	timeFrameChan := make(chan *TimeFrame)
+	done := make(chan struct{})
+	go func() {
+		for tf := range timeFrameChan {
+			timeFrames = append(timeFrames, tf)
+		}
+		close(done)
+	}()
+
+	err = reader.ProcessTimeFrames(1, timeFrameChan)
+	<-done
+	if err != nil {
+		return "", err
+	}
+	return formatParsed(&reader.Header, timeFrames), nil
+}
+
+func parseWithIterator(data string) (string, error) {
+	parser, err := NewParser(strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	timeFrames := make([]*TimeFrame, 0)
+	for parser.Next() {
+		timeFrames = append(timeFrames, parser.Frame())
+	}
+	if err := parser.Err(); err != nil {
+		return "", err
+	}
+	return formatParsed(parser.Header(), timeFrames), nil
+}
+
+var parsers = map[string]func(string) (string, error){
+	"parser":   parseWithParser,
+	"raw":      parseRaw,
+	"reader":   parseWithReader,
+	"iterator": parseWithIterator,
+}
+
+func TestParserEdgeCases(t *testing.T) {
+	global := "0,ReferenceTime=2021-07-24T04:00:00Z,ReferenceLongitude=40,ReferenceLatitude=41\n"
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "basic",
+			input:    parserTestHeader + parserTestGlobal + "#1\n1,T=1|2|3,Name=A\n#2\n-1\n",
+			expected: global + "#1.000000\n1,T=1|2|3,Name=A\n#2.000000\n-1\n",
+		},
+		{
+			name:     "byte order mark",
+			input:    string(bomHeader) + parserTestHeader + parserTestGlobal + "#1\n1,T=1|2|3\n",
+			expected: global + "#1.000000\n1,T=1|2|3\n",
+		},
+		{
+			name:     "crlf line endings",
+			input:    strings.ReplaceAll(parserTestHeader+parserTestGlobal+"#1\n1,T=1|2|3,Name=A\n#2.5\n-1\n", "\n", "\r\n"),
+			expected: global + "#1.000000\n1,T=1|2|3,Name=A\n#2.500000\n-1\n",
+		},
+		{
+			name:     "blank and comment lines",
+			input:    parserTestHeader + "\n// comment\n" + parserTestGlobal + "\n#1\n// 1,T=4|5|6\n\n1,T=1|2|3\n\n",
+			expected: global + "#1.000000\n1,T=1|2|3\n",
+		},
+		{
+			name:     "multi-line values",
+			input:    parserTestHeader + parserTestGlobal + "#1\n1,Name=A,Comments=First\\\n// Second\\\n\\\nThird\n",
+			expected: global + "#1.000000\n1,Name=A,Comments=First\\\n// Second\\\n\\\nThird\n",
+		},
+		{
+			name:     "multi-line values with crlf",
+			input:    strings.ReplaceAll(parserTestHeader+parserTestGlobal+"#1\n1,Comments=First\\\nSecond\n", "\n", "\r\n"),
+			expected: global + "#1.000000\n1,Comments=First\\\nSecond\n",
+		},
+		{
+			name:     "escaped commas",
+			input:    parserTestHeader + parserTestGlobal + "#1\n1,Name=A\\, B,T=1|2|3\n",
+			expected: global + "#1.000000\n1,Name=A\\, B,T=1|2|3\n",
+		},
+		{
+			name:     "empty properties",
+			input:    parserTestHeader + parserTestGlobal + "#1\n1,,T=1|2|3,\n2,\n",
+			expected: global + "#1.000000\n1,T=1|2|3\n2,\n",
+		},
+		{
+			name:     "repeated objects are merged",
+			input:    parserTestHeader + parserTestGlobal + "#1\n1,T=1|2|3\n2,Name=B\n1,Name=A\n",
+			expected: global + "#1.000000\n1,T=1|2|3,Name=A\n2,Name=B\n",
+		},
+		{
+			name:     "upper case object ids",
+			input:    parserTestHeader + parserTestGlobal + "#1\n1A,T=1|2|3\n-1A\n",
+			expected: global + "#1.000000\n-1a\n",
+		},
+		{
+			name:     "empty time frames",
+			input:    parserTestHeader + parserTestGlobal + "#1\n#2\n1,T=1|2|3\n#3\n",
+			expected: global + "#1.000000\n#2.000000\n1,T=1|2|3\n#3.000000\n",
+		},
+		{
+			name:     "missing trailing line break",
+			input:    parserTestHeader + parserTestGlobal + "#1\n1,T=1|2|3",
+			expected: global + "#1.000000\n1,T=1|2|3\n",
+		},
+		{
+			name:     "unknown header keys",
+			input:    "FileType=text/acmi/tacview\nFileVersion=2.2\nExtra=Value\n" + parserTestGlobal + "#1\n",
+			expected: global + "#1.000000\n",
+		},
+		{
+			name:     "global properties within time frames",
+			input:    parserTestHeader + parserTestGlobal + "#1\n0,Event=Message|1|Hello\\, World\n",
+			expected: global + "#1.000000\n0,Event=Message|1|Hello\\, World\n",
+		},
+	}
+
+	for _, test := range tests {
+		for name, parse := range parsers {
+			output, err := parse(test.input)
+			if err != nil {
+				t.Fatalf("%v (%v): failed to parse: %v", test.name, name, err)
+			}
+			if output != test.expected {
+				t.Fatalf("%v (%v): output mismatch; expected %q, parsed %q.", test.name, name, test.expected, output)
+			}
+		}
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing FileType":         "FileVersion=2.2\n" + parserTestGlobal,
+		"missing FileVersion":      "FileType=text/acmi/tacview\n" + parserTestGlobal,
+		"invalid header line":      "FileType=text/acmi/tacview\nFileVersion\n" + parserTestGlobal,
+		"missing global object":    parserTestHeader + "1,T=1|2|3\n",
+		"missing ReferenceTime":    parserTestHeader + "0,Title=Test\n",
+		"invalid ReferenceTime":    parserTestHeader + "0,ReferenceTime=yesterday\n",
+		"invalid reference point":  parserTestHeader + "0,ReferenceTime=2021-07-24T04:00:00Z,ReferenceLongitude=east\n",
+		"invalid time frame":       parserTestHeader + parserTestGlobal + "#soon\n",
+		"invalid object id":        parserTestHeader + parserTestGlobal + "#1\nxyz,T=1|2|3\n",
+		"property without a value": parserTestHeader + parserTestGlobal + "#1\n1,T\n",
+	}
+
+	for name, input := range tests {
+		for parserName, parse := range parsers {
+			if _, err := parse(input); err == nil {
+				t.Fatalf("%v (%v): expected an error", name, parserName)
+			}
+		}
+	}
+}
+
+func TestParserHeader(t *testing.T) {
+	parser, _ := NewParser(strings.NewReader(parserTestHeader + parserTestGlobal))
+	header, err := parser.ReadHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.FileType != "text/acmi/tacview" || header.FileVersion != "2.2" {
+		t.Fatalf("Header mismatch; parsed %v and %v.", header.FileType, header.FileVersion)
+	}
+	if header.ReferenceLongitude != 40 || header.ReferenceLatitude != 41 {
+		t.Fatalf("Reference point mismatch; parsed %v, %v.", header.ReferenceLongitude, header.ReferenceLatitude)
+	}
+	if header.ReferenceTime.Format("2006-01-02T15:04:05Z") != "2021-07-24T04:00:00Z" {
+		t.Fatalf("Reference time mismatch; parsed %v.", header.ReferenceTime)
+	}
+
+	reader, err := NewReader(strings.NewReader(parserTestHeader + parserTestGlobal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Header.FileType != header.FileType || reader.Header.FileVersion != header.FileVersion {
+		t.Fatalf("Reader header mismatch; parsed %v and %v.", reader.Header.FileType, reader.Header.FileVersion)
+	}
+}
@@ -0,0 +1,300 @@
+package tacview
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const (
+	// DefaultReorderWindow is the default number of time frames parsed ahead
+	//  of the oldest time frame not yet produced, per processing routine
+	DefaultReorderWindow = 16
+	// DefaultMaxInFlightBytes is the default limit on the raw size of the time
+	//  frames read but not yet produced
+	DefaultMaxInFlightBytes = 64 * 1024 * 1024
+)
+
+// ProcessOptions configures how a Reader processes time frames
+type ProcessOptions struct {
+	// Concurrency is the number of routines parsing time frames in parallel
+	Concurrency int
+	// Ordered produces time frames in file order, otherwise time frames are
+	//  produced as soon as they are parsed
+	Ordered bool
+	// ReorderWindow is the maximum number of time frames read but not yet
+	//  produced. Defaults to DefaultReorderWindow per processing routine.
+	ReorderWindow int
+	// MaxInFlightBytes is the maximum raw size of the time frames read but not
+	//  yet produced. A single time frame is always allowed regardless of its
+	//  size. Defaults to DefaultMaxInFlightBytes.
+	MaxInFlightBytes int
+}
+
+func (o ProcessOptions) withDefaults() ProcessOptions {
+	if o.Concurrency < 1 {
+		o.Concurrency = 1
+	}
+	if o.ReorderWindow < 1 {
+		o.ReorderWindow = DefaultReorderWindow * o.Concurrency
+	}
+	if o.MaxInFlightBytes < 1 {
+		o.MaxInFlightBytes = DefaultMaxInFlightBytes
+	}
+	return o
+}
+
+// ProcessTimeFrames concurrently processes time frames from within the ACMI file,
+//  producing them to an output channel. If your use case requires strong ordering
+//  and you do not wish to implement this guarantee on the consumer side, you must
+//  set the concurrency to 1 or use ProcessTimeFramesOrdered. The first error
+//  encountered while parsing a time frame stops processing and is returned.
+func (r *Reader) ProcessTimeFrames(concurrency int, timeFrame chan<- *TimeFrame) error {
+	return r.ProcessTimeFramesWithOptions(ProcessOptions{Concurrency: concurrency}, timeFrame)
+}
+
+// ProcessTimeFramesOrdered processes time frames like ProcessTimeFrames but
+//  parses them in parallel while producing them in file order.
+func (r *Reader) ProcessTimeFramesOrdered(concurrency int, timeFrame chan<- *TimeFrame) error {
+	return r.ProcessTimeFramesWithOptions(ProcessOptions{Concurrency: concurrency, Ordered: true}, timeFrame)
+}
+
+// ProcessTimeFramesWithOptions processes time frames from within the ACMI file,
+//  producing them to an output channel which is closed once every time frame
+//  was produced. Memory use is bounded by the reorder window and in-flight
+//  bytes limit: reading stops while either is exhausted until the consumer
+//  catches up.
+func (r *Reader) ProcessTimeFramesWithOptions(options ProcessOptions, timeFrame chan<- *TimeFrame) error {
+	return r.ProcessTimeFramesContext(context.Background(), options, timeFrame)
+}
+
+// ProcessTimeFramesContext processes time frames like ProcessTimeFramesWithOptions
+//  until the context is done, in which case the context's error is returned once
+//  the processing routines have stopped. The output channel is always closed.
+func (r *Reader) ProcessTimeFramesContext(ctx context.Context, options ProcessOptions, timeFrame chan<- *TimeFrame) error {
+	options = options.withDefaults()
+
+	jobs := make(chan *timeFrameJob)
+	results := make(chan *timeFrameJob, options.Concurrency)
+	limiter := newInFlightLimiter(options.ReorderWindow, options.MaxInFlightBytes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			parser := newBytesParser()
+			for job := range jobs {
+				parser.reset(*job.data)
+				job.timeFrame, job.err = parser.ReadTimeFrame(-1)
+				timeFrameBuffers.Put(job.data)
+				job.data = nil
+				results <- job
+			}
+		}()
+	}
+
+	producerErr := make(chan error, 1)
+	go func() {
+		err := r.timeFrameProducer(jobs, limiter)
+		close(jobs)
+		wg.Wait()
+		close(results)
+		producerErr <- err
+	}()
+
+	// Stop reading once the context is done
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			limiter.close()
+		case <-stopped:
+		}
+	}()
+
+	var err error
+	stop := func(cause error) {
+		err = cause
+		limiter.close()
+	}
+
+	emit := func(job *timeFrameJob) {
+		select {
+		case timeFrame <- job.timeFrame:
+			limiter.release(job.size)
+		case <-ctx.Done():
+			stop(ctx.Err())
+		}
+	}
+
+	pending := make(map[int]*timeFrameJob)
+	next := 0
+	for job := range results {
+		// After an error we keep draining so the processing routines can finish
+		if err != nil {
+			continue
+		}
+
+		if job.err != nil {
+			stop(job.err)
+			continue
+		}
+
+		if !options.Ordered {
+			emit(job)
+			continue
+		}
+
+		pending[job.sequence] = job
+		for err == nil {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+
+			delete(pending, next)
+			emit(ready)
+			next++
+		}
+	}
+
+	close(timeFrame)
+	readErr := <-producerErr
+	if err == nil {
+		err = readErr
+	}
+	if err != nil && ctx.Err() != nil {
+		// Errors caused by the input being closed on cancellation are reported
+		//  as the context's error
+		return ctx.Err()
+	}
+	return err
+}
+
+// timeFrameJob carries the raw contents of a time frame to a parsing routine
+//  and the parsed time frame back
+type timeFrameJob struct {
+	sequence  int
+	size      int
+	data      *[]byte
+	timeFrame *TimeFrame
+	err       error
+}
+
+// timeFrameBuffers holds the buffers used to pass the raw contents of time
+//  frames to the parsing routines
+var timeFrameBuffers = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, 0, 4096)
+		return &buffer
+	},
+}
+
+// errProcessingStopped is returned by the producer when processing was stopped
+//  before the input was read completely
+var errProcessingStopped = errors.New("processing stopped")
+
+// timeFrameProducer splits the remaining input into time frames, sending each
+//  to the parsing routines once the limiter has room for it
+func (r *Reader) timeFrameProducer(jobs chan<- *timeFrameJob, limiter *inFlightLimiter) error {
+	sequence := 0
+	send := func(data *[]byte) bool {
+		if !limiter.acquire(len(*data)) {
+			return false
+		}
+
+		jobs <- &timeFrameJob{sequence: sequence, size: len(*data), data: data}
+		sequence++
+		return true
+	}
+
+	buf := timeFrameBuffers.Get().(*[]byte)
+	*buf = (*buf)[:0]
+
+	scanner := r.parser.scanner
+	for r.parser.peek() {
+		r.parser.pending = false
+
+		if scanner.Kind() == TimeFrameLine {
+			if len(*buf) > 0 {
+				if !send(buf) {
+					return errProcessingStopped
+				}
+				buf = timeFrameBuffers.Get().(*[]byte)
+				*buf = (*buf)[:0]
+			}
+
+			if offset, err := scanner.Offset(); err == nil {
+				r.parser.reportProgress(offset)
+			}
+		}
+
+		*buf = append(*buf, scanner.Bytes()...)
+		*buf = append(*buf, '\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(*buf) > 0 && !send(buf) {
+		return errProcessingStopped
+	}
+	r.parser.reportDone()
+	return nil
+}
+
+// inFlightLimiter bounds the number and raw size of time frames which were
+//  read but not yet produced
+type inFlightLimiter struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	frames    int
+	maxFrames int
+	bytes     int
+	maxBytes  int
+	closed    bool
+}
+
+func newInFlightLimiter(maxFrames int, maxBytes int) *inFlightLimiter {
+	limiter := &inFlightLimiter{maxFrames: maxFrames, maxBytes: maxBytes}
+	limiter.cond = sync.NewCond(&limiter.mu)
+	return limiter
+}
+
+// acquire blocks until a time frame of the given size fits within the limits,
+//  returning false if the limiter was closed in the meantime
+func (l *inFlightLimiter) acquire(size int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for !l.closed && l.frames > 0 && (l.frames >= l.maxFrames || l.bytes+size > l.maxBytes) {
+		l.cond.Wait()
+	}
+	if l.closed {
+		return false
+	}
+
+	l.frames++
+	l.bytes += size
+	return true
+}
+
+func (l *inFlightLimiter) release(size int) {
+	l.mu.Lock()
+	l.frames--
+	l.bytes -= size
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// close wakes and stops any routine waiting to acquire
+func (l *inFlightLimiter) close() {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
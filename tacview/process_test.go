@@ -0,0 +1,171 @@
+package tacview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func processTestData(timeFrames int) string {
+	var builder strings.Builder
+	builder.WriteString(parserTestHeader + parserTestGlobal)
+	for i := 1; i <= timeFrames; i++ {
+		fmt.Fprintf(&builder, "#%d\n", i)
+		// Vary the size of time frames so they finish parsing out of order
+		for id := 1; id <= 1+(i*7)%13; id++ {
+			fmt.Fprintf(&builder, "%x,T=%d|%d|%d,Name=Object %d\n", id, i, id, i+id, id)
+		}
+	}
+	return builder.String()
+}
+
+func processWithOptions(data string, options ProcessOptions) ([]*TimeFrame, error) {
+	reader, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return processReader(reader, options)
+}
+
+func processReader(reader *Reader, options ProcessOptions) ([]*TimeFrame, error) {
+	timeFrames := make([]*TimeFrame, 0)
+	timeFrameChan := make(chan *TimeFrame)
+	done := make(chan struct{})
+	go func() {
+		for tf := range timeFrameChan {
+			timeFrames = append(timeFrames, tf)
+		}
+		close(done)
+	}()
+
+	err := reader.ProcessTimeFramesWithOptions(options, timeFrameChan)
+	<-done
+	return timeFrames, err
+}
+
+func TestProcessTimeFramesOrdered(t *testing.T) {
+	data := processTestData(500)
+	expected, err := parseWithParser(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]ProcessOptions{
+		"defaults":             {Concurrency: 8, Ordered: true},
+		"small window":         {Concurrency: 8, Ordered: true, ReorderWindow: 2},
+		"single time frame":    {Concurrency: 8, Ordered: true, ReorderWindow: 1},
+		"small in-flight size": {Concurrency: 8, Ordered: true, MaxInFlightBytes: 1},
+	}
+
+	for name, options := range tests {
+		timeFrames, err := processWithOptions(data, options)
+		if err != nil {
+			t.Fatalf("%v: failed to process: %v", name, err)
+		}
+
+		reader, _ := NewReader(strings.NewReader(data))
+		if output := formatParsed(&reader.Header, timeFrames); output != expected {
+			t.Fatalf("%v: output does not match the sequential parser.", name)
+		}
+	}
+}
+
+func TestProcessTimeFramesUnordered(t *testing.T) {
+	timeFrames, err := processWithOptions(processTestData(200), ProcessOptions{Concurrency: 4, ReorderWindow: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[float64]bool)
+	for _, tf := range timeFrames {
+		seen[tf.Offset] = true
+	}
+	if len(timeFrames) != 200 || len(seen) != 200 {
+		t.Fatalf("Expected 200 distinct time frames, processed %v (%v distinct).", len(timeFrames), len(seen))
+	}
+}
+
+func TestProcessTimeFramesError(t *testing.T) {
+	data := processTestData(100) + "#101\nxyz,T=1|2|3\n" + strings.TrimPrefix(processTestData(100), parserTestHeader+parserTestGlobal)
+
+	for _, ordered := range []bool{true, false} {
+		_, err := processWithOptions(data, ProcessOptions{Concurrency: 4, Ordered: ordered, ReorderWindow: 2})
+		if err == nil || !strings.Contains(err.Error(), "xyz") {
+			t.Fatalf("Expected an object line error (ordered: %v), got %v", ordered, err)
+		}
+	}
+}
+
+func TestProcessTimeFramesCancel(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(processTestData(500)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	timeFrameChan := make(chan *TimeFrame)
+	received := 0
+	done := make(chan struct{})
+	go func() {
+		for range timeFrameChan {
+			received++
+			if received == 10 {
+				cancel()
+			}
+		}
+		close(done)
+	}()
+
+	err = reader.ProcessTimeFramesContext(ctx, ProcessOptions{Concurrency: 4, Ordered: true}, timeFrameChan)
+	<-done
+	if err != context.Canceled {
+		t.Fatalf("Expected the context to be canceled, got %v", err)
+	}
+	if received >= 500 {
+		t.Fatalf("Expected processing to stop early, received %v time frames.", received)
+	}
+}
+
+func TestProcessTimeFramesProgress(t *testing.T) {
+	data := processTestData(100)
+	reader, err := NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports := make([]Progress, 0)
+	reader.SetProgress(0, func(progress Progress) {
+		reports = append(reports, progress)
+	})
+
+	_, err = processReader(reader, ProcessOptions{Concurrency: 4, Ordered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reports) < 2 {
+		t.Fatalf("Expected progress reports for every time frame, got %v.", len(reports))
+	}
+	final := reports[len(reports)-1]
+	if !final.Done || final.BytesRead != int64(len(data)) || final.Offset != 100 {
+		t.Fatalf("Final progress mismatch; got %+v for %v bytes.", final, len(data))
+	}
+	for idx := 1; idx < len(reports); idx++ {
+		if reports[idx].Offset < reports[idx-1].Offset || reports[idx].BytesRead < reports[idx-1].BytesRead {
+			t.Fatalf("Progress went backwards; %+v after %+v.", reports[idx], reports[idx-1])
+		}
+	}
+}
+
+func TestTrimRawCancel(t *testing.T) {
+	parser, _ := NewParser(strings.NewReader(processTestData(10)))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := TrimRawContext(ctx, parser, NewRawWriter(&bytes.Buffer{}), 5, 8)
+	if err != context.Canceled {
+		t.Fatalf("Expected the context to be canceled, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package tacview
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Progress describes how far a Parser has read through its input
+type Progress struct {
+	// BytesRead is the number of bytes read from the underlying reader
+	BytesRead int64
+	// Offset is the offset of the most recently read time frame
+	Offset float64
+	// Elapsed is the time since progress reporting started
+	Elapsed time.Duration
+	// Throughput is the average number of bytes read per second since progress
+	//  reporting started
+	Throughput float64
+	// Done is set for the final report once the input was read completely
+	Done bool
+}
+
+// ProgressFunc receives progress reports
+type ProgressFunc func(Progress)
+
+// countingReader counts the bytes read from the wrapped reader
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	atomic.AddInt64(&c.count, int64(n))
+	return n, err
+}
+
+func (c *countingReader) bytesRead() int64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&c.count)
+}
+
+// progressReporter throttles progress reports to one per interval
+type progressReporter struct {
+	fn         ProgressFunc
+	interval   time.Duration
+	start      time.Time
+	startBytes int64
+	last       time.Time
+	offset     float64
+}
+
+// SetProgress registers a function which receives a progress report at most once
+//  per interval while time frames are read, and a final report once the input
+//  was read completely. The function is called from the routine reading the
+//  input, which for ProcessTimeFrames is not the calling routine.
+func (p *Parser) SetProgress(interval time.Duration, fn ProgressFunc) {
+	if fn == nil {
+		p.progress = nil
+		return
+	}
+
+	now := time.Now()
+	p.progress = &progressReporter{
+		fn:         fn,
+		interval:   interval,
+		start:      now,
+		startBytes: p.counter.bytesRead(),
+		last:       now,
+	}
+}
+
+// SetProgress registers a progress function on the underlying Parser
+func (r *Reader) SetProgress(interval time.Duration, fn ProgressFunc) {
+	r.parser.SetProgress(interval, fn)
+}
+
+// BytesRead returns the number of bytes read from the underlying reader
+func (p *Parser) BytesRead() int64 {
+	return p.counter.bytesRead()
+}
+
+// progressDue returns whether a progress report should be made for the next
+//  time frame
+func (p *Parser) progressDue() bool {
+	return p.progress != nil && time.Since(p.progress.last) >= p.progress.interval
+}
+
+// reportProgress records the offset of the time frame being read, reporting
+//  progress once the interval has passed
+func (p *Parser) reportProgress(offset float64) {
+	if p.progress == nil {
+		return
+	}
+
+	p.progress.offset = offset
+	if p.progressDue() {
+		p.report(false)
+	}
+}
+
+// reportDone makes the final progress report
+func (p *Parser) reportDone() {
+	if p.progress != nil {
+		p.report(true)
+		p.progress = nil
+	}
+}
+
+func (p *Parser) report(done bool) {
+	now := time.Now()
+	p.progress.last = now
+
+	bytesRead := p.counter.bytesRead()
+	elapsed := now.Sub(p.progress.start)
+
+	var throughput float64
+	if elapsed > 0 {
+		throughput = float64(bytesRead-p.progress.startBytes) / elapsed.Seconds()
+	}
+
+	p.progress.fn(Progress{
+		BytesRead:  bytesRead,
+		Offset:     p.progress.offset,
+		Elapsed:    elapsed,
+		Throughput: throughput,
+		Done:       done,
+	})
+}
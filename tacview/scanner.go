@@ -0,0 +1,401 @@
+package tacview
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spkg/bom"
+)
+
+// LineKind describes the kind of a logical ACMI line
+type LineKind int
+
+const (
+	// ObjectLine is an object update (`id,Key=Value,...`), header lines such as
+	//  `FileType=...` are also reported as object lines
+	ObjectLine LineKind = iota
+	// RemoveLine removes an object (`-id`)
+	RemoveLine
+	// TimeFrameLine starts a new time frame (`#offset`)
+	TimeFrameLine
+)
+
+// ErrInvalidObjectId is returned when an object line does not start with a
+//  hexadecimal object id
+var ErrInvalidObjectId = errors.New("invalid object id")
+
+// Scanner tokenizes ACMI text into logical lines, object ids and property key
+//  and value spans. Lines continued with a trailing backslash are joined into a
+//  single logical line. Returned byte slices point into an internal buffer and
+//  are only valid until the next call to Scan; escape sequences within values
+//  are left intact until Unescape or ValueString is called. Once its buffers
+//  have grown to the longest line a Scanner does not allocate.
+type Scanner struct {
+	reader *bufio.Reader
+	data   []byte
+	pos    int
+
+	line   []byte
+	buffer []byte
+	long   []byte
+	kind   LineKind
+	rest   []byte
+	key    []byte
+	value  []byte
+	// position of the current key within the line
+	keyOffset int
+	escaped   bool
+	err       error
+	// keys interns the property keys seen by readProperties so objects share a
+	//  single copy of each key instead of holding on to whole lines
+	keys map[string]string
+}
+
+// scannerKeyLimit caps the number of property keys a scanner interns, keys seen
+//  afterwards share the memory of their line like values do
+const scannerKeyLimit = 4096
+
+// NewScanner creates a new Scanner reading from the given reader, skipping any
+//  leading byte order mark
+func NewScanner(reader io.Reader) *Scanner {
+	return &Scanner{reader: bufio.NewReaderSize(bom.NewReader(reader), 64*1024)}
+}
+
+// NewBytesScanner creates a new Scanner over an in-memory buffer
+func NewBytesScanner(data []byte) *Scanner {
+	s := &Scanner{}
+	s.Reset(data)
+	return s
+}
+
+// Reset switches the scanner to an in-memory buffer, reusing its internal buffers
+func (s *Scanner) Reset(data []byte) {
+	s.reader = nil
+	s.data = data
+	s.pos = 0
+	s.line = nil
+	s.rest = nil
+	s.err = nil
+}
+
+// readLine returns the next physical line without its (LF or CRLF) line
+//  terminator. The returned slice is only valid until the next call.
+func (s *Scanner) readLine() ([]byte, error) {
+	line, err := s.readRawLine()
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, err
+}
+
+func (s *Scanner) readRawLine() ([]byte, error) {
+	if s.reader == nil {
+		if s.pos >= len(s.data) {
+			return nil, io.EOF
+		}
+
+		data := s.data[s.pos:]
+		end := bytes.IndexByte(data, '\n')
+		if end == -1 {
+			s.pos = len(s.data)
+			return data, nil
+		}
+		s.pos += end + 1
+		return data[:end], nil
+	}
+
+	line, err := s.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Lines longer than the reader's buffer are collected separately
+		s.long = append(s.long[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = s.reader.ReadSlice('\n')
+			s.long = append(s.long, line...)
+		}
+		line = s.long
+	}
+
+	if err == io.EOF && len(line) > 0 {
+		return line, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return line[:len(line)-1], nil
+}
+
+// Scan advances to the next logical line, skipping blank lines and `//`
+//  comment lines. Returns false once the input is exhausted or an error occurred.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	var line []byte
+	for {
+		var err error
+		line, err = s.readLine()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			s.line = nil
+			return false
+		}
+
+		if len(line) > 0 && !bytes.HasPrefix(line, []byte("//")) {
+			break
+		}
+	}
+
+	// Continued lines keep their escaped line break so values can be unescaped
+	//  (or written back out) exactly as they were read
+	if continued(line) {
+		s.buffer = append(s.buffer[:0], line...)
+		for continued(s.buffer) {
+			next, err := s.readLine()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				s.err = err
+				return false
+			}
+
+			s.buffer = append(s.buffer, '\n')
+			s.buffer = append(s.buffer, next...)
+		}
+		line = s.buffer
+	}
+
+	s.line = line
+	s.rest = nil
+	s.key = nil
+	s.value = nil
+	switch {
+	case len(line) > 0 && line[0] == '#':
+		s.kind = TimeFrameLine
+	case len(line) > 0 && line[0] == '-':
+		s.kind = RemoveLine
+	default:
+		s.kind = ObjectLine
+		if idx := bytes.IndexByte(line, ','); idx != -1 {
+			s.rest = line[idx+1:]
+		}
+	}
+	return true
+}
+
+// continued returns whether a line ends with an unescaped backslash
+func continued(line []byte) bool {
+	count := 0
+	for idx := len(line) - 1; idx >= 0 && line[idx] == '\\'; idx-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// Err returns the first error encountered while scanning, reaching the end of
+//  the input is not considered an error
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Bytes returns the current logical line
+func (s *Scanner) Bytes() []byte {
+	return s.line
+}
+
+// Kind returns the kind of the current logical line
+func (s *Scanner) Kind() LineKind {
+	return s.kind
+}
+
+// Offset parses the offset of the current time frame line
+func (s *Scanner) Offset() (float64, error) {
+	if s.kind != TimeFrameLine {
+		return 0, fmt.Errorf("Expected time frame offset, found `%s`", s.line)
+	}
+	return strconv.ParseFloat(string(s.line[1:]), 64)
+}
+
+// Id parses the object id of the current object or remove line
+func (s *Scanner) Id() (uint64, error) {
+	data := s.line
+	if s.kind == RemoveLine {
+		data = data[1:]
+	}
+	if idx := bytes.IndexByte(data, ','); idx != -1 {
+		data = data[:idx]
+	}
+	if len(data) == 0 || len(data) > 16 {
+		return 0, ErrInvalidObjectId
+	}
+
+	var id uint64
+	for _, c := range data {
+		switch {
+		case c >= '0' && c <= '9':
+			id = id<<4 | uint64(c-'0')
+		case c >= 'a' && c <= 'f':
+			id = id<<4 | uint64(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			id = id<<4 | uint64(c-'A'+10)
+		default:
+			return 0, ErrInvalidObjectId
+		}
+	}
+	return id, nil
+}
+
+// NextProperty advances to the next property of the current object line,
+//  returning false once every property was read or a property is malformed
+//  (in which case Err returns the error). Empty properties are skipped.
+func (s *Scanner) NextProperty() bool {
+	for len(s.rest) > 0 {
+		end := bytes.IndexByte(s.rest, ',')
+		if end == -1 {
+			end = len(s.rest)
+		}
+
+		s.escaped = bytes.IndexByte(s.rest[:end], '\\') != -1
+		if s.escaped {
+			end = propertyEnd(s.rest)
+		}
+
+		token := s.rest[:end]
+		s.keyOffset = len(s.line) - len(s.rest)
+		if end < len(s.rest) {
+			s.rest = s.rest[end+1:]
+		} else {
+			s.rest = nil
+		}
+
+		if len(token) == 0 {
+			continue
+		}
+
+		equals := bytes.IndexByte(token, '=')
+		if s.escaped {
+			equals = propertyEquals(token)
+		}
+		if equals == -1 {
+			s.err = fmt.Errorf("Failed to parse property: `%s`", token)
+			s.rest = nil
+			return false
+		}
+
+		s.key = token[:equals]
+		s.value = token[equals+1:]
+		return true
+	}
+	return false
+}
+
+// propertyEnd returns the position of the first unescaped comma in data
+func propertyEnd(data []byte) int {
+	for idx := 0; idx < len(data); idx++ {
+		if data[idx] == '\\' {
+			idx++
+		} else if data[idx] == ',' {
+			return idx
+		}
+	}
+	return len(data)
+}
+
+// propertyEquals returns the position of the first unescaped equals sign in
+//  token, or -1
+func propertyEquals(token []byte) int {
+	for idx := 0; idx < len(token); idx++ {
+		if token[idx] == '\\' {
+			idx++
+		} else if token[idx] == '=' {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Key returns the key of the current property
+func (s *Scanner) Key() []byte {
+	return s.key
+}
+
+// Value returns the raw (still escaped) value of the current property
+func (s *Scanner) Value() []byte {
+	return s.value
+}
+
+// ValueString returns the unescaped value of the current property
+func (s *Scanner) ValueString() string {
+	if !s.escaped {
+		return string(s.value)
+	}
+	return string(Unescape(nil, s.value))
+}
+
+// Unescape appends the unescaped form of a raw property value to dst
+func Unescape(dst []byte, value []byte) []byte {
+	for idx := 0; idx < len(value); idx++ {
+		if value[idx] == '\\' && idx+1 < len(value) {
+			idx++
+		}
+		dst = append(dst, value[idx])
+	}
+	return dst
+}
+
+// internKey returns the shared copy of a property key sliced from a line
+func (s *Scanner) internKey(key string) string {
+	if name, ok := s.keys[key]; ok {
+		return name
+	}
+	if len(s.keys) >= scannerKeyLimit {
+		return key
+	}
+
+	// The key is copied so it does not hold on to the whole line
+	name := string([]byte(key))
+	if s.keys == nil {
+		s.keys = make(map[string]string)
+	}
+	s.keys[name] = name
+	return name
+}
+
+// readProperties sets every property of the current object line on the
+//  object. Unescaped values share a single string allocated for the line, keys
+//  are interned and the properties themselves are allocated together.
+func (s *Scanner) readProperties(object *Object) error {
+	if len(s.rest) == 0 {
+		return nil
+	}
+
+	line := string(s.line)
+	properties := make([]Property, 0, bytes.Count(s.rest, []byte(","))+1)
+	if cap(object.Properties)-len(object.Properties) < cap(properties) {
+		grown := make([]*Property, len(object.Properties), len(object.Properties)+cap(properties))
+		copy(grown, object.Properties)
+		object.Properties = grown
+	}
+	for s.NextProperty() {
+		valueOffset := s.keyOffset + len(s.key) + 1
+		value := line[valueOffset : valueOffset+len(s.value)]
+		if s.escaped {
+			value = string(Unescape(nil, s.value))
+		}
+
+		key := s.internKey(line[s.keyOffset : valueOffset-1])
+		if idx := object.find(key); idx >= 0 {
+			object.Properties[idx].Value = value
+			continue
+		}
+
+		properties = append(properties, Property{Key: key, Value: value})
+		object.add(&properties[len(properties)-1])
+	}
+	return s.err
+}
@@ -0,0 +1,188 @@
+package tacview
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const scannerTestData = "FileType=text/acmi/tacview\n" +
+	"FileVersion=2.2\n" +
+	"0,ReferenceTime=2021-07-24T04:00:00Z,Comments=First\\\nSecond\n" +
+	"#1.5\n" +
+	"1a,T=1|2|3,Name=A\\, B,,Pilot=Test\n" +
+	"-1a\n" +
+	"#2"
+
+func TestScanner(t *testing.T) {
+	scanner := NewScanner(strings.NewReader(scannerTestData))
+
+	expected := []string{
+		"FileType=text/acmi/tacview",
+		"FileVersion=2.2",
+		"0,ReferenceTime=2021-07-24T04:00:00Z,Comments=First\\\nSecond",
+		"#1.5",
+		"1a,T=1|2|3,Name=A\\, B,,Pilot=Test",
+		"-1a",
+		"#2",
+	}
+
+	for _, line := range expected {
+		if !scanner.Scan() {
+			t.Fatalf("Expected line `%s`, scanning stopped: %v", line, scanner.Err())
+		}
+		if string(scanner.Bytes()) != line {
+			t.Fatalf("Line mismatch; expected `%s`, scanned `%s`.", line, scanner.Bytes())
+		}
+	}
+
+	if scanner.Scan() || scanner.Err() != nil {
+		t.Fatalf("Expected the end of input, found `%s` (%v)", scanner.Bytes(), scanner.Err())
+	}
+}
+
+func TestScannerTokens(t *testing.T) {
+	scanner := NewBytesScanner([]byte(scannerTestData))
+	for idx := 0; idx < 3; idx++ {
+		scanner.Scan()
+	}
+
+	if !scanner.NextProperty() || !scanner.NextProperty() {
+		t.Fatalf("Failed to read global properties: %v", scanner.Err())
+	}
+	if scanner.ValueString() != "First\nSecond" {
+		t.Fatalf("Multi-line value mismatch; scanned `%s`.", scanner.ValueString())
+	}
+
+	scanner.Scan()
+	if offset, err := scanner.Offset(); scanner.Kind() != TimeFrameLine || err != nil || offset != 1.5 {
+		t.Fatalf("Time frame mismatch; scanned %v (%v).", offset, err)
+	}
+
+	scanner.Scan()
+	if id, err := scanner.Id(); scanner.Kind() != ObjectLine || err != nil || id != 0x1a {
+		t.Fatalf("Object id mismatch; scanned %v (%v).", id, err)
+	}
+
+	properties := make([]string, 0)
+	for scanner.NextProperty() {
+		properties = append(properties, fmt.Sprintf("%s=%s", scanner.Key(), scanner.ValueString()))
+	}
+	if strings.Join(properties, ";") != "T=1|2|3;Name=A, B;Pilot=Test" {
+		t.Fatalf("Property mismatch; scanned %v.", properties)
+	}
+
+	scanner.Scan()
+	if id, err := scanner.Id(); scanner.Kind() != RemoveLine || err != nil || id != 0x1a {
+		t.Fatalf("Removal mismatch; scanned %v (%v).", id, err)
+	}
+}
+
+func TestScannerInvalid(t *testing.T) {
+	scanner := NewBytesScanner([]byte("zz,T=1|2|3\n1,Name"))
+
+	scanner.Scan()
+	if _, err := scanner.Id(); err != ErrInvalidObjectId {
+		t.Fatalf("Expected an invalid object id, found %v", err)
+	}
+
+	scanner.Scan()
+	if scanner.NextProperty() || scanner.Err() == nil {
+		t.Fatalf("Expected an error for a property without a value")
+	}
+}
+
+func TestScannerAllocations(t *testing.T) {
+	data := []byte(strings.Repeat("#12.5\n101,T=1|2|3|4|5|6,Name=F-16C_50,Pilot=Test\\, Pilot\n-102\n", 100))
+	scanner := NewBytesScanner(data)
+
+	allocations := testing.AllocsPerRun(10, func() {
+		scanner.Reset(data)
+		for scanner.Scan() {
+			switch scanner.Kind() {
+			case TimeFrameLine:
+				scanner.Offset()
+			default:
+				scanner.Id()
+				for scanner.NextProperty() {
+				}
+			}
+		}
+	})
+
+	if allocations != 0 {
+		t.Fatalf("Expected scanning to not allocate, found %v allocations", allocations)
+	}
+}
+
+func TestParserLastTimeFrame(t *testing.T) {
+	parser, err := NewParser(strings.NewReader(scannerTestData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = parser.ReadHeader(); err != nil {
+		t.Fatalf("Failed to read header: %v", err)
+	}
+
+	for _, expected := range []float64{1.5, 2} {
+		tf, err := parser.ReadRawTimeFrame(-1)
+		if err != nil {
+			t.Fatalf("Failed to read time frame %v: %v", expected, err)
+		}
+		if tf.Offset != expected {
+			t.Fatalf("Offset mismatch; expected %v, read %v.", expected, tf.Offset)
+		}
+	}
+}
+
+func benchmarkData() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("FileType=text/acmi/tacview\nFileVersion=2.2\n0,ReferenceTime=2021-07-24T04:00:00Z\n")
+	for frame := 0; frame < 10000; frame++ {
+		fmt.Fprintf(&buffer, "#%.2f\n", float64(frame)*0.2)
+		for object := 0; object < 20; object++ {
+			fmt.Fprintf(&buffer, "%x,T=%.7f|%.7f|%.2f|0.1|-2.3|%.1f,IAS=%.1f\n", 0x100+object, 1.5+float64(frame)*1e-5, 2.5, 3000.0, 90.0, 150.0)
+		}
+	}
+	return buffer.Bytes()
+}
+
+func BenchmarkScanner(b *testing.B) {
+	data := benchmarkData()
+	scanner := NewBytesScanner(data)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		scanner.Reset(data)
+		for scanner.Scan() {
+			for scanner.NextProperty() {
+			}
+		}
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	data := benchmarkData()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		reader, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		timeFrames := make(chan *TimeFrame)
+		go func() {
+			for range timeFrames {
+			}
+		}()
+		err = reader.ProcessTimeFrames(1, timeFrames)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
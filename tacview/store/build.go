@@ -0,0 +1,257 @@
+package store
+
+import (
+	"math"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// minSamplesPerBucket is the average number of samples per bucket an object
+//  needs before its bounds are indexed. Objects with fewer samples are cheap
+//  enough to search directly.
+const minSamplesPerBucket = 4
+
+// objectBuilder collects the samples and property changes of a single object
+type objectBuilder struct {
+	object *Object
+
+	transform     tacview.Transform
+	times         []float64
+	columns       [componentCount][]float64
+	changeOffsets []float64
+	changes       []uint32
+	// lastValues holds the last value id of each key, skipping updates which do
+	//  not change the value
+	lastValues map[uint32]uint32
+}
+
+type builder struct {
+	store    *Store
+	valueIds map[string]uint32
+	objects  []*objectBuilder
+	alive    map[uint64]*objectBuilder
+}
+
+// New loads the initial time frame and every remaining time frame of the reader
+//  into a store
+func New(reader *tacview.Reader) (*Store, error) {
+	b := &builder{
+		store: &Store{
+			ReferenceTime:      reader.Header.ReferenceTime,
+			ReferenceLongitude: reader.Header.ReferenceLongitude,
+			ReferenceLatitude:  reader.Header.ReferenceLatitude,
+			Start:              reader.Header.InitialTimeFrame.Offset,
+			End:                reader.Header.InitialTimeFrame.Offset,
+			BucketSize:         DefaultBucketSize,
+			ids:                make(map[uint64][]*Object),
+			keyIds:             make(map[string]uint32),
+		},
+		valueIds: make(map[string]uint32),
+		alive:    make(map[uint64]*objectBuilder),
+	}
+
+	err := b.apply(&reader.Header.InitialTimeFrame)
+	if err != nil {
+		return nil, err
+	}
+
+	for reader.Next() {
+		err = b.apply(reader.Frame())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if reader.Err() != nil {
+		return nil, reader.Err()
+	}
+
+	return b.finish(), nil
+}
+
+func (b *builder) apply(tf *tacview.TimeFrame) error {
+	b.store.End = math.Max(b.store.End, tf.Offset)
+
+	for _, object := range tf.Objects {
+		ob := b.alive[object.Id]
+		if object.Deleted {
+			if ob != nil {
+				ob.object.Removed = tf.Offset
+				delete(b.alive, object.Id)
+			}
+			continue
+		}
+
+		if ob == nil {
+			ob = &objectBuilder{
+				object: &Object{
+					Id:      object.Id,
+					Spawned: tf.Offset,
+					Removed: math.Inf(1),
+					store:   b.store,
+				},
+				lastValues: make(map[uint32]uint32),
+			}
+			b.objects = append(b.objects, ob)
+			b.alive[object.Id] = ob
+			b.store.ids[object.Id] = append(b.store.ids[object.Id], ob.object)
+		}
+
+		for _, property := range object.Properties {
+			if property.Key == "T" && object.Id != 0 {
+				err := ob.transform.Update(property.Value, b.store.ReferenceLongitude, b.store.ReferenceLatitude)
+				if err != nil {
+					return err
+				}
+				ob.sample(tf.Offset)
+				continue
+			}
+
+			ob.change(tf.Offset, b.keyId(property.Key), b.valueId(property.Value), property.Key == "Event")
+		}
+	}
+	return nil
+}
+
+func (b *builder) keyId(key string) uint32 {
+	id, ok := b.store.keyIds[key]
+	if !ok {
+		id = uint32(len(b.store.keys))
+		b.store.keyIds[key] = id
+		b.store.keys = append(b.store.keys, key)
+	}
+	return id
+}
+
+func (b *builder) valueId(value string) uint32 {
+	id, ok := b.valueIds[value]
+	if !ok {
+		id = uint32(len(b.store.values))
+		b.valueIds[value] = id
+		b.store.values = append(b.store.values, value)
+	}
+	return id
+}
+
+// sample records the current transform, replacing any sample already recorded
+//  for the same time frame
+func (ob *objectBuilder) sample(offset float64) {
+	components := [componentCount]float64{
+		ob.transform.Longitude,
+		ob.transform.Latitude,
+		ob.transform.Altitude,
+		ob.transform.Roll,
+		ob.transform.Pitch,
+		ob.transform.Yaw,
+		ob.transform.U,
+		ob.transform.V,
+		ob.transform.Heading,
+	}
+
+	if last := len(ob.times) - 1; last >= 0 && ob.times[last] == offset {
+		for component, value := range components {
+			ob.columns[component][last] = value
+		}
+		return
+	}
+
+	ob.times = append(ob.times, offset)
+	for component, value := range components {
+		ob.columns[component] = append(ob.columns[component], value)
+	}
+}
+
+// change records a property change. Events are always recorded, other
+//  properties only when their value changes.
+func (ob *objectBuilder) change(offset float64, key uint32, value uint32, event bool) {
+	if last, ok := ob.lastValues[key]; ok && last == value && !event {
+		return
+	}
+	ob.lastValues[key] = value
+
+	ob.changeOffsets = append(ob.changeOffsets, offset)
+	ob.changes = append(ob.changes, key, value)
+}
+
+// finish lays out the columns of every object within the store
+func (b *builder) finish() *Store {
+	s := b.store
+	s.objects = make([]*Object, len(b.objects))
+
+	for idx, ob := range b.objects {
+		object := ob.object
+		s.objects[idx] = object
+
+		object.samples = len(ob.times)
+		object.sampleStart = len(s.floats)
+		s.floats = append(s.floats, ob.times...)
+		if object.samples > 0 {
+			object.components = positionComponents
+		}
+		for component, column := range ob.columns {
+			if object.components&(1<<component) == 0 && !hasNonZero(column) {
+				continue
+			}
+			object.components |= 1 << component
+			s.floats = append(s.floats, column...)
+		}
+
+		object.changes = len(ob.changeOffsets)
+		object.changeStart = len(s.floats)
+		object.changeIdStart = len(s.changes) / 2
+		s.floats = append(s.floats, ob.changeOffsets...)
+		s.changes = append(s.changes, ob.changes...)
+
+		b.index(ob)
+	}
+
+	return s
+}
+
+// index stores the bounds of the objects positions within each bucket it is
+//  alive for. The bounds of a bucket include the position held from before it.
+func (b *builder) index(ob *objectBuilder) {
+	s, object := b.store, ob.object
+	if object.samples == 0 {
+		return
+	}
+
+	first := s.bucket(ob.times[0])
+	last := s.bucket(math.Min(object.Removed, s.End))
+	if object.samples < (last-first+1)*minSamplesPerBucket {
+		return
+	}
+
+	latitudes, longitudes := ob.columns[componentLatitude], ob.columns[componentLongitude]
+	object.firstBucket = first
+	object.buckets = last - first + 1
+	object.boundsStart = len(s.floats)
+
+	held, next := -1, 0
+	for bucket := first; bucket <= last; bucket++ {
+		bounds := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		include := func(idx int) {
+			bounds[0] = math.Min(bounds[0], latitudes[idx])
+			bounds[1] = math.Min(bounds[1], longitudes[idx])
+			bounds[2] = math.Max(bounds[2], latitudes[idx])
+			bounds[3] = math.Max(bounds[3], longitudes[idx])
+		}
+
+		if held >= 0 {
+			include(held)
+		}
+		for ; next < object.samples && s.bucket(ob.times[next]) == bucket; next++ {
+			include(next)
+			held = next
+		}
+		s.floats = append(s.floats, bounds[:]...)
+	}
+}
+
+func hasNonZero(values []float64) bool {
+	for _, value := range values {
+		if value != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"time"
+)
+
+// fileMagic starts every store cache file
+const fileMagic = "JAMBSTOR"
+
+// fileVersion is increased whenever the layout of cache files changes
+const fileVersion = 1
+
+// A cache file contains the header, the key and value strings (each prefixed
+//  with its uint32 length), the object records, padding up to a multiple of
+//  eight bytes and finally the floats and changes columns. Everything is little
+//  endian so the columns can be used directly from a memory mapping.
+type fileHeader struct {
+	Magic                [8]byte
+	Version              uint32
+	Objects              uint32
+	ReferenceTimeSeconds int64
+	ReferenceTimeNanos   int64
+	ReferenceLongitude   float64
+	ReferenceLatitude    float64
+	Start                float64
+	End                  float64
+	BucketSize           float64
+	Keys                 uint32
+	Values               uint32
+	StringsSize          uint64
+	Floats               uint64
+	Changes              uint64
+}
+
+type objectRecord struct {
+	Id            uint64
+	Spawned       float64
+	Removed       float64
+	Components    uint32
+	Samples       uint32
+	SampleStart   uint64
+	Changes       uint32
+	FirstBucket   int32
+	ChangeStart   uint64
+	ChangeIdStart uint64
+	Buckets       uint32
+	Padding       uint32
+	BoundsStart   uint64
+}
+
+var (
+	fileHeaderSize   = binary.Size(fileHeader{})
+	objectRecordSize = binary.Size(objectRecord{})
+)
+
+// ErrInvalidFile is returned when opening a file which is not a store cache
+//  file, was written by another version or is truncated
+var ErrInvalidFile = errors.New("Invalid store cache file")
+
+// Save writes the store to a cache file
+func (s *Store) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.WriteTo(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteTo writes the store in the cache file format
+func (s *Store) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{writer: w}
+	writer := bufio.NewWriter(counter)
+
+	stringsSize := 0
+	for _, value := range append(append([]string{}, s.keys...), s.values...) {
+		stringsSize += 4 + len(value)
+	}
+
+	header := fileHeader{
+		Version:              fileVersion,
+		Objects:              uint32(len(s.objects)),
+		ReferenceTimeSeconds: s.ReferenceTime.Unix(),
+		ReferenceTimeNanos:   int64(s.ReferenceTime.Nanosecond()),
+		ReferenceLongitude:   s.ReferenceLongitude,
+		ReferenceLatitude:    s.ReferenceLatitude,
+		Start:                s.Start,
+		End:                  s.End,
+		BucketSize:           s.BucketSize,
+		Keys:                 uint32(len(s.keys)),
+		Values:               uint32(len(s.values)),
+		StringsSize:          uint64(stringsSize),
+		Floats:               uint64(len(s.floats)),
+		Changes:              uint64(len(s.changes)),
+	}
+	copy(header.Magic[:], fileMagic)
+
+	err := binary.Write(writer, binary.LittleEndian, &header)
+	if err != nil {
+		return counter.count, err
+	}
+
+	var buffer [8]byte
+	for _, table := range [][]string{s.keys, s.values} {
+		for _, value := range table {
+			binary.LittleEndian.PutUint32(buffer[:], uint32(len(value)))
+			writer.Write(buffer[:4])
+			writer.WriteString(value)
+		}
+	}
+
+	records := make([]objectRecord, len(s.objects))
+	for idx, object := range s.objects {
+		records[idx] = objectRecord{
+			Id:            object.Id,
+			Spawned:       object.Spawned,
+			Removed:       object.Removed,
+			Components:    object.components,
+			Samples:       uint32(object.samples),
+			SampleStart:   uint64(object.sampleStart),
+			Changes:       uint32(object.changes),
+			FirstBucket:   int32(object.firstBucket),
+			ChangeStart:   uint64(object.changeStart),
+			ChangeIdStart: uint64(object.changeIdStart),
+			Buckets:       uint32(object.buckets),
+			BoundsStart:   uint64(object.boundsStart),
+		}
+	}
+	err = binary.Write(writer, binary.LittleEndian, records)
+	if err != nil {
+		return counter.count, err
+	}
+
+	writer.Write(make([]byte, padding(fileHeaderSize+stringsSize+len(records)*objectRecordSize)))
+
+	for _, value := range s.floats {
+		binary.LittleEndian.PutUint64(buffer[:], math.Float64bits(value))
+		writer.Write(buffer[:])
+	}
+	for _, value := range s.changes {
+		binary.LittleEndian.PutUint32(buffer[:], value)
+		writer.Write(buffer[:4])
+	}
+
+	err = writer.Flush()
+	return counter.count, err
+}
+
+// Open memory-maps a cache file where supported, otherwise the file is read
+//  into memory. The store must be closed once it is no longer used.
+func Open(path string) (*Store, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := decode(data)
+	if err != nil {
+		unmap()
+		return nil, fmt.Errorf("Failed to open store '%v': %v", path, err)
+	}
+	s.close = unmap
+	return s, nil
+}
+
+// readFile reads the whole file into memory, for files which cannot be mapped
+func readFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
+
+// decode creates a store using the columns within the data
+func decode(data []byte) (*Store, error) {
+	if len(data) < fileHeaderSize {
+		return nil, ErrInvalidFile
+	}
+
+	var header fileHeader
+	err := binary.Read(bytes.NewReader(data[:fileHeaderSize]), binary.LittleEndian, &header)
+	if err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != fileMagic || header.Version != fileVersion {
+		return nil, ErrInvalidFile
+	}
+
+	recordsStart := uint64(fileHeaderSize) + header.StringsSize
+	floatsStart := recordsStart + uint64(header.Objects)*uint64(objectRecordSize)
+	floatsStart += uint64(padding(int(floatsStart % 8)))
+	changesStart := floatsStart + header.Floats*8
+	if header.StringsSize > uint64(len(data)) || header.Floats > uint64(len(data)) || header.Changes > uint64(len(data)) ||
+		changesStart+header.Changes*4 != uint64(len(data)) {
+		return nil, ErrInvalidFile
+	}
+
+	s := &Store{
+		ReferenceTime:      time.Unix(header.ReferenceTimeSeconds, header.ReferenceTimeNanos).UTC(),
+		ReferenceLongitude: header.ReferenceLongitude,
+		ReferenceLatitude:  header.ReferenceLatitude,
+		Start:              header.Start,
+		End:                header.End,
+		BucketSize:         header.BucketSize,
+		ids:                make(map[uint64][]*Object),
+		keyIds:             make(map[string]uint32),
+		floats:             float64View(data[floatsStart:changesStart]),
+		changes:            uint32View(data[changesStart:]),
+	}
+
+	table := data[fileHeaderSize:recordsStart]
+	s.keys, table, err = readStrings(table, int(header.Keys))
+	if err != nil {
+		return nil, err
+	}
+	s.values, table, err = readStrings(table, int(header.Values))
+	if err != nil {
+		return nil, err
+	}
+	if len(table) != 0 {
+		return nil, ErrInvalidFile
+	}
+	for idx, key := range s.keys {
+		s.keyIds[key] = uint32(idx)
+	}
+
+	// Changes are key and value id pairs
+	if len(s.changes)%2 != 0 {
+		return nil, ErrInvalidFile
+	}
+	for idx := 0; idx < len(s.changes); idx += 2 {
+		if int(s.changes[idx]) >= len(s.keys) || int(s.changes[idx+1]) >= len(s.values) {
+			return nil, ErrInvalidFile
+		}
+	}
+
+	records := make([]objectRecord, header.Objects)
+	err = binary.Read(bytes.NewReader(data[recordsStart:floatsStart]), binary.LittleEndian, records)
+	if err != nil {
+		return nil, err
+	}
+
+	s.objects = make([]*Object, len(records))
+	for idx, record := range records {
+		object := &Object{
+			Id:            record.Id,
+			Spawned:       record.Spawned,
+			Removed:       record.Removed,
+			store:         s,
+			components:    record.Components,
+			samples:       int(record.Samples),
+			sampleStart:   int(record.SampleStart),
+			changes:       int(record.Changes),
+			changeStart:   int(record.ChangeStart),
+			changeIdStart: int(record.ChangeIdStart),
+			firstBucket:   int(record.FirstBucket),
+			buckets:       int(record.Buckets),
+			boundsStart:   int(record.BoundsStart),
+		}
+		if !s.valid(object) {
+			return nil, ErrInvalidFile
+		}
+
+		s.objects[idx] = object
+		s.ids[object.Id] = append(s.ids[object.Id], object)
+	}
+	return s, nil
+}
+
+// valid returns whether every column of the object lies within the store
+func (s *Store) valid(o *Object) bool {
+	columns := 1 + bits.OnesCount32(o.components)
+	return o.components < 1<<componentCount &&
+		o.sampleStart >= 0 && o.sampleStart+o.samples*columns <= len(s.floats) &&
+		o.changeStart >= 0 && o.changeStart+o.changes <= len(s.floats) &&
+		o.changeIdStart >= 0 && (o.changeIdStart+o.changes)*2 <= len(s.changes) &&
+		o.boundsStart >= 0 && o.boundsStart+o.buckets*4 <= len(s.floats)
+}
+
+// readStrings reads count length prefixed strings, returning the remaining data
+func readStrings(data []byte, count int) ([]string, []byte, error) {
+	if count > len(data)/4 {
+		return nil, nil, ErrInvalidFile
+	}
+
+	result := make([]string, count)
+	for idx := range result {
+		if len(data) < 4 {
+			return nil, nil, ErrInvalidFile
+		}
+		length := binary.LittleEndian.Uint32(data)
+		if uint64(len(data)-4) < uint64(length) {
+			return nil, nil, ErrInvalidFile
+		}
+		result[idx] = string(data[4 : 4+length])
+		data = data[4+length:]
+	}
+	return result, data, nil
+}
+
+// padding returns the number of bytes needed to align the size to eight bytes
+func padding(size int) int {
+	return (8 - size%8) % 8
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,258 @@
+// Package store loads a recording once into a compact columnar structure which
+//  answers point in time queries without re-parsing the ACMI file. Each object
+//  keeps a time series of its decoded transform components and the changes of
+//  its other properties, with keys and values interned. Stores can be saved to
+//  and memory-mapped from a binary cache file.
+package store
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+// Transform components in the order they are stored
+const (
+	componentLongitude = iota
+	componentLatitude
+	componentAltitude
+	componentRoll
+	componentPitch
+	componentYaw
+	componentU
+	componentV
+	componentHeading
+	componentCount
+)
+
+// positionComponents are stored for every object with a transform
+const positionComponents = 1<<componentLongitude | 1<<componentLatitude | 1<<componentAltitude
+
+// DefaultBucketSize is the length (in seconds) of the time buckets which bound
+//  the positions of each object for spatial queries
+const DefaultBucketSize = 30.0
+
+// Store is a columnar recording. Stores are safe for concurrent queries.
+type Store struct {
+	ReferenceTime      time.Time
+	ReferenceLongitude float64
+	ReferenceLatitude  float64
+	// Offsets of the first and last time frames
+	Start float64
+	End   float64
+	// BucketSize is the length (in seconds) of each spatial index bucket
+	BucketSize float64
+
+	objects []*Object
+	ids     map[uint64][]*Object
+	keys    []string
+	keyIds  map[string]uint32
+	values  []string
+	// floats holds every sample time, transform component, change offset and
+	//  bucket bound
+	floats []float64
+	// changes holds a key and value id pair for every property change
+	changes []uint32
+	// close releases the memory mapping the store was opened from
+	close func() error
+}
+
+// Object is the recorded lifetime of a single ACMI object. An id reused after
+//  its object was removed results in a separate Object.
+type Object struct {
+	Id uint64
+	// Offset of the time frame the object was first seen in
+	Spawned float64
+	// Offset of the time frame the object was removed in, or +Inf if it was
+	//  never removed
+	Removed float64
+
+	store      *Store
+	components uint32
+	// samples are stored as a column of times followed by a column for each
+	//  component, all starting at sampleStart within the store's floats
+	samples     int
+	sampleStart int
+	// change offsets start at changeStart within the store's floats and their
+	//  key and value pairs at 2*changeIdStart within the store's changes
+	changes       int
+	changeStart   int
+	changeIdStart int
+	// bounds are stored as min latitude, min longitude, max latitude and max
+	//  longitude for each bucket starting with firstBucket
+	firstBucket int
+	buckets     int
+	boundsStart int
+}
+
+// Position is the transform of an object at a point in time
+type Position struct {
+	Object    *Object
+	Transform tacview.Transform
+}
+
+// Close releases the file the store was opened from, after which the store must
+//  not be used
+func (s *Store) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	err := s.close()
+	s.close = nil
+	return err
+}
+
+// Objects returns every object in the order they were first seen
+func (s *Store) Objects() []*Object {
+	return s.objects
+}
+
+// Lookup returns the object with the given id alive at the offset, or nil
+func (s *Store) Lookup(id uint64, offset float64) *Object {
+	for _, object := range s.ids[id] {
+		if object.AliveAt(offset) {
+			return object
+		}
+	}
+	return nil
+}
+
+// TransformAt returns the transform of the object with the given id at the offset
+func (s *Store) TransformAt(id uint64, offset float64) (tacview.Transform, bool) {
+	object := s.Lookup(id, offset)
+	if object == nil {
+		return tacview.Transform{}, false
+	}
+	return object.TransformAt(offset)
+}
+
+// Alive returns every object alive at the offset
+func (s *Store) Alive(offset float64) []*Object {
+	result := make([]*Object, 0)
+	for _, object := range s.objects {
+		if object.AliveAt(offset) {
+			result = append(result, object)
+		}
+	}
+	return result
+}
+
+// InBox returns the position of every object within the bounding box at the
+//  offset. Objects are skipped without searching their samples when the bounds
+//  of their positions during the surrounding bucket do not intersect the box.
+func (s *Store) InBox(offset, minLatitude, minLongitude, maxLatitude, maxLongitude float64) []Position {
+	result := make([]Position, 0)
+	bucket := s.bucket(offset)
+	for _, object := range s.objects {
+		if object.samples == 0 || !object.AliveAt(offset) {
+			continue
+		}
+
+		if idx := bucket - object.firstBucket; idx >= 0 && idx < object.buckets {
+			bounds := s.floats[object.boundsStart+idx*4 : object.boundsStart+idx*4+4]
+			if bounds[0] > maxLatitude || bounds[2] < minLatitude || bounds[1] > maxLongitude || bounds[3] < minLongitude {
+				continue
+			}
+		}
+
+		transform, ok := object.TransformAt(offset)
+		if !ok {
+			continue
+		}
+		if transform.Latitude >= minLatitude && transform.Latitude <= maxLatitude &&
+			transform.Longitude >= minLongitude && transform.Longitude <= maxLongitude {
+			result = append(result, Position{Object: object, Transform: transform})
+		}
+	}
+	return result
+}
+
+// bucket returns the index of the bucket containing the offset
+func (s *Store) bucket(offset float64) int {
+	return int(math.Floor((offset - s.Start) / s.BucketSize))
+}
+
+// AliveAt returns whether the object exists at the offset
+func (o *Object) AliveAt(offset float64) bool {
+	return o.Spawned <= offset && offset < o.Removed
+}
+
+// Samples returns the number of transform samples of the object
+func (o *Object) Samples() int {
+	return o.samples
+}
+
+// Sample returns the offset and full transform of a sample
+func (o *Object) Sample(idx int) (float64, tacview.Transform) {
+	return o.store.floats[o.sampleStart+idx], o.transform(idx)
+}
+
+// TransformAt returns the transform of the object at the offset, which is the
+//  last transform recorded at or before it
+func (o *Object) TransformAt(offset float64) (tacview.Transform, bool) {
+	times := o.store.floats[o.sampleStart : o.sampleStart+o.samples]
+	idx := sort.Search(len(times), func(i int) bool { return times[i] > offset }) - 1
+	if idx < 0 || !o.AliveAt(offset) {
+		return tacview.Transform{}, false
+	}
+	return o.transform(idx), true
+}
+
+func (o *Object) transform(idx int) tacview.Transform {
+	var components [componentCount]float64
+	column := o.sampleStart + o.samples
+	for component := 0; component < componentCount; component++ {
+		if o.components&(1<<component) == 0 {
+			continue
+		}
+		components[component] = o.store.floats[column+idx]
+		column += o.samples
+	}
+
+	return tacview.Transform{
+		Longitude: components[componentLongitude],
+		Latitude:  components[componentLatitude],
+		Altitude:  components[componentAltitude],
+		Roll:      components[componentRoll],
+		Pitch:     components[componentPitch],
+		Yaw:       components[componentYaw],
+		U:         components[componentU],
+		V:         components[componentV],
+		Heading:   components[componentHeading],
+	}
+}
+
+// Property returns the value of a property at the offset. Transforms are not
+//  stored as properties, use TransformAt instead.
+func (o *Object) Property(key string, offset float64) (string, bool) {
+	keyId, ok := o.store.keyIds[key]
+	if !ok {
+		return "", false
+	}
+
+	changes := o.store.changes[o.changeIdStart*2 : (o.changeIdStart+o.changes)*2]
+	for idx := o.changesUntil(offset) - 1; idx >= 0; idx-- {
+		if changes[idx*2] == keyId {
+			return o.store.values[changes[idx*2+1]], true
+		}
+	}
+	return "", false
+}
+
+// Properties returns the value of every property at the offset
+func (o *Object) Properties(offset float64) map[string]string {
+	result := make(map[string]string)
+	changes := o.store.changes[o.changeIdStart*2 : (o.changeIdStart+o.changes)*2]
+	for idx := 0; idx < o.changesUntil(offset); idx++ {
+		result[o.store.keys[changes[idx*2]]] = o.store.values[changes[idx*2+1]]
+	}
+	return result
+}
+
+// changesUntil returns the number of property changes made at or before the offset
+func (o *Object) changesUntil(offset float64) int {
+	offsets := o.store.floats[o.changeStart : o.changeStart+o.changes]
+	return sort.Search(len(offsets), func(i int) bool { return offsets[i] > offset })
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/b1naryth1ef/jambon/tacview"
+)
+
+const storeTestHeader = "FileType=text/acmi/tacview\nFileVersion=2.2\n" +
+	"0,ReferenceTime=2021-07-24T04:00:00Z,ReferenceLongitude=40,ReferenceLatitude=41\n" +
+	"50,T=0.5|0.5|10,Type=Ground+Static+Aerodrome,Name=Field\n"
+
+// storeTestData creates a recording of objects flying in circles, leaving and
+//  re-using their ids, with occasional property changes
+func storeTestData(timeFrames int) string {
+	var builder strings.Builder
+	builder.WriteString(storeTestHeader)
+	for i := 1; i <= timeFrames; i++ {
+		fmt.Fprintf(&builder, "#%v\n", float64(i)/2)
+		for id := 1; id <= 20; id++ {
+			angle := float64(i*id) / 100
+			switch {
+			case (i+id)%97 == 0:
+				fmt.Fprintf(&builder, "-%x\n", id)
+			case id%3 == 0:
+				fmt.Fprintf(&builder, "%x,T=%.4f|%.4f|%v||%v|\n", id, math.Cos(angle), math.Sin(angle), 1000+i, i%360)
+			default:
+				fmt.Fprintf(&builder, "%x,T=%.4f|%.4f|%v,Name=Object %v,Fuel=%v\n", id, math.Cos(angle), math.Sin(angle), 1000+i, id, (i/10)%4)
+			}
+		}
+		if i%25 == 0 {
+			fmt.Fprintf(&builder, "0,Event=Message|1|Frame %v\n", i)
+		}
+	}
+	return builder.String()
+}
+
+func newTestStore(t *testing.T, data string) *Store {
+	reader, err := tacview.NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// compareWithWorld checks every query of the store against the state of a
+//  world at each time frame
+func compareWithWorld(t *testing.T, s *Store, data string) {
+	reader, err := tacview.NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	world := tacview.NewWorld(&reader.Header)
+	check := func(tf *tacview.TimeFrame) {
+		err := world.Apply(tf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		alive := s.Alive(tf.Offset)
+		if len(alive) != len(world.Objects)+1 {
+			t.Fatalf("Expected %v objects alive at %v, found %v.", len(world.Objects)+1, tf.Offset, len(alive))
+		}
+
+		for id, state := range world.Objects {
+			object := s.Lookup(id, tf.Offset)
+			if object == nil {
+				t.Fatalf("Object %v is missing at %v.", id, tf.Offset)
+			}
+
+			transform, ok := s.TransformAt(id, tf.Offset)
+			if ok != state.HasTransform || transform != state.Transform {
+				t.Fatalf("Object %v at %v has transform %+v, expected %+v.", id, tf.Offset, transform, state.Transform)
+			}
+
+			properties := object.Properties(tf.Offset)
+			for _, property := range state.Object.Properties {
+				if property.Key != "T" && properties[property.Key] != property.Value {
+					t.Fatalf("Object %v at %v has %v=%v, expected %v.", id, tf.Offset, property.Key, properties[property.Key], property.Value)
+				}
+			}
+			if value, _ := object.Property("Name", tf.Offset); value != properties["Name"] {
+				t.Fatalf("Object %v at %v has name %q, expected %q.", id, tf.Offset, value, properties["Name"])
+			}
+		}
+
+		positions := s.InBox(tf.Offset, 0, -0.5, 0.6, 0.6)
+		found := make([]string, 0, len(positions))
+		for _, position := range positions {
+			found = append(found, fmt.Sprint(position.Object.Id))
+		}
+		expected := make([]string, 0)
+		for id, state := range world.Objects {
+			if state.HasTransform && state.Transform.Latitude >= 0 && state.Transform.Latitude <= 0.6 &&
+				state.Transform.Longitude >= -0.5 && state.Transform.Longitude <= 0.6 {
+				expected = append(expected, fmt.Sprint(id))
+			}
+		}
+		sort.Strings(found)
+		sort.Strings(expected)
+		if strings.Join(found, ",") != strings.Join(expected, ",") {
+			t.Fatalf("Expected objects %v in box at %v, found %v.", expected, tf.Offset, found)
+		}
+	}
+
+	check(&reader.Header.InitialTimeFrame)
+	for reader.Next() {
+		check(reader.Frame())
+	}
+	if reader.Err() != nil {
+		t.Fatal(reader.Err())
+	}
+}
+
+func TestStoreMatchesWorld(t *testing.T) {
+	data := storeTestData(400)
+	s := newTestStore(t, data)
+	if s.Start != 0 || s.End != 200 {
+		t.Fatalf("Expected offsets 0 to 200, found %v to %v.", s.Start, s.End)
+	}
+	compareWithWorld(t, s, data)
+}
+
+func TestStoreSaveOpen(t *testing.T) {
+	data := storeTestData(400)
+	path := filepath.Join(t.TempDir(), "test.store")
+
+	err := newTestStore(t, data).Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.ReferenceTime.Format("2006-01-02T15:04:05Z") != "2021-07-24T04:00:00Z" || s.ReferenceLongitude != 40 {
+		t.Fatalf("Header was not restored: %v %v", s.ReferenceTime, s.ReferenceLongitude)
+	}
+	compareWithWorld(t, s, data)
+}
+
+func TestStoreReusedIds(t *testing.T) {
+	s := newTestStore(t, storeTestHeader+"#1\n1,T=1|2|3,Name=A\n#2\n-1\n#3\n1,T=4|5|6,Name=B\n")
+
+	if objects := s.Objects(); len(objects) != 4 {
+		t.Fatalf("Expected 4 objects, found %v.", len(objects))
+	}
+	if object := s.Lookup(1, 1.5); object == nil || object.Removed != 2 {
+		t.Fatal("Expected the first object to be removed at 2")
+	}
+	if s.Lookup(1, 2) != nil {
+		t.Fatal("Expected no object after it was removed")
+	}
+	if name, _ := s.Lookup(1, 3).Property("Name", 3); name != "B" {
+		t.Fatalf("Expected the re-created object, found %q.", name)
+	}
+	if transform, _ := s.TransformAt(1, 10); transform.Longitude != 44 || transform.Altitude != 6 {
+		t.Fatalf("Unexpected transform %+v.", transform)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	var buffer strings.Builder
+	_, err := newTestStore(t, storeTestData(10)).WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file ends with the value id of the last property change
+	corrupted := []byte(buffer.String())
+	binary.LittleEndian.PutUint32(corrupted[len(corrupted)-4:], math.MaxUint32)
+
+	for _, data := range []string{"", "JAMBSTOR", buffer.String()[:buffer.Len()-1], string(corrupted)} {
+		_, err = decode([]byte(data))
+		if err != ErrInvalidFile {
+			t.Fatalf("Expected an invalid file error, found %v.", err)
+		}
+	}
+}
+
+func BenchmarkInBox(b *testing.B) {
+	reader, err := tacview.NewReader(strings.NewReader(storeTestData(2000)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	s, err := New(reader)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		s.InBox(float64(i%1000), 0, -0.5, 0.6, 0.6)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// littleEndian is whether the native byte order matches the cache file format
+var littleEndian = func() bool {
+	value := uint16(1)
+	return *(*byte)(unsafe.Pointer(&value)) == 1
+}()
+
+// float64View returns the little endian floats within the data, which are used
+//  in place when the native byte order and alignment allow it
+func float64View(data []byte) []float64 {
+	count := len(data) / 8
+	if count == 0 {
+		return nil
+	}
+
+	if littleEndian && uintptr(unsafe.Pointer(&data[0]))%8 == 0 {
+		var view []float64
+		header := (*reflect.SliceHeader)(unsafe.Pointer(&view))
+		header.Data = uintptr(unsafe.Pointer(&data[0]))
+		header.Len = count
+		header.Cap = count
+		return view
+	}
+
+	result := make([]float64, count)
+	for idx := range result {
+		result[idx] = math.Float64frombits(binary.LittleEndian.Uint64(data[idx*8:]))
+	}
+	return result
+}
+
+// uint32View returns the little endian integers within the data, which are used
+//  in place when the native byte order and alignment allow it
+func uint32View(data []byte) []uint32 {
+	count := len(data) / 4
+	if count == 0 {
+		return nil
+	}
+
+	if littleEndian && uintptr(unsafe.Pointer(&data[0]))%4 == 0 {
+		var view []uint32
+		header := (*reflect.SliceHeader)(unsafe.Pointer(&view))
+		header.Data = uintptr(unsafe.Pointer(&data[0]))
+		header.Len = count
+		header.Cap = count
+		return view
+	}
+
+	result := make([]uint32, count)
+	for idx := range result {
+		result[idx] = binary.LittleEndian.Uint32(data[idx*4:])
+	}
+	return result
+}
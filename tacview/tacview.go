@@ -2,70 +2,49 @@ package tacview
 
 import (
 	"bufio"
-	"bytes"
-	"errors"
 	"fmt"
 	"io"
-	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
-
-	"github.com/spkg/bom"
 )
 
 var bomHeader = []byte{0xef, 0xbb, 0xbf}
-var keyRe = regexp.MustCompilePOSIX("^(.*)=(.*)$")
-
-func splitPropertyTokens(s string) (tokens []string, err error) {
-	var runes []rune
-	inEscape := false
-	for _, r := range s {
-		switch {
-		case inEscape:
-			inEscape = false
-			fallthrough
-		default:
-			runes = append(runes, r)
-		case r == '\\':
-			inEscape = true
-		case r == ',':
-			tokens = append(tokens, string(runes))
-			runes = runes[:0]
-		}
-	}
-	tokens = append(tokens, string(runes))
-	if inEscape {
-		err = errors.New("invalid escape")
-	}
-	return tokens, err
-}
 
 // Header describes a ACMI file header
 type Header struct {
-	FileType         string
-	FileVersion      string
-	ReferenceTime    time.Time
-	InitialTimeFrame TimeFrame
+	FileType           string
+	FileVersion        string
+	ReferenceTime      time.Time
+	ReferenceLongitude float64
+	ReferenceLatitude  float64
+	InitialTimeFrame   TimeFrame
 }
 
 // Reader provides an interface for reading an ACMI file
 type Reader struct {
 	Header Header
-	reader *bufio.Reader
+	parser *Parser
 }
 
 // Writer provides an interface for writing an ACMI file
 type Writer struct {
-	writer *bufio.Writer
-	closer io.Closer
+	writer  *bufio.Writer
+	closer  io.Closer
+	encoder encoder
 }
 
-// TimeFrame represents a single time frame from an ACMI file
+// TimeFrame represents a single time frame from an ACMI file. Objects are
+//  indexed by id once first looked up, the index is rebuilt whenever Objects is
+//  assigned or changes length. Objects replaced in place within the slice must
+//  be replaced through Add instead.
 type TimeFrame struct {
 	Offset  float64
 	Objects []*Object
+	// index maps object ids to their position within Objects
+	index map[uint64]int
+	// indexed is the slice of Objects the index was built for
+	indexed []*Object
 }
 
 // RawTimeFrame represents a raw time frame that has not been parsed yet
@@ -80,33 +59,61 @@ type Property struct {
 	Value string
 }
 
-// Object describes an ACMI object
+// Object describes an ACMI object. Properties are indexed by key once an object
+//  has more than a few, the index is rebuilt whenever Properties is assigned or
+//  changes length. Properties must be renamed through Rename rather than by
+//  changing their key.
 type Object struct {
 	Id         uint64
 	Properties []*Property
 	Deleted    bool
+	// index maps property keys to their position within Properties
+	index map[string]int
+	// indexed is the slice of Properties the index was built for
+	indexed []*Property
 }
 
+// objectIndexThreshold is the number of properties an object holds before
+//  lookups use an index instead of comparing every key
+const objectIndexThreshold = 8
+
 func (r *RawTimeFrame) Parse() (*TimeFrame, error) {
 	timeFrame := NewTimeFrame()
 	timeFrame.Offset = r.Offset
+
+	scanner := &Scanner{}
+	objects := make(map[uint64]*Object)
 	for _, line := range r.Contents {
-		object, err := parseObjectLine(line)
+		scanner.Reset([]byte(line))
+		if !scanner.Scan() {
+			continue
+		}
+
+		err := applyObjectLine(scanner, timeFrame, objects)
 		if err != nil {
 			return nil, err
 		}
-
-		timeFrame.Objects = append(timeFrame.Objects, object)
 	}
 
 	return timeFrame, nil
 }
 
-// NewTimeFrame creates an empty TimeFrame
-func NewTimeFrame() *TimeFrame {
-	return &TimeFrame{
-		Objects: make([]*Object, 0),
+// NewTimeFrame creates a TimeFrame containing the given objects
+func NewTimeFrame(objects ...*Object) *TimeFrame {
+	tf := &TimeFrame{Objects: make([]*Object, 0, len(objects))}
+	for _, object := range objects {
+		tf.Add(object)
 	}
+	return tf
+}
+
+// NewObject creates an object with the given properties
+func NewObject(id uint64, properties ...Property) *Object {
+	object := &Object{Id: id, Properties: make([]*Property, 0, len(properties))}
+	for _, property := range properties {
+		object.Set(property.Key, property.Value)
+	}
+	return object
 }
 
 // NewWriter creates a new ACMI writer
@@ -120,9 +127,18 @@ func NewWriter(writer io.WriteCloser, header *Header) (*Writer, error) {
 
 // NewReader creates a new ACMI reader
 func NewReader(reader io.Reader) (*Reader, error) {
-	r := &Reader{reader: bufio.NewReader(bom.NewReader(reader))}
-	err := r.readHeader()
-	return r, err
+	parser, err := NewParser(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{parser: parser}
+	header, err := parser.ReadHeader()
+	if err != nil {
+		return r, err
+	}
+	r.Header = *header
+	return r, nil
 }
 
 // Close closes the writer, flushing any remaining contents
@@ -145,61 +161,118 @@ func (w *Writer) writeHeader(header *Header) error {
 
 // WriteTimeFrame writes a time frame
 func (w *Writer) WriteTimeFrame(tf *TimeFrame) error {
-	return tf.Write(w.writer, true)
+	w.encoder.timeFrame(tf, true)
+	return w.encoder.flush(w.writer)
 }
 
 func (h *Header) Write(writer *bufio.Writer) error {
-	_, err := writer.WriteString("FileType=text/acmi/tacview\nFileVersion=2.2\n")
+	e := getEncoder()
+	defer encoders.Put(e)
+
+	e.header("text/acmi/tacview", "2.2")
+	e.timeFrame(&h.InitialTimeFrame, false)
+	err := e.flush(writer)
 	if err != nil {
 		return err
 	}
 
-	h.InitialTimeFrame.Write(writer, false)
-
 	return writer.Flush()
 }
 
 // Get returns an object (if one exists) for a given object id
 func (tf *TimeFrame) Get(id uint64) *Object {
-	for _, object := range tf.Objects {
-		if object.Id == id {
-			return object
-		}
+	idx := tf.find(id)
+	if idx < 0 {
+		return nil
 	}
-	return nil
+	return tf.Objects[idx]
+}
+
+// Add adds an object to the end of the time frame, replacing any object with
+//  the same id in place
+func (tf *TimeFrame) Add(object *Object) {
+	if idx := tf.find(object.Id); idx >= 0 {
+		tf.Objects[idx] = object
+		return
+	}
+
+	tf.Objects = append(tf.Objects, object)
+	tf.index[object.Id] = len(tf.Objects) - 1
+	tf.indexed = tf.Objects
 }
 
-// Delete removes an object (if one exists) for a given object id
+// Delete removes an object (if one exists) for a given object id. A new slice
+//  is used so a loop over Objects still visits every object, use Filter to
+//  remove many objects at once.
 func (tf *TimeFrame) Delete(id uint64) {
-	for idx, object := range tf.Objects {
-		if object.Id == id {
-			tf.Objects = append(tf.Objects[:idx], tf.Objects[idx+1:]...)
-			break
+	idx := tf.find(id)
+	if idx < 0 {
+		return
+	}
+
+	objects := make([]*Object, 0, len(tf.Objects)-1)
+	objects = append(objects, tf.Objects[:idx]...)
+	tf.Objects = append(objects, tf.Objects[idx+1:]...)
+	tf.index, tf.indexed = nil, nil
+}
+
+// Filter removes every object for which keep returns false, keeping the order
+//  of the remaining objects. A new slice is used so a loop over Objects still
+//  visits every object.
+func (tf *TimeFrame) Filter(keep func(*Object) bool) {
+	objects := make([]*Object, 0, len(tf.Objects))
+	for _, object := range tf.Objects {
+		if keep(object) {
+			objects = append(objects, object)
 		}
 	}
+	tf.Objects = objects
+	tf.index, tf.indexed = nil, nil
 }
 
-func (tf *TimeFrame) Write(writer *bufio.Writer, includeOffset bool) error {
-	if includeOffset {
-		_, err := writer.WriteString(fmt.Sprintf("#%F\n", tf.Offset))
-		if err != nil {
-			return err
+// sameObjects returns whether two slices have the same length and backing array
+func sameObjects(a, b []*Object) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// find returns the position of the object with the given id, or -1
+func (tf *TimeFrame) find(id uint64) int {
+	if tf.index != nil && sameObjects(tf.indexed, tf.Objects) {
+		idx, ok := tf.index[id]
+		if !ok {
+			return -1
+		}
+		if tf.Objects[idx].Id == id {
+			return idx
 		}
 	}
 
-	for _, object := range tf.Objects {
-		object.Write(writer)
+	// The index is missing or Objects was changed directly. The first object
+	//  with an id is found, like a scan over Objects would.
+	tf.index = make(map[uint64]int, len(tf.Objects))
+	for idx := len(tf.Objects) - 1; idx >= 0; idx-- {
+		tf.index[tf.Objects[idx].Id] = idx
 	}
+	tf.indexed = tf.Objects
 
-	return nil
+	if idx, ok := tf.index[id]; ok {
+		return idx
+	}
+	return -1
+}
+
+func (tf *TimeFrame) Write(writer *bufio.Writer, includeOffset bool) error {
+	e := getEncoder()
+	defer encoders.Put(e)
+
+	e.timeFrame(tf, includeOffset)
+	return e.flush(writer)
 }
 
 func (tf *TimeFrame) ToRaw() *RawTimeFrame {
 	lines := make([]string, len(tf.Objects))
-	idx := 0
-	for _, object := range tf.Objects {
+	for idx, object := range tf.Objects {
 		lines[idx] = object.Serialize()
-		idx += 1
 	}
 
 	return &RawTimeFrame{
@@ -208,313 +281,182 @@ func (tf *TimeFrame) ToRaw() *RawTimeFrame {
 	}
 }
 
-// Set updates the given property
-func (o *Object) Set(key string, value string) {
-	for _, property := range o.Properties {
-		if property.Key == key {
-			property.Value = value
-			return
-		}
-	}
-	o.Properties = append(o.Properties, &Property{Key: key, Value: value})
-}
-
 // Get returns a property (if one exists) for a given key
 func (o *Object) Get(key string) *Property {
-	for _, property := range o.Properties {
-		if property.Key == key {
-			return property
-		}
+	idx := o.find(key)
+	if idx < 0 {
+		return nil
 	}
-	return nil
+	return o.Properties[idx]
 }
 
-func (o *Object) Serialize() string {
-	if o.Deleted {
-		return fmt.Sprintf("-%x", o.Id)
-	}
-
-	var buffer []string
-	for _, property := range o.Properties {
-		buffer = append(buffer, fmt.Sprintf(
-			"%s=%s",
-			property.Key,
-			strings.Replace(strings.Replace(property.Value, "\n", "\\\n", -1), ",", "\\,", -1)),
-		)
+// Set updates the given property
+func (o *Object) Set(key string, value string) {
+	if idx := o.find(key); idx >= 0 {
+		o.Properties[idx].Value = value
+		return
 	}
-
-	return fmt.Sprintf("%x,%s", o.Id, strings.Join(buffer, ","))
+	o.add(&Property{Key: key, Value: value})
 }
 
-func (o *Object) Write(writer *bufio.Writer) error {
-	if o.Deleted {
-		_, err := writer.WriteString(fmt.Sprintf("-%x\n", o.Id))
-		return err
-	}
-
-	_, err := writer.WriteString(fmt.Sprintf("%x", o.Id))
-	if err != nil {
-		return err
+// Rename changes the key of a property (if one exists) keeping its position,
+//  replacing any other property with the new key
+func (o *Object) Rename(key string, newKey string) {
+	if key == newKey || o.find(key) < 0 {
+		return
 	}
 
-	if len(o.Properties) == 0 {
-		_, err = writer.WriteString(",\n")
-		return err
-	}
-
-	for _, property := range o.Properties {
-		_, err = writer.WriteString(fmt.Sprintf(
-			",%s=%s",
-			property.Key,
-			strings.Replace(strings.Replace(property.Value, "\n", "\\\n", -1), ",", "\\,", -1)),
-		)
-		if err != nil {
-			return err
-		}
+	o.Delete(newKey)
+	idx := o.find(key)
+	o.Properties[idx].Key = newKey
+	if o.index != nil {
+		delete(o.index, key)
+		o.index[newKey] = idx
 	}
-
-	_, err = writer.WriteString("\n")
-	return err
 }
 
-func (r *Reader) parseObject(object *Object, data string) error {
-	var parts []string
-
-	if strings.Contains(data, `\,`) {
-		var err error
-		parts, err = splitPropertyTokens(data)
-		if err != nil {
-			return err
-		}
-	} else {
-		// Fast-er path
-		parts = strings.Split(data, ",")
-	}
-
-	for _, part := range parts {
-		if len(part) == 0 {
-			continue
-		}
-		partSplit := strings.SplitN(part, "=", 2)
-		if len(partSplit) != 2 {
-			return fmt.Errorf("Failed to parse property: `%v`", part)
-		}
-
-		object.Properties = append(object.Properties, &Property{Key: partSplit[0], Value: partSplit[1]})
+// Delete removes a property (if one exists) for a given key. A new slice is
+//  used so a loop over Properties still visits every property.
+func (o *Object) Delete(key string) {
+	idx := o.find(key)
+	if idx < 0 {
+		return
 	}
 
-	return nil
+	properties := make([]*Property, 0, len(o.Properties)-1)
+	properties = append(properties, o.Properties[:idx]...)
+	o.Properties = append(properties, o.Properties[idx+1:]...)
+	o.index, o.indexed = nil, nil
 }
 
-// ProcessTimeFrames concurrently processes time frames from within the ACMI file,
-//  producing them to an output channel. If your use case requires strong ordering
-//  and you do not wish to implement this guarantee on the consumer side, you must
-//  set the concurrency to 1.
-func (r *Reader) ProcessTimeFrames(concurrency int, timeFrame chan<- *TimeFrame) error {
-	bufferChan := make(chan []byte)
-
-	var wg sync.WaitGroup
-	for i := 0; i < concurrency; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			for {
-				data, ok := <-bufferChan
-				if data == nil || !ok {
-					return
-				}
-
-				tf := NewTimeFrame()
-				err := r.parseTimeFrame(data, tf, true)
-				if err != nil && err != io.EOF {
-					fmt.Printf("Failed to process time frame: (%v) %v\n", string(data), err)
-					close(timeFrame)
-					return
-				}
-
-				timeFrame <- tf
-			}
-		}()
+// Clone returns a deep copy of the object
+func (o *Object) Clone() *Object {
+	clone := &Object{
+		Id:         o.Id,
+		Properties: make([]*Property, len(o.Properties)),
+		Deleted:    o.Deleted,
 	}
-
-	err := r.timeFrameProducer(bufferChan)
-	for i := 0; i < concurrency; i++ {
-		bufferChan <- nil
+	for idx, property := range o.Properties {
+		clone.Properties[idx] = &Property{Key: property.Key, Value: property.Value}
 	}
-
-	wg.Wait()
-	close(timeFrame)
-	return err
+	return clone
 }
 
-func (r *Reader) timeFrameProducer(buffs chan<- []byte) error {
-	var buf []byte
-	for {
-		line, err := r.reader.ReadBytes('\n')
-		if err == io.EOF {
-			buffs <- buf
-			return nil
-		} else if err != nil {
-			return err
-		}
-
-		if line[0] != '#' {
-			buf = append(buf, line...)
-			continue
-		}
-
-		if len(buf) > 0 {
-			buffs <- buf
-		}
-		buf = line
+// add appends a property, the object must not already contain its key
+func (o *Object) add(property *Property) {
+	valid := o.index != nil && sameProperties(o.indexed, o.Properties)
+	o.Properties = append(o.Properties, property)
+	if valid {
+		o.index[property.Key] = len(o.Properties) - 1
+		o.indexed = o.Properties
 	}
 }
 
-func (r *Reader) parseTimeFrame(data []byte, timeFrame *TimeFrame, parseOffset bool) error {
-	reader := bufio.NewReader(bytes.NewBuffer(data))
-	return r.readTimeFrame(reader, timeFrame, parseOffset)
+// sameProperties returns whether two slices have the same length and backing
+//  array
+func sameProperties(a, b []*Property) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
 }
 
-func (r *Reader) readTimeFrame(reader *bufio.Reader, timeFrame *TimeFrame, parseOffset bool) error {
-	if parseOffset {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			return err
+// find returns the position of the property with the given key, or -1
+func (o *Object) find(key string) int {
+	if len(o.Properties) <= objectIndexThreshold {
+		for idx, property := range o.Properties {
+			if property.Key == key {
+				return idx
+			}
 		}
+		return -1
+	}
 
-		if len(line) == 0 || line[0] != '#' {
-			return fmt.Errorf("Expected time frame offset, found `%v`", line)
+	if o.index != nil && sameProperties(o.indexed, o.Properties) {
+		idx, ok := o.index[key]
+		if !ok {
+			return -1
 		}
-
-		offset, err := strconv.ParseFloat(line[1:len(line)-1], 64)
-		if err != nil {
-			return err
+		if o.Properties[idx].Key == key {
+			return idx
 		}
-
-		timeFrame.Offset = offset
 	}
 
-	timeFrameObjectCache := make(map[uint64]*Object)
-
-	for {
-		buffer := ""
-
-		nextLinePrefix, err := reader.Peek(1)
-		if err != nil {
-			return err
-		}
+	// The index is missing or Properties was changed directly. The first
+	//  property with a key is found, like a scan over Properties would.
+	o.index = make(map[string]int, len(o.Properties))
+	for idx := len(o.Properties) - 1; idx >= 0; idx-- {
+		o.index[o.Properties[idx].Key] = idx
+	}
+	o.indexed = o.Properties
 
-		if nextLinePrefix[0] == '#' {
-			break
-		}
+	if idx, ok := o.index[key]; ok {
+		return idx
+	}
+	return -1
+}
 
-		for {
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				return err
-			}
+// Tags returns the tags from the objects `Type` property
+func (o *Object) Tags() []string {
+	objectType := o.Get("Type")
+	if objectType == nil || objectType.Value == "" {
+		return nil
+	}
+	return strings.Split(objectType.Value, "+")
+}
 
-			buffer = buffer + strings.TrimSuffix(line, "\n")
-			if !strings.HasSuffix(buffer, "\\") {
+// HasTags returns whether the objects `Type` property contains all of the given tags
+func (o *Object) HasTags(tags ...string) bool {
+	objectTags := o.Tags()
+	for _, tag := range tags {
+		found := false
+		for _, objectTag := range objectTags {
+			if objectTag == tag {
+				found = true
 				break
 			}
-
-			buffer = buffer[:len(buffer)-1] + "\n"
 		}
 
-		lineParts := strings.SplitN(buffer, ",", 2)
-		if lineParts[0][0] == '-' {
-			objectId, err := strconv.ParseUint(lineParts[0][1:], 16, 64)
-			if err != nil {
-				return err
-			}
-
-			if timeFrameObjectCache[objectId] != nil {
-				timeFrameObjectCache[objectId].Deleted = true
-			} else {
-				object := &Object{Id: objectId, Properties: make([]*Property, 0), Deleted: true}
-				timeFrameObjectCache[objectId] = object
-				timeFrame.Objects = append(timeFrame.Objects, object)
-			}
-		} else {
-			objectId, err := strconv.ParseUint(lineParts[0], 16, 64)
-			if err != nil {
-				return err
-			}
-			object, ok := timeFrameObjectCache[objectId]
-			if !ok {
-				object = &Object{
-					Id:         objectId,
-					Properties: make([]*Property, 0),
-				}
-				timeFrameObjectCache[objectId] = object
-				timeFrame.Objects = append(timeFrame.Objects, object)
-			}
-
-			err = r.parseObject(object, lineParts[1])
-			if err != nil {
-				return err
-			}
+		if !found {
+			return false
 		}
-
 	}
-
-	return nil
+	return true
 }
 
-func (r *Reader) readHeader() error {
-	foundFileType := false
-	foundFileVersion := false
+func (o *Object) Serialize() string {
+	e := getEncoder()
+	defer encoders.Put(e)
 
-	for {
-		line, err := r.reader.ReadString('\n')
-		if err != nil {
-			return err
-		}
+	e.buffer = appendObject(e.buffer, o)
+	return string(e.buffer)
+}
+
+func (o *Object) Write(writer *bufio.Writer) error {
+	e := getEncoder()
+	defer encoders.Put(e)
 
-		line = strings.TrimSuffix(line, "\n")
+	e.buffer = appendObject(e.buffer, o)
+	e.buffer = append(e.buffer, '\n')
+	return e.flush(writer)
+}
 
-		matches := keyRe.FindAllStringSubmatch(line, -1)
-		if len(matches) != 1 {
-			return fmt.Errorf("Failed to parse key pair from line: `%v`", line)
+// readReferencePoint parses the optional reference longitude and latitude which
+//  all object transforms are offset from.
+func (h *Header) readReferencePoint(globalObj *Object) error {
+	for _, key := range []string{"ReferenceLongitude", "ReferenceLatitude"} {
+		property := globalObj.Get(key)
+		if property == nil {
+			continue
 		}
 
-		if matches[0][1] == "FileType" && !foundFileType {
-			foundFileType = true
-			r.Header.FileType = matches[0][1]
-		} else if matches[0][1] == "FileVersion" && !foundFileVersion {
-			foundFileVersion = true
-			r.Header.FileVersion = matches[0][2]
+		value, err := strconv.ParseFloat(property.Value, 64)
+		if err != nil {
+			return fmt.Errorf("Failed to parse %v: `%v`", key, property.Value)
 		}
 
-		if foundFileType && foundFileVersion {
-			break
+		if key == "ReferenceLongitude" {
+			h.ReferenceLongitude = value
+		} else {
+			h.ReferenceLatitude = value
 		}
 	}
-
-	r.Header.InitialTimeFrame = *NewTimeFrame()
-	err := r.readTimeFrame(r.reader, &r.Header.InitialTimeFrame, false)
-	if err != nil {
-		return err
-	}
-
-	globalObj := r.Header.InitialTimeFrame.Get(0)
-	if globalObj == nil {
-		return fmt.Errorf("No global object found in initial time frame")
-	}
-
-	referenceTimeProperty := globalObj.Get("ReferenceTime")
-	if referenceTimeProperty == nil {
-		return fmt.Errorf("Global object is missing ReferenceTime")
-	}
-
-	referenceTime, err := time.Parse("2006-01-02T15:04:05Z", referenceTimeProperty.Value)
-	if err != nil {
-		return fmt.Errorf("Failed to parse ReferenceTime: `%v`", referenceTimeProperty.Value)
-	}
-
-	r.Header.ReferenceTime = referenceTime
-
 	return nil
 }
@@ -0,0 +1,167 @@
+package tacview
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func objectIds(tf *TimeFrame) string {
+	ids := make([]string, 0, len(tf.Objects))
+	for _, object := range tf.Objects {
+		ids = append(ids, fmt.Sprintf("%x", object.Id))
+	}
+	return strings.Join(ids, ",")
+}
+
+func propertyNames(object *Object) string {
+	keys := make([]string, 0, len(object.Properties))
+	for _, property := range object.Properties {
+		keys = append(keys, property.Key)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestTimeFrameDeleteWhileIterating(t *testing.T) {
+	tf := NewTimeFrame()
+	for id := uint64(1); id <= 1000; id++ {
+		tf.Add(NewObject(id))
+	}
+
+	visited := 0
+	for _, object := range tf.Objects {
+		visited++
+		if object.Id%2 == 0 || object.Id%3 == 0 {
+			tf.Delete(object.Id)
+		}
+	}
+	if visited != 1000 {
+		t.Fatalf("Expected to visit 1000 objects, visited %v.", visited)
+	}
+
+	if len(tf.Objects) != 333 {
+		t.Fatalf("Expected 333 objects to remain, found %v.", len(tf.Objects))
+	}
+	for idx, object := range tf.Objects {
+		if object.Id%2 == 0 || object.Id%3 == 0 || tf.Get(object.Id) != object {
+			t.Fatalf("Unexpected object %v at %v.", object.Id, idx)
+		}
+	}
+	if tf.Get(2) != nil || tf.Get(6) != nil {
+		t.Fatal("Expected deleted objects to be removed")
+	}
+}
+
+func TestTimeFrameOrder(t *testing.T) {
+	tf := NewTimeFrame(NewObject(5), NewObject(3), NewObject(1), NewObject(2))
+	tf.Delete(5)
+	tf.Delete(1)
+	tf.Add(NewObject(4))
+	tf.Add(NewObject(1))
+	replaced := NewObject(3, Property{Key: "Name", Value: "A"})
+	tf.Add(replaced)
+
+	if ids := objectIds(tf); ids != "3,2,4,1" {
+		t.Fatalf("Expected objects 3,2,4,1, found %v.", ids)
+	}
+	if tf.Get(3) != replaced {
+		t.Fatal("Expected the object to be replaced in place")
+	}
+
+	tf.Filter(func(object *Object) bool { return object.Id != 2 })
+	if ids := objectIds(tf); ids != "3,4,1" || tf.Get(2) != nil || tf.Get(1) == nil {
+		t.Fatalf("Expected objects 3,4,1, found %v.", ids)
+	}
+}
+
+func TestTimeFrameObjectsChanged(t *testing.T) {
+	tf := NewTimeFrame(NewObject(1), NewObject(2), NewObject(3))
+	if tf.Get(3) == nil {
+		t.Fatal("Expected object 3 to be found")
+	}
+
+	// Appended, assigned and reordered directly rather than through Add
+	tf.Objects = append(tf.Objects, NewObject(4))
+	if tf.Get(4) == nil {
+		t.Fatal("Expected an appended object to be found")
+	}
+
+	tf.Objects = []*Object{NewObject(5), NewObject(6), NewObject(7), NewObject(8)}
+	if tf.Get(1) != nil || tf.Get(8) == nil {
+		t.Fatalf("Expected the assigned objects, found %v.", objectIds(tf))
+	}
+
+	tf.Objects[0], tf.Objects[3] = tf.Objects[3], tf.Objects[0]
+	if tf.Get(8) != tf.Objects[0] || tf.Get(5) != tf.Objects[3] {
+		t.Fatal("Expected reordered objects to be found at their new position")
+	}
+}
+
+func TestObjectProperties(t *testing.T) {
+	object := NewObject(1)
+	for idx := 0; idx < 20; idx++ {
+		object.Set(fmt.Sprintf("Key%v", idx), fmt.Sprint(idx))
+	}
+	object.Set("Key3", "three")
+
+	if object.Get("Key3").Value != "three" || object.Get("Key19").Value != "19" {
+		t.Fatal("Expected properties to be found by key")
+	}
+	if object.Get("Missing") != nil || object.Get("Key20") != nil {
+		t.Fatal("Expected unknown properties to be missing")
+	}
+
+	object.Delete("Key0")
+	object.Delete("Key10")
+	object.Rename("Key1", "Key5")
+	object.Rename("Key2", "Renamed")
+
+	expected := "Key5,Renamed,Key3,Key4,Key6,Key7,Key8,Key9,Key11,Key12,Key13,Key14,Key15,Key16,Key17,Key18,Key19"
+	if keys := propertyNames(object); keys != expected {
+		t.Fatalf("Expected properties %v, found %v.", expected, keys)
+	}
+	if object.Get("Key5").Value != "1" || object.Get("Renamed").Value != "2" || object.Get("Key2") != nil {
+		t.Fatal("Expected renamed properties to keep their values")
+	}
+	if object.Get("Key11").Value != "11" || object.Get("Key10") != nil {
+		t.Fatal("Expected properties to be found after deleting")
+	}
+}
+
+func TestObjectPropertiesChanged(t *testing.T) {
+	object := NewObject(1)
+	for idx := 0; idx < 20; idx++ {
+		object.Set(fmt.Sprintf("Key%v", idx), fmt.Sprint(idx))
+	}
+	if object.Get("Key19") == nil {
+		t.Fatal("Expected Key19 to be found")
+	}
+
+	object.Properties = append(object.Properties, &Property{Key: "Appended", Value: "A"})
+	if object.Get("Appended") == nil || object.Get("Appended").Value != "A" {
+		t.Fatal("Expected an appended property to be found")
+	}
+
+	properties := make([]*Property, 0, len(object.Properties))
+	for _, property := range object.Properties {
+		if property.Key != "Key0" {
+			properties = append(properties, property)
+		}
+	}
+	properties = append(properties, &Property{Key: "Assigned", Value: "B"})
+	object.Properties = properties
+	if object.Get("Key0") != nil || object.Get("Assigned") == nil || object.Get("Key1").Value != "1" {
+		t.Fatal("Expected the assigned properties")
+	}
+}
+
+func TestParseMergesProperties(t *testing.T) {
+	tf, err := (&RawTimeFrame{Contents: []string{"1,T=1|2|3,Name=A", "1,Name=B,Color=Red"}}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serialized := tf.Get(1).Serialize(); serialized != "1,T=1|2|3,Name=B,Color=Red" {
+		t.Fatalf("Expected merged properties, serialized %q.", serialized)
+	}
+}
@@ -0,0 +1,65 @@
+package tacview
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Transform describes the decoded `T` property of an object. Longitude and
+//  latitude are absolute (already offset by the header reference point) and
+//  altitude is in meters.
+type Transform struct {
+	Longitude float64
+	Latitude  float64
+	Altitude  float64
+	Roll      float64
+	Pitch     float64
+	Yaw       float64
+	U         float64
+	V         float64
+	Heading   float64
+}
+
+// Update applies a raw `T` property value to the transform, leaving any omitted
+//  components untouched. Longitude and latitude values are offset by the given
+//  reference point.
+func (t *Transform) Update(value string, referenceLongitude, referenceLatitude float64) error {
+	parts := strings.Split(value, "|")
+
+	var fields []*float64
+	switch len(parts) {
+	case 3:
+		fields = []*float64{&t.Longitude, &t.Latitude, &t.Altitude}
+	case 5:
+		fields = []*float64{&t.Longitude, &t.Latitude, &t.Altitude, &t.U, &t.V}
+	case 6:
+		fields = []*float64{&t.Longitude, &t.Latitude, &t.Altitude, &t.Roll, &t.Pitch, &t.Yaw}
+	case 9:
+		fields = []*float64{&t.Longitude, &t.Latitude, &t.Altitude, &t.Roll, &t.Pitch, &t.Yaw, &t.U, &t.V, &t.Heading}
+	default:
+		return fmt.Errorf("Invalid transform `%v`", value)
+	}
+
+	for idx, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return fmt.Errorf("Failed to parse transform component `%v`: %v", part, err)
+		}
+
+		switch idx {
+		case 0:
+			number += referenceLongitude
+		case 1:
+			number += referenceLatitude
+		}
+
+		*fields[idx] = number
+	}
+
+	return nil
+}
@@ -1,25 +1,69 @@
 package tacview
 
 import (
+	"context"
 	"io"
+	"sort"
 	"time"
 )
 
+// FrameFilter rewrites time frames in place. Frames are always provided in order.
+type FrameFilter interface {
+	Filter(*TimeFrame) error
+}
+
 func TrimRaw(reader RawReader, writer RawWriter, start, end float64) error {
+	return TrimRawFilteredContext(context.Background(), reader, writer, start, end, nil)
+}
+
+// TrimRawContext trims like TrimRaw, stopping with the context's error once the
+//  context is done
+func TrimRawContext(ctx context.Context, reader RawReader, writer RawWriter, start, end float64) error {
+	return TrimRawFilteredContext(ctx, reader, writer, start, end, nil)
+}
+
+// TrimRawFiltered trims like TrimRaw but passes every time frame (including the
+//  header's initial time frame) through a filter created from the input header.
+//  Providing a filter requires each time frame to be parsed.
+func TrimRawFiltered(reader RawReader, writer RawWriter, start, end float64, newFilter func(*Header) FrameFilter) error {
+	return TrimRawFilteredContext(context.Background(), reader, writer, start, end, newFilter)
+}
+
+// TrimRawFilteredContext trims like TrimRawFiltered, stopping with the context's
+//  error once the context is done
+func TrimRawFilteredContext(ctx context.Context, reader RawReader, writer RawWriter, start, end float64, newFilter func(*Header) FrameFilter) error {
 	header, err := reader.ReadHeader()
 	if err != nil {
 		return err
 	}
 
+	var filter FrameFilter
+	if newFilter != nil {
+		filter = newFilter(header)
+		err = filter.Filter(&header.InitialTimeFrame)
+		if err != nil {
+			return err
+		}
+	}
+
 	aliveObjects := make(map[uint64]*Object)
 
+	// The first time frame at or after the start is written as is
+	var next *RawTimeFrame
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		rawTimeFrame, err := reader.ReadRawTimeFrame(-1)
-		if err != nil {
+		if err == io.EOF {
+			break
+		} else if err != nil {
 			return err
 		}
 
 		if rawTimeFrame.Offset >= start {
+			next = rawTimeFrame
 			break
 		}
 		parsed, err := rawTimeFrame.Parse()
@@ -27,6 +71,13 @@ func TrimRaw(reader RawReader, writer RawWriter, start, end float64) error {
 			return err
 		}
 
+		if filter != nil {
+			err = filter.Filter(parsed)
+			if err != nil {
+				return err
+			}
+		}
+
 		for _, object := range parsed.Objects {
 			existingObject := aliveObjects[object.Id]
 			if object.Deleted && existingObject != nil {
@@ -36,6 +87,11 @@ func TrimRaw(reader RawReader, writer RawWriter, start, end float64) error {
 					aliveObjects[object.Id] = object
 				} else {
 					for _, newProp := range object.Properties {
+						// Transform updates may only contain some components
+						if existing := existingObject.Get("T"); newProp.Key == "T" && existing != nil {
+							existing.Value = mergeTransformValue(existing.Value, newProp.Value)
+							continue
+						}
 						existingObject.Set(newProp.Key, newProp.Value)
 					}
 				}
@@ -43,39 +99,72 @@ func TrimRaw(reader RawReader, writer RawWriter, start, end float64) error {
 		}
 	}
 
-	referenceTime := header.ReferenceTime.Add(time.Second * time.Duration(start))
+	referenceTime := header.ReferenceTime.Add(time.Duration(start * float64(time.Second)))
 
 	// We copy the initial time frame completely
 	initialTimeFrame := NewTimeFrame()
 	initialTimeFrame.Offset = 0
-	initialTimeFrame.Objects = header.InitialTimeFrame.Objects
+	objects := append([]*Object{}, header.InitialTimeFrame.Objects...)
+	for idx, object := range objects {
+		if object.Id == 0 {
+			objects[idx] = object.Clone()
+			objects[idx].Set("ReferenceTime", referenceTime.Format(time.RFC3339Nano))
+		}
+	}
+	alive := make([]*Object, 0, len(aliveObjects))
 	for _, object := range aliveObjects {
-		initialTimeFrame.Objects = append(initialTimeFrame.Objects, object)
+		alive = append(alive, object)
 	}
+	sort.Slice(alive, func(i, j int) bool { return alive[i].Id < alive[j].Id })
+	objects = append(objects, alive...)
+	initialTimeFrame.Objects = objects
 
 	err = writer.WriteHeader(&Header{
-		FileType:         header.FileType,
-		FileVersion:      header.FileVersion,
-		ReferenceTime:    referenceTime,
-		InitialTimeFrame: *initialTimeFrame,
+		FileType:           header.FileType,
+		FileVersion:        header.FileVersion,
+		ReferenceTime:      referenceTime,
+		ReferenceLongitude: header.ReferenceLongitude,
+		ReferenceLatitude:  header.ReferenceLatitude,
+		InitialTimeFrame:   *initialTimeFrame,
 	})
 	if err != nil {
 		return err
 	}
 
 	for {
-		rawTimeFrame, err := reader.ReadRawTimeFrame(-1)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rawTimeFrame := next
+		if rawTimeFrame == nil {
+			var err error
+			rawTimeFrame, err = reader.ReadRawTimeFrame(-1)
 			if err == io.EOF {
 				break
+			} else if err != nil {
+				return err
 			}
-			return err
 		}
+		next = nil
 
 		if rawTimeFrame.Offset > end {
 			break
 		}
 
+		if filter != nil {
+			parsed, err := rawTimeFrame.Parse()
+			if err != nil {
+				return err
+			}
+
+			err = filter.Filter(parsed)
+			if err != nil {
+				return err
+			}
+			rawTimeFrame = parsed.ToRaw()
+		}
+
 		rawTimeFrame.Offset = rawTimeFrame.Offset - start
 		writer.Write(rawTimeFrame)
 	}
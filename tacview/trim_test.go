@@ -0,0 +1,69 @@
+package tacview
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTrimRaw(t *testing.T) {
+	cases := []struct {
+		name       string
+		input      string
+		start, end float64
+		expected   string
+	}{
+		{
+			name:  "alive objects move into the header",
+			input: "#1\n2,T=1|2|3,Name=B\n1,T=4|5|6,Name=A\n#2\n1,T=7||\n#3\n-2\n#4\n1,Name=C\n",
+			start: 2.5,
+			end:   10,
+			expected: "0,ReferenceTime=2021-07-24T04:00:02.5Z,ReferenceLongitude=40,ReferenceLatitude=41\n" +
+				"1,T=7|5|6,Name=A\n2,T=1|2|3,Name=B\n#0.5\n-2\n#1.5\n1,Name=C\n",
+		},
+		{
+			name:  "the first time frame after the start is kept",
+			input: "#1\n1,T=1|2|3\n#2\n1,T=4|5|6\n#3\n1,T=7|8|9\n",
+			start: 1.5,
+			end:   2.5,
+			expected: "0,ReferenceTime=2021-07-24T04:00:01.5Z,ReferenceLongitude=40,ReferenceLatitude=41\n" +
+				"1,T=1|2|3\n#0.5\n1,T=4|5|6\n",
+		},
+		{
+			name:  "a time frame at the start keeps its offset",
+			input: "#1\n1,T=1|2|3\n#2\n1,T=4|5|6\n#3\n1,T=7|8|9\n",
+			start: 2,
+			end:   3,
+			expected: "0,ReferenceTime=2021-07-24T04:00:02Z,ReferenceLongitude=40,ReferenceLatitude=41\n" +
+				"1,T=1|2|3\n#0\n1,T=4|5|6\n#1\n1,T=7|8|9\n",
+		},
+		{
+			name:     "start after the end of the recording",
+			input:    "#1\n1,T=1|2|3\n#2\n-1\n",
+			start:    5,
+			end:      10,
+			expected: "0,ReferenceTime=2021-07-24T04:00:05Z,ReferenceLongitude=40,ReferenceLatitude=41\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			parser, err := NewParser(strings.NewReader(parserTestHeader + parserTestGlobal + c.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output bytes.Buffer
+			err = TrimRaw(parser, NewRawWriter(&output), c.start, c.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			trimmed := strings.TrimPrefix(output.String(), "\ufeff"+parserTestHeader)
+			trimmed = strings.NewReplacer(".000000\n", "\n", "00000\n", "\n").Replace(trimmed)
+			if trimmed != c.expected {
+				t.Fatalf("Expected:\n%q\nwrote:\n%q", c.expected, trimmed)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package tacview
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Event describes a global event attached to the global object
+type Event struct {
+	Type    string
+	Objects []uint64
+	Text    string
+}
+
+// ParseEvent parses the value of an `Event` property
+func ParseEvent(value string) *Event {
+	parts := strings.Split(value, "|")
+
+	event := &Event{Type: parts[0]}
+	if len(parts) == 1 {
+		return event
+	}
+
+	// All fields between the type and the trailing text are object ids
+	for _, part := range parts[1 : len(parts)-1] {
+		id, err := strconv.ParseUint(part, 16, 64)
+		if err != nil {
+			continue
+		}
+		event.Objects = append(event.Objects, id)
+	}
+	event.Text = parts[len(parts)-1]
+
+	return event
+}
+
+// ObjectState describes the fully reconstructed state of an object
+type ObjectState struct {
+	// Object contains every property of the object merged from all time frames
+	Object       *Object
+	Transform    Transform
+	HasTransform bool
+	// SpawnTransform contains the first known transform of the object
+	SpawnTransform Transform
+	// Offset of the time frame the object was first seen in
+	Spawned float64
+	// Offset of the time frame the object was last updated in
+	Updated float64
+}
+
+// Id returns the objects id
+func (s *ObjectState) Id() uint64 {
+	return s.Object.Id
+}
+
+// World reconstructs the full state of every live object in a recording by
+//  applying time frames in order.
+type World struct {
+	Header *Header
+	Offset float64
+	// Global contains the merged properties of the global object
+	Global  *Object
+	Objects map[uint64]*ObjectState
+	// Created contains the objects created by the most recently applied time frame
+	Created []*ObjectState
+	// Removed contains the objects removed by the most recently applied time frame
+	Removed []*ObjectState
+	// Events contains the events emitted by the most recently applied time frame
+	Events []*Event
+}
+
+// NewWorld creates an empty World for the given header. The header's initial
+//  time frame is not applied automatically.
+func NewWorld(header *Header) *World {
+	return &World{
+		Header:  header,
+		Global:  NewObject(0),
+		Objects: make(map[uint64]*ObjectState),
+	}
+}
+
+// Get returns the state of a live object (if one exists) for a given object id
+func (w *World) Get(id uint64) *ObjectState {
+	return w.Objects[id]
+}
+
+// Apply merges a time frame into the world. Time frames must be applied in order.
+func (w *World) Apply(tf *TimeFrame) error {
+	w.Offset = tf.Offset
+	w.Created = w.Created[:0]
+	w.Removed = w.Removed[:0]
+	w.Events = w.Events[:0]
+
+	for _, object := range tf.Objects {
+		if object.Id == 0 {
+			for _, property := range object.Properties {
+				if property.Key == "Event" {
+					w.Events = append(w.Events, ParseEvent(property.Value))
+					continue
+				}
+				w.Global.Set(property.Key, property.Value)
+			}
+			continue
+		}
+
+		state, ok := w.Objects[object.Id]
+		if object.Deleted {
+			if ok {
+				err := w.update(state, object)
+				if err != nil {
+					return err
+				}
+				delete(w.Objects, object.Id)
+				w.Removed = append(w.Removed, state)
+			}
+			continue
+		}
+
+		if !ok {
+			state = &ObjectState{
+				Object:  &Object{Id: object.Id, Properties: make([]*Property, 0, len(object.Properties))},
+				Spawned: tf.Offset,
+			}
+			w.Objects[object.Id] = state
+			w.Created = append(w.Created, state)
+		}
+
+		err := w.update(state, object)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *World) update(state *ObjectState, object *Object) error {
+	state.Updated = w.Offset
+
+	for _, property := range object.Properties {
+		if property.Key == "T" {
+			err := state.Transform.Update(property.Value, w.Header.ReferenceLongitude, w.Header.ReferenceLatitude)
+			if err != nil {
+				return err
+			}
+
+			if !state.HasTransform {
+				state.SpawnTransform = state.Transform
+			}
+			state.HasTransform = true
+
+			// Merge the transform components so the stored property always
+			//  describes the full transform.
+			if existing := state.Object.Get("T"); existing != nil {
+				existing.Value = mergeTransformValue(existing.Value, property.Value)
+				continue
+			}
+		}
+		state.Object.Set(property.Key, property.Value)
+	}
+
+	return nil
+}
+
+func mergeTransformValue(existing string, update string) string {
+	existingParts := strings.Split(existing, "|")
+	updateParts := strings.Split(update, "|")
+	if len(existingParts) != len(updateParts) {
+		return update
+	}
+
+	for idx, part := range updateParts {
+		if part != "" {
+			existingParts[idx] = part
+		}
+	}
+	return strings.Join(existingParts, "|")
+}
@@ -1,9 +1,7 @@
 package tacview
 
 import (
-	"fmt"
 	"io"
-	"strings"
 )
 
 type HeaderWriter interface {
@@ -23,32 +21,23 @@ type ZWriter interface {
 }
 
 type rawWriter struct {
-	out io.Writer
+	out     io.Writer
+	encoder encoder
 }
 
 func NewRawWriter(dest io.Writer) RawWriter {
-	return &rawWriter{dest}
+	return &rawWriter{out: dest}
 }
 
 func (r *rawWriter) Write(tf *RawTimeFrame) error {
-	data := []byte{}
-	if tf.Offset != 0 {
-		data = []byte(fmt.Sprintf("#%F\n", tf.Offset))
-	}
-	data = append(data, []byte(strings.Join(tf.Contents, "\n"))...)
-
-	_, err := r.out.Write(append(data, '\n'))
-	return err
+	r.encoder.rawTimeFrame(tf, true)
+	return r.encoder.flush(r.out)
 }
 
 // TODO
 func (r *rawWriter) WriteHeader(header *Header) error {
-	_, err := r.out.Write(bomHeader)
-	if err != nil {
-		return err
-	}
-
-	r.out.Write([]byte(fmt.Sprintf("FileType=%s\n", header.FileType)))
-	r.out.Write([]byte(fmt.Sprintf("FileVersion=%s\n", header.FileVersion)))
-	return r.Write(header.InitialTimeFrame.ToRaw())
+	r.encoder.buffer = append(r.encoder.buffer, bomHeader...)
+	r.encoder.header(header.FileType, header.FileVersion)
+	r.encoder.rawTimeFrame(header.InitialTimeFrame.ToRaw(), false)
+	return r.encoder.flush(r.out)
 }
@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/b1naryth1ef/jambon/ops"
+	"github.com/b1naryth1ef/jambon/tacview"
 )
 
 func openReadableTacView(path string) (io.ReadCloser, error) {
@@ -68,3 +71,67 @@ func openWritableTacView(path string) (io.WriteCloser, error) {
 
 	return file, nil
 }
+
+// inputFiles are the ACMI files read by a command, announcing each file and
+//  displaying a progress bar while it is read
+type inputFiles struct {
+	paths []string
+	files []io.ReadCloser
+	bar   *progressBar
+}
+
+// openInputFiles opens every path, closing any opened files on failure
+func openInputFiles(paths ...string) (*inputFiles, error) {
+	inputs := &inputFiles{paths: paths}
+	for _, path := range paths {
+		file, err := openReadableTacView(path)
+		if err != nil {
+			inputs.Close()
+			return nil, err
+		}
+		inputs.files = append(inputs.files, file)
+	}
+	return inputs, nil
+}
+
+func (i *inputFiles) readers() []io.Reader {
+	readers := make([]io.Reader, len(i.files))
+	for idx, file := range i.files {
+		readers[idx] = file
+	}
+	return readers
+}
+
+// options returns the ops options used to read the files
+func (i *inputFiles) options(concurrency int) ops.Options {
+	return ops.Options{
+		Concurrency: concurrency,
+		OnInput: func(index int) {
+			i.bar.finish()
+			fmt.Fprintf(os.Stderr, "Processing file %v...\n", i.paths[index])
+			i.bar = newProgressBar(tacViewSize(i.paths[index]))
+		},
+		Progress: func(progress tacview.Progress) {
+			i.bar.update(progress)
+			if progress.Done {
+				i.bar.finish()
+				i.bar = nil
+			}
+		},
+		ProgressInterval: progressInterval,
+	}
+}
+
+func (i *inputFiles) Close() error {
+	i.bar.finish()
+	i.bar = nil
+
+	var result error
+	for _, file := range i.files {
+		err := file.Close()
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}